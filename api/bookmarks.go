@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"badger_explorer_core/db"
+)
+
+type ListBookmarksResult struct {
+	Bookmarks []db.KeyStatus `json:"bookmarks"` // In pin order; missing keys have exists false
+}
+
+// handleListBookmarks returns the pinned keys of the open DB with their current values.
+func (h *Handler) handleListBookmarks() (interface{}, error) {
+	if h.cfg == nil {
+		return nil, errNoConfig
+	}
+	if !h.dbClient.IsOpen() {
+		return nil, db.ErrNotOpen
+	}
+	keys := h.cfg.GetBookmarks(h.dbClient.GetPath())
+	statuses, err := h.dbClient.LookupKeys(keys, db.ListKeysOptions{PreviewCodec: h.codecFor})
+	if err != nil {
+		return nil, err
+	}
+	return ListBookmarksResult{Bookmarks: statuses}, nil
+}
+
+type BookmarkParams struct {
+	Key string `json:"key"`
+}
+
+// handleAddBookmark pins a key of the open DB. Pinning a pinned key does nothing.
+func (h *Handler) handleAddBookmark(params json.RawMessage) (interface{}, error) {
+	var p BookmarkParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if h.cfg == nil {
+		return nil, errNoConfig
+	}
+	if !h.dbClient.IsOpen() {
+		return nil, db.ErrNotOpen
+	}
+	if p.Key == "" {
+		return nil, fmt.Errorf("key cannot be empty")
+	}
+	if !h.cfg.AddBookmark(h.dbClient.GetPath(), p.Key) {
+		return nil, nil
+	}
+	return nil, h.cfg.Save()
+}
+
+func (h *Handler) handleRemoveBookmark(params json.RawMessage) (interface{}, error) {
+	var p BookmarkParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if h.cfg == nil {
+		return nil, errNoConfig
+	}
+	if !h.dbClient.IsOpen() {
+		return nil, db.ErrNotOpen
+	}
+	if !h.cfg.RemoveBookmark(h.dbClient.GetPath(), p.Key) {
+		return nil, fmt.Errorf("key is not bookmarked: %s", p.Key)
+	}
+	return nil, h.cfg.Save()
+}
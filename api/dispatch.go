@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Default dispatcher sizes, used unless the config sets others.
+const (
+	DefaultWorkers   = 8
+	DefaultQueueSize = 64
+)
+
+// dispatcher runs requests on a bounded pool of goroutines.
+//
+// Requests sharing an order key (e.g. the chunks of one upload) run one at a
+// time in arrival order. Exclusive requests (open_db, close_db) wait for
+// everything before them and run alone. When workers and queue are all in use,
+// submit blocks, which stops the reader and pushes back on the client.
+type dispatcher struct {
+	admit chan struct{} // Held from submit until the request finishes
+	run   chan struct{} // Held while the request executes
+	wg    sync.WaitGroup
+
+	mu    sync.Mutex
+	lanes map[string][]func() // Pending requests per order key
+}
+
+func newDispatcher(workers, queueSize int) *dispatcher {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	return &dispatcher{
+		admit: make(chan struct{}, workers+queueSize),
+		run:   make(chan struct{}, workers),
+		lanes: make(map[string][]func()),
+	}
+}
+
+// submit schedules fn. An empty key means no ordering constraint.
+func (d *dispatcher) submit(key string, fn func()) {
+	d.admit <- struct{}{}
+	d.wg.Add(1)
+
+	task := func() {
+		d.run <- struct{}{}
+		fn()
+		<-d.run
+		<-d.admit
+		d.wg.Done()
+	}
+
+	if key == "" {
+		go task()
+		return
+	}
+
+	d.mu.Lock()
+	pending, busy := d.lanes[key]
+	d.lanes[key] = append(pending, task)
+	d.mu.Unlock()
+	if !busy {
+		go d.drainLane(key)
+	}
+}
+
+// drainLane runs the requests of one order key until none are left.
+func (d *dispatcher) drainLane(key string) {
+	for {
+		d.mu.Lock()
+		pending := d.lanes[key]
+		if len(pending) == 0 {
+			delete(d.lanes, key)
+			d.mu.Unlock()
+			return
+		}
+		task := pending[0]
+		d.lanes[key] = pending[1:]
+		d.mu.Unlock()
+
+		task()
+	}
+}
+
+// exclusive waits for all submitted requests and then runs fn on the caller's goroutine.
+func (d *dispatcher) exclusive(fn func()) {
+	d.wait()
+	fn()
+}
+
+// wait blocks until every submitted request has finished.
+func (d *dispatcher) wait() {
+	d.wg.Wait()
+}
+
+// orderKey returns the key that serializes req with related requests.
+// Requests of one upload session share the ID of their put_value request, and
+// unsubscribe follows the subscribe it ends.
+func orderKey(req Request) string {
+	switch req.Type {
+	case TypePutValue:
+		return "upload:" + req.ID
+	case TypePutChunk, TypePutCommit, TypeUploadAbort:
+		var p struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(req.Params, &p)
+		return "upload:" + p.ID
+	case TypeSubscribe:
+		return "subscription:" + req.ID
+	case TypeUnsubscribe:
+		var p struct {
+			ID string `json:"id"`
+		}
+		json.Unmarshal(req.Params, &p)
+		return "subscription:" + p.ID
+	}
+	return ""
+}
+
+// isExclusive reports whether req changes which database is open.
+func isExclusive(req Request) bool {
+	return req.Type == TypeOpenDB || req.Type == TypeCloseDB
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+
+	"badger_explorer_core/db"
+)
+
+// Error codes of the native protocol. See docs/api_spec.md for the JSON-RPC mapping.
+const (
+	CodeInternal       = 1000 // Any error without a more specific code
+	CodeUnknownRequest = 1001
+	CodeInvalidParams  = 1002
+	CodeInvalidRequest = 1003 // The line is not a valid request
+	CodeFrameTooLarge  = 1004
+	CodeReadError      = 1005
+	CodeUnauthorized   = 1006 // Missing or wrong token on the HTTP server
+	CodeForbidden      = 1007 // The request is not permitted on this connection
+
+	CodeNotOpen      = 1100
+	CodeAlreadyOpen  = 1101
+	CodePathNotFound = 1102
+	CodeLocked       = 1103
+	CodeKeyNotFound  = 1104
+	CodeReadOnly     = 1105
+	CodeConflict     = 1106
+	CodeInvalidRegex = 1107
+	CodeTooBig       = 1108
+	CodeKeyExists    = 1109
+)
+
+// errSharedDB is returned by the requests in sharedRefused when other clients share the DB.
+var errSharedDB = errors.New("request not permitted while the DB is shared with other clients")
+
+// dbErrorCodes maps the db package errors to their codes.
+var dbErrorCodes = []struct {
+	err  error
+	code int
+}{
+	{db.ErrNotOpen, CodeNotOpen},
+	{db.ErrAlreadyOpen, CodeAlreadyOpen},
+	{db.ErrPathNotFound, CodePathNotFound},
+	{db.ErrLocked, CodeLocked},
+	{db.ErrKeyNotFound, CodeKeyNotFound},
+	{db.ErrReadOnly, CodeReadOnly},
+	{db.ErrConflict, CodeConflict},
+	{db.ErrInvalidRegex, CodeInvalidRegex},
+	{db.ErrTooBig, CodeTooBig},
+	{db.ErrKeyExists, CodeKeyExists},
+}
+
+// ErrorData holds the details of an error, sent in the "data" field.
+type ErrorData struct {
+	Key     string `json:"key,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	Cause   string `json:"cause,omitempty"` // Message of the underlying error
+}
+
+// errorInfo returns the code and details for a handler error.
+func errorInfo(err error) (int, *ErrorData) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, errUnknownRequest):
+		return CodeUnknownRequest, nil
+	case errors.Is(err, errSharedDB):
+		return CodeForbidden, nil
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return CodeInvalidParams, &ErrorData{Cause: err.Error()}
+	}
+
+	code := CodeInternal
+	for _, c := range dbErrorCodes {
+		if errors.Is(err, c.err) {
+			code = c.code
+			break
+		}
+	}
+
+	var dbErr *db.Error
+	if !errors.As(err, &dbErr) {
+		return code, nil
+	}
+	data := &ErrorData{Key: dbErr.Key, Path: dbErr.Path, Pattern: dbErr.Pattern}
+	if dbErr.Err != nil {
+		data.Cause = dbErr.Err.Error()
+	}
+	return code, data
+}
+
+// NewError converts a handler or db error into the error sent to clients.
+// Transports other than Run, such as the HTTP server, use it to report the same codes.
+func NewError(err error) *Error {
+	code, data := errorInfo(err)
+	return &Error{Code: code, Message: err.Error(), Data: data}
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Framing modes. Messages are JSON lines unless the first request switches
+// the connection to length-prefixed frames.
+//
+// A length-prefixed frame is:
+//
+//	uint32 big-endian header length | JSON header | uint32 big-endian payload length | payload
+//
+// The header is a Request or Response. The payload carries raw value bytes
+// that are base64 encoded in line mode (put_chunk/put_value data, get_value,
+// get_chunk and get_value_range results) and is empty for other messages.
+const (
+	FramingLines          = "lines"
+	FramingLengthPrefixed = "length_prefixed"
+)
+
+// DefaultMaxFrameSize bounds a single request line or frame.
+const DefaultMaxFrameSize = 16 << 20
+
+var errFrameTooLarge = errors.New("request too large")
+
+type FramingParams struct {
+	Mode string `json:"mode"`
+}
+
+type FramingResult struct {
+	Mode         string `json:"mode"`
+	MaxFrameSize int    `json:"max_frame_size"`
+}
+
+// frameReader reads one request at a time.
+type frameReader interface {
+	// next returns the JSON request and its raw payload, if any.
+	// An oversized frame is skipped and reported as errFrameTooLarge.
+	next() (header, payload []byte, err error)
+}
+
+// lineReader reads newline-delimited JSON.
+type lineReader struct {
+	r   *bufio.Reader
+	max int
+}
+
+func (l *lineReader) next() ([]byte, []byte, error) {
+	var line []byte
+	tooLarge := false
+	for {
+		chunk, err := l.r.ReadSlice('\n')
+		if !tooLarge {
+			if len(line)+len(chunk) > l.max+1 { // +1 for the newline
+				tooLarge = true
+				line = nil
+			} else {
+				line = append(line, chunk...)
+			}
+		}
+
+		switch {
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF && (len(line) > 0 || tooLarge):
+			// Last line without a newline
+		case err != nil:
+			return nil, nil, err
+		}
+
+		if tooLarge {
+			return nil, nil, errFrameTooLarge
+		}
+		return trimEOL(line), nil, nil
+	}
+}
+
+func trimEOL(line []byte) []byte {
+	if n := len(line); n > 0 && line[n-1] == '\n' {
+		line = line[:n-1]
+	}
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	return line
+}
+
+// binaryReader reads length-prefixed frames.
+type binaryReader struct {
+	r   *bufio.Reader
+	max int
+}
+
+func (b *binaryReader) next() ([]byte, []byte, error) {
+	header, err := b.readPart()
+	if err != nil {
+		if errors.Is(err, errFrameTooLarge) {
+			// Skip the payload that follows the oversized header
+			if _, perr := b.readPart(); perr != nil && !errors.Is(perr, errFrameTooLarge) {
+				return nil, nil, perr
+			}
+		}
+		return nil, nil, err
+	}
+	payload, err := b.readPart()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(header)+len(payload) > b.max {
+		return nil, nil, errFrameTooLarge
+	}
+	return header, payload, nil
+}
+
+// readPart reads one length-prefixed part, discarding it if it exceeds the limit.
+func (b *binaryReader) readPart() ([]byte, error) {
+	var size uint32
+	if err := binary.Read(b.r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if int64(size) > int64(b.max) {
+		if _, err := b.r.Discard(int(size)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		return nil, errFrameTooLarge
+	}
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(b.r, buf); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return buf, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// writeFrame encodes one length-prefixed frame.
+func writeFrame(w io.Writer, header, payload []byte) error {
+	buf := make([]byte, 0, 8+len(header)+len(payload))
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(header)))
+	buf = append(buf, header...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(payload)))
+	buf = append(buf, payload...)
+	_, err := w.Write(buf)
+	return err
+}
+
+// framedResult carries raw bytes next to a result in length-prefixed mode.
+type framedResult struct {
+	result  interface{}
+	payload []byte
+}
+
+// encodeData returns data as base64 in line mode, or as a frame payload in
+// length-prefixed mode, where the JSON field is left empty.
+func (h *Handler) encodeData(data []byte) (string, []byte) {
+	if h.framing == FramingLengthPrefixed {
+		return "", data
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// withPayload attaches a frame payload to a handler result.
+func withPayload(result interface{}, payload []byte) interface{} {
+	if payload == nil {
+		return result
+	}
+	return framedResult{result: result, payload: payload}
+}
+
+// handleFraming switches the connection to the requested framing.
+// The response is still written in the old framing.
+func (h *Handler) handleFraming(req Request) {
+	var p FramingParams
+	if err := json.Unmarshal(req.Params, &p); err != nil {
+		h.reply(req, nil, err)
+		return
+	}
+	switch {
+	case p.Mode != FramingLines && p.Mode != FramingLengthPrefixed:
+		h.reply(req, nil, fmt.Errorf("unknown framing mode: %s", p.Mode))
+		return
+	case p.Mode == FramingLengthPrefixed && h.jsonrpc:
+		// Batch responses have no place for frame payloads
+		h.reply(req, nil, fmt.Errorf("length-prefixed framing is not available in JSON-RPC mode"))
+		return
+	}
+
+	h.reply(req, FramingResult{Mode: p.Mode, MaxFrameSize: h.maxFrameSize}, nil)
+	h.mu.Lock()
+	h.framing = p.Mode
+	h.mu.Unlock()
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+
+	"badger_explorer_core/codec"
+)
+
+// Version is the core version reported by hello. Release builds set it with
+// -ldflags "-X badger_explorer_core/api.Version=1.2.3".
+var Version = "dev"
+
+// ProtocolVersions lists the protocol versions this core speaks, oldest first.
+// A breaking change to the wire format adds a version; handlers can check
+// Handler.protocol to keep the old behaviour for clients that asked for it.
+var ProtocolVersions = []int{1}
+
+// requestTypes lists every request type handled by handleRequest.
+var requestTypes = []string{
+	TypeHello,
+	TypeOpenDB,
+	TypeListKeys,
+	TypeGetValue,
+	TypeGetValueRange,
+	TypePutValue,
+	TypePutChunk,
+	TypePutCommit,
+	TypeUploadAbort,
+	TypeDeleteKey,
+	TypeCloseDB,
+	TypeDiff,
+	TypeDiffApply,
+	TypeLoadDescriptorSet,
+	TypeSubscribe,
+	TypeUnsubscribe,
+	TypeListSavedQueries,
+	TypeSaveQuery,
+	TypeDeleteSavedQuery,
+	TypeListBookmarks,
+	TypeAddBookmark,
+	TypeRemoveBookmark,
+	TypeRenameKey,
+	TypeCopyKey,
+	TypeCountKeys,
+	TypeFraming,
+}
+
+type HelloParams struct {
+	Client string `json:"client,omitempty"` // Informational, e.g. "electron-app/2.1.0"
+	// Protocol versions the client can speak. The highest one shared with the
+	// core is selected. Empty keeps the current version.
+	ProtocolVersions []int `json:"protocol_versions,omitempty"`
+}
+
+type HelloResult struct {
+	CoreVersion      string      `json:"core_version"`
+	BadgerVersion    string      `json:"badger_version"`
+	ProtocolVersion  int         `json:"protocol_version"` // Selected version
+	ProtocolVersions []int       `json:"protocol_versions"`
+	RequestTypes     []string    `json:"request_types"`
+	SearchModes      []string    `json:"search_modes"`
+	Codecs           []string    `json:"codecs"`
+	PutEncodings     []string    `json:"put_encodings"`
+	Framing          string      `json:"framing"`
+	JSONRPC          bool        `json:"jsonrpc"`
+	Limits           HelloLimits `json:"limits"`
+}
+
+type HelloLimits struct {
+	MaxFrameSize     int   `json:"max_frame_size"`
+	MaxChunkSize     int   `json:"max_chunk_size"` // Largest put_chunk data that fits in a frame
+	MaxUploadSize    int64 `json:"max_upload_size"`
+	MaxUploads       int   `json:"max_uploads"`
+	UploadTTLSeconds int   `json:"upload_ttl_seconds"`
+	Workers          int   `json:"workers"`
+	QueueSize        int   `json:"queue_size"`
+}
+
+// frameOverhead is reserved in a frame for the JSON around chunk data.
+const frameOverhead = 4096
+
+func (h *Handler) handleHello(params json.RawMessage) (interface{}, error) {
+	var p HelloParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(p.ProtocolVersions) > 0 {
+		version, ok := selectProtocol(p.ProtocolVersions)
+		if !ok {
+			return nil, fmt.Errorf("no common protocol version: core supports %v", ProtocolVersions)
+		}
+		h.mu.Lock()
+		h.protocol = version
+		h.mu.Unlock()
+	}
+
+	h.uploads.mu.Lock()
+	limits := h.uploads.limits
+	h.uploads.mu.Unlock()
+
+	h.mu.Lock()
+	framing, protocol := h.framing, h.protocol
+	h.mu.Unlock()
+
+	maxChunk := h.maxFrameSize - frameOverhead
+	if framing == FramingLines {
+		maxChunk = maxChunk / 4 * 3 // Base64
+	}
+
+	return HelloResult{
+		CoreVersion:      Version,
+		BadgerVersion:    badgerVersion(),
+		ProtocolVersion:  protocol,
+		ProtocolVersions: ProtocolVersions,
+		RequestTypes:     requestTypes,
+		SearchModes:      []string{"prefix", "substring", "regex"},
+		Codecs:           codec.Names(),
+		PutEncodings:     []string{EncodingUTF8, EncodingBase64, EncodingHex, EncodingJSON},
+		Framing:          framing,
+		JSONRPC:          h.jsonrpc,
+		Limits: HelloLimits{
+			MaxFrameSize:     h.maxFrameSize,
+			MaxChunkSize:     maxChunk,
+			MaxUploadSize:    limits.MaxSessionSize,
+			MaxUploads:       limits.MaxSessions,
+			UploadTTLSeconds: int(limits.SessionTTL.Seconds()),
+			Workers:          h.workers,
+			QueueSize:        h.queueSize,
+		},
+	}, nil
+}
+
+// selectProtocol returns the highest version supported by both sides.
+func selectProtocol(client []int) (int, bool) {
+	best, ok := 0, false
+	for _, v := range client {
+		for _, s := range ProtocolVersions {
+			if v == s && v > best {
+				best, ok = v, true
+			}
+		}
+	}
+	return best, ok
+}
+
+// badgerVersion reads the Badger module version from the build info.
+func badgerVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/dgraph-io/badger/v4" {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
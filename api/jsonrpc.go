@@ -0,0 +1,330 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// JSON-RPC 2.0 mode.
+//
+// When enabled, requests use "method" in place of "type", responses carry
+// "jsonrpc": "2.0" and errors use the standard codes. Protocol-level errors
+// (codes 1000-1099) are mapped into the server error range, 1000+n becoming
+// -32000-n; database errors (11xx) keep their codes.
+// Streaming messages (get_chunk, diff_entry) are sent as notifications whose
+// params hold the request id and the message.
+
+// JSON-RPC 2.0 error codes.
+const (
+	RPCParseError     = -32700
+	RPCInvalidRequest = -32600
+	RPCMethodNotFound = -32601
+	RPCInvalidParams  = -32602
+	RPCServerError    = -32000 // Application errors count down from here
+)
+
+var errUnknownRequest = errors.New("Unknown request type")
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// rpcStreamParams are the params of a streaming notification.
+type rpcStreamParams struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result"`
+}
+
+// rpcCall tracks a request until its final response is sent.
+type rpcCall struct {
+	id     json.RawMessage // nil for notifications
+	method string
+	batch  *rpcBatch
+	after  func() // Runs once the response is written
+}
+
+// rpcBatch collects the responses of a batch request into one array.
+type rpcBatch struct {
+	mu        sync.Mutex
+	pending   int
+	responses []json.RawMessage
+	after     []func() // Run once the array is written
+}
+
+var nullID = json.RawMessage("null")
+
+// SetJSONRPC switches the handler to JSON-RPC 2.0 messages.
+func (h *Handler) SetJSONRPC(enabled bool) {
+	h.jsonrpc = enabled
+}
+
+// parseJSONRPC turns a line into requests. Invalid requests are answered here.
+func (h *Handler) parseJSONRPC(line []byte) []Request {
+	trimmed := bytes.TrimSpace(line)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var items []json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			h.writeRPCError(nullID, RPCParseError, "Parse error")
+			return nil
+		}
+		if len(items) == 0 {
+			h.writeRPCError(nullID, RPCInvalidRequest, "Invalid Request")
+			return nil
+		}
+
+		batch := &rpcBatch{pending: len(items)}
+		var reqs []Request
+		for _, item := range items {
+			req, call, ok := h.parseRPCItem(item)
+			call.batch = batch
+			if !ok {
+				h.finishCall(call, rpcErrorResponse(nullID, RPCInvalidRequest, "Invalid Request"))
+				continue
+			}
+			if !h.registerCall(req.rpcKey, call) {
+				h.finishCall(call, rpcErrorResponse(call.id, RPCInvalidRequest, "Duplicate request id"))
+				continue
+			}
+			reqs = append(reqs, req)
+		}
+		return reqs
+	}
+
+	var probe interface{}
+	if err := json.Unmarshal(trimmed, &probe); err != nil {
+		h.writeRPCError(nullID, RPCParseError, "Parse error")
+		return nil
+	}
+	req, call, ok := h.parseRPCItem(trimmed)
+	if !ok {
+		h.writeRPCError(nullID, RPCInvalidRequest, "Invalid Request")
+		return nil
+	}
+	if !h.registerCall(req.rpcKey, call) {
+		h.writeRPCError(call.id, RPCInvalidRequest, "Duplicate request id")
+		return nil
+	}
+	return []Request{req}
+}
+
+// parseRPCItem validates one request object and converts it to a Request.
+// The call is registered under the raw id, which Request.rpcKey holds;
+// Request.ID is the id unquoted, for the params that refer to it.
+func (h *Handler) parseRPCItem(item json.RawMessage) (Request, *rpcCall, bool) {
+	call := &rpcCall{id: nullID}
+
+	var r rpcRequest
+	if err := json.Unmarshal(item, &r); err != nil {
+		return Request{}, call, false
+	}
+	if r.JSONRPC != "2.0" || r.Method == "" || !validRPCID(r.ID) {
+		return Request{}, call, false
+	}
+	call.method = r.Method
+
+	// Requests without an id are notifications and get no response
+	var id, key string
+	if r.ID == nil {
+		call.id = nil
+		h.rpcMu.Lock()
+		h.notifySeq++
+		id = fmt.Sprintf("notification-%d", h.notifySeq)
+		h.rpcMu.Unlock()
+		key = id
+	} else {
+		call.id = r.ID
+		key = string(r.ID)
+		if err := json.Unmarshal(r.ID, &id); err != nil {
+			id = string(r.ID) // Numbers are used as written
+		}
+	}
+
+	return Request{ID: id, Type: r.Method, Params: r.Params, rpcKey: key}, call, true
+}
+
+// validRPCID accepts a missing id, a string, a number or null.
+func validRPCID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	switch id[0] {
+	case '"', 'n', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+// registerCall tracks call under its raw id. It fails if a call with the
+// same id is still in flight.
+func (h *Handler) registerCall(key string, call *rpcCall) bool {
+	h.rpcMu.Lock()
+	defer h.rpcMu.Unlock()
+
+	if _, ok := h.calls[key]; ok {
+		return false
+	}
+	h.calls[key] = call
+	return true
+}
+
+func (h *Handler) lookupCall(id string, remove bool) *rpcCall {
+	h.rpcMu.Lock()
+	defer h.rpcMu.Unlock()
+
+	call := h.calls[id]
+	if remove {
+		delete(h.calls, id)
+	}
+	return call
+}
+
+// rpcID returns the JSON-RPC id of a pending request, or nil.
+func (h *Handler) rpcID(id string) json.RawMessage {
+	if call := h.lookupCall(id, false); call != nil {
+		return call.id
+	}
+	return nil
+}
+
+// replyJSONRPC sends the final response of a request. after, if set, runs
+// once the response is written, or the whole batch for a batch request.
+func (h *Handler) replyJSONRPC(id string, result interface{}, err error, after func()) {
+	call := h.lookupCall(id, true)
+	if call == nil {
+		if after != nil {
+			after()
+		}
+		return
+	}
+	call.after = after
+	if err != nil {
+		code, data := errorInfo(err)
+		resp := rpcErrorResponse(call.id, rpcCode(code), err.Error())
+		resp.Error.Data = data
+		h.finishCall(call, resp)
+		return
+	}
+
+	if fr, ok := result.(framedResult); ok {
+		result = fr.result
+	}
+	raw, mErr := json.Marshal(result)
+	if mErr != nil {
+		h.finishCall(call, rpcErrorResponse(call.id, RPCServerError, mErr.Error()))
+		return
+	}
+	h.finishCall(call, rpcResponse{JSONRPC: "2.0", ID: call.id, Result: raw})
+}
+
+// finishCall writes a response, or adds it to the call's batch.
+// Notifications produce no output.
+func (h *Handler) finishCall(call *rpcCall, resp rpcResponse) {
+	if call.batch == nil {
+		if call.id != nil {
+			h.writeJSON(resp)
+		}
+		if call.after != nil {
+			call.after()
+		}
+		return
+	}
+
+	b := call.batch
+	b.mu.Lock()
+	if call.id != nil {
+		raw, _ := json.Marshal(resp)
+		b.responses = append(b.responses, raw)
+	}
+	if call.after != nil {
+		b.after = append(b.after, call.after)
+	}
+	b.pending--
+	done := b.pending == 0
+	responses, after := b.responses, b.after
+	b.mu.Unlock()
+
+	if !done {
+		return
+	}
+	if len(responses) > 0 {
+		h.writeJSON(responses)
+	}
+	for _, f := range after {
+		f()
+	}
+}
+
+// sendJSONRPC handles sendResponse and sendError in JSON-RPC mode.
+// Messages other than the final response become notifications.
+func (h *Handler) sendJSONRPC(id, typeStr string, result interface{}, rpcErr *Error) {
+	if rpcErr != nil {
+		rpcErr.Code = rpcCode(rpcErr.Code)
+		resp := rpcResponse{JSONRPC: "2.0", ID: nullID, Error: rpcErr}
+		if call := h.lookupCall(id, true); call != nil {
+			resp.ID = call.id
+			h.finishCall(call, resp)
+			return
+		}
+		h.writeJSON(resp)
+		return
+	}
+
+	call := h.lookupCall(id, false)
+	if call == nil || call.id == nil {
+		return
+	}
+	if fr, ok := result.(framedResult); ok {
+		result = fr.result
+	}
+	h.writeJSON(rpcNotification{
+		JSONRPC: "2.0",
+		Method:  typeStr,
+		Params:  rpcStreamParams{ID: call.id, Result: result},
+	})
+}
+
+func (h *Handler) writeRPCError(id json.RawMessage, code int, msg string) {
+	h.writeJSON(rpcErrorResponse(id, code, msg))
+}
+
+func rpcErrorResponse(id json.RawMessage, code int, msg string) rpcResponse {
+	if id == nil {
+		id = nullID
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: id, Error: &Error{Code: code, Message: msg}}
+}
+
+// rpcCode maps a native error code to JSON-RPC.
+func rpcCode(code int) int {
+	switch code {
+	case CodeInvalidRequest:
+		return RPCParseError
+	case CodeUnknownRequest:
+		return RPCMethodNotFound
+	case CodeInvalidParams:
+		return RPCInvalidParams
+	}
+	if code < CodeNotOpen {
+		return RPCServerError - (code - CodeInternal)
+	}
+	return code
+}
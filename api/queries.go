@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"badger_explorer_core/config"
+)
+
+// errNoConfig is returned by requests that need the config file.
+var errNoConfig = errors.New("no config attached to the handler")
+
+type ListSavedQueriesParams struct {
+	Path string `json:"path,omitempty"` // DB whose history to return; empty uses the open DB
+}
+
+type ListSavedQueriesResult struct {
+	Saved   []config.SavedQuery `json:"saved"`
+	History []string            `json:"history"` // Most recent first
+}
+
+func (h *Handler) handleListSavedQueries(params json.RawMessage) (interface{}, error) {
+	var p ListSavedQueriesParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+	}
+	if h.cfg == nil {
+		return nil, errNoConfig
+	}
+	if p.Path == "" {
+		p.Path = h.dbClient.GetPath()
+	}
+	return ListSavedQueriesResult{
+		Saved:   h.cfg.GetSavedQueries(),
+		History: h.cfg.GetSearchHistory(p.Path),
+	}, nil
+}
+
+// handleSaveQuery stores a query, replacing one with the same name.
+func (h *Handler) handleSaveQuery(params json.RawMessage) (interface{}, error) {
+	var q config.SavedQuery
+	if err := json.Unmarshal(params, &q); err != nil {
+		return nil, err
+	}
+	if h.cfg == nil {
+		return nil, errNoConfig
+	}
+	if q.Name == "" {
+		return nil, fmt.Errorf("query name cannot be empty")
+	}
+	if q.Mode == "" {
+		q.Mode = "prefix"
+	}
+	h.cfg.SaveQuery(q)
+	return nil, h.cfg.Save()
+}
+
+type DeleteSavedQueryParams struct {
+	Name string `json:"name"`
+}
+
+func (h *Handler) handleDeleteSavedQuery(params json.RawMessage) (interface{}, error) {
+	var p DeleteSavedQueryParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	if h.cfg == nil {
+		return nil, errNoConfig
+	}
+	if !h.cfg.DeleteSavedQuery(p.Name) {
+		return nil, fmt.Errorf("unknown saved query: %s", p.Name)
+	}
+	return nil, h.cfg.Save()
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"badger_explorer_core/db"
+)
+
+type RenameKeyParams struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Prefix    bool   `json:"prefix,omitempty"`    // Treat From and To as prefixes and move every key under From
+	Overwrite bool   `json:"overwrite,omitempty"` // Replace existing target keys
+}
+
+func (h *Handler) handleRenameKey(params json.RawMessage) (interface{}, error) {
+	return h.moveKey(params, true)
+}
+
+func (h *Handler) handleCopyKey(params json.RawMessage) (interface{}, error) {
+	return h.moveKey(params, false)
+}
+
+// moveKey renames or copies a key or prefix and returns a db.BulkResult.
+func (h *Handler) moveKey(params json.RawMessage, rename bool) (interface{}, error) {
+	var p RenameKeyParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	if p.Prefix {
+		if rename {
+			return h.dbClient.RenamePrefix(context.Background(), p.From, p.To, p.Overwrite)
+		}
+		return h.dbClient.CopyPrefix(context.Background(), p.From, p.To, p.Overwrite)
+	}
+
+	if p.From == "" || p.To == "" {
+		return nil, fmt.Errorf("from and to cannot be empty")
+	}
+	var err error
+	if rename {
+		err = h.dbClient.RenameKey(p.From, p.To, p.Overwrite)
+	} else {
+		err = h.dbClient.CopyKey(p.From, p.To, p.Overwrite)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return db.BulkResult{Done: 1}, nil
+}
@@ -2,12 +2,19 @@ package api
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 
+	"badger_explorer_core/codec"
+	"badger_explorer_core/config"
 	"badger_explorer_core/db"
 )
 
@@ -21,6 +28,25 @@ const (
 	TypePutCommit = "put_commit"
 	TypeDeleteKey = "delete_key"
 	TypeCloseDB   = "close_db"
+	TypeDiff      = "diff"
+	TypeDiffApply = "diff_apply"
+
+	TypeLoadDescriptorSet = "load_descriptor_set"
+	TypeGetValueRange     = "get_value_range"
+	TypeUploadAbort       = "upload_abort"
+	TypeFraming           = "framing"
+	TypeHello             = "hello"
+	TypeSubscribe         = "subscribe"
+	TypeUnsubscribe       = "unsubscribe"
+	TypeListSavedQueries  = "list_saved_queries"
+	TypeSaveQuery         = "save_query"
+	TypeDeleteSavedQuery  = "delete_saved_query"
+	TypeListBookmarks     = "list_bookmarks"
+	TypeAddBookmark       = "add_bookmark"
+	TypeRemoveBookmark    = "remove_bookmark"
+	TypeRenameKey         = "rename_key"
+	TypeCopyKey           = "copy_key"
+	TypeCountKeys         = "count_keys"
 )
 
 // Request represents a JSON-RPC request.
@@ -28,6 +54,18 @@ type Request struct {
 	ID     string          `json:"id"`
 	Type   string          `json:"type"`
 	Params json.RawMessage `json:"params"`
+
+	payload []byte // Raw bytes of a length-prefixed frame
+	rpcKey  string // Raw id of a JSON-RPC call, which ID holds unquoted
+}
+
+// replyID returns the ID that routes the responses of req. JSON-RPC calls
+// are tracked by their raw id, so "1" and 1 stay apart.
+func (req Request) replyID() string {
+	if req.rpcKey != "" {
+		return req.rpcKey
+	}
+	return req.ID
 }
 
 // Response represents a JSON-RPC response.
@@ -40,45 +78,209 @@ type Response struct {
 
 // Error represents an error in the response.
 type Error struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int        `json:"code"`
+	Message string     `json:"message"`
+	Data    *ErrorData `json:"data,omitempty"`
 }
 
 // Handler handles API requests.
 type Handler struct {
 	dbClient *db.DBClient
+	cfg      *config.Config // Optional, enables config-backed features such as codec rules
 	out      io.Writer
 	mu       sync.Mutex
+	ctx      context.Context // Cancels long scans such as count_keys
+
+	// Chunked upload sessions
+	uploads *uploadStore
+
+	// Dispatcher sizes used by Run
+	workers   int
+	queueSize int
+
+	sharedDB bool // The DB is shared with other clients, so sharedRefused requests fail
 
-	// Chunking state
-	chunkBuffer map[string][]byte // requestID -> data buffer
+	framing      string // FramingLines or FramingLengthPrefixed
+	maxFrameSize int
+	protocol     int // Protocol version selected by hello
+
+	// Change notifications
+	subs subscriptions
+
+	// JSON-RPC 2.0 mode
+	jsonrpc   bool
+	rpcMu     sync.Mutex
+	calls     map[string]*rpcCall // Raw JSON-RPC id -> pending call
+	notifySeq int
 }
 
 // NewHandler creates a new API handler.
 func NewHandler(dbClient *db.DBClient, out io.Writer) *Handler {
 	return &Handler{
-		dbClient:    dbClient,
-		out:         out,
-		chunkBuffer: make(map[string][]byte),
+		dbClient:  dbClient,
+		out:       out,
+		ctx:       context.Background(),
+		uploads:   newUploadStore(DefaultUploadLimits),
+		workers:   DefaultWorkers,
+		queueSize: DefaultQueueSize,
+
+		framing:      FramingLines,
+		maxFrameSize: DefaultMaxFrameSize,
+		protocol:     ProtocolVersions[len(ProtocolVersions)-1],
+
+		subs:  subscriptions{subs: make(map[string]*subscription)},
+		calls: make(map[string]*rpcCall),
+	}
+}
+
+// SetContext sets the context of the handler's scans: cancelling it stops
+// the running count_keys requests.
+func (h *Handler) SetContext(ctx context.Context) {
+	h.ctx = ctx
+}
+
+// SetUploadLimits replaces the limits for upload sessions opened from now on.
+func (h *Handler) SetUploadLimits(limits UploadLimits) {
+	h.uploads.mu.Lock()
+	h.uploads.limits = limits
+	h.uploads.mu.Unlock()
+}
+
+// SetConfig attaches the application config to the handler.
+func (h *Handler) SetConfig(cfg *config.Config) {
+	h.cfg = cfg
+	h.SetConcurrency(cfg.API.Workers, cfg.API.QueueSize)
+	h.SetMaxFrameSize(cfg.API.MaxFrameSize)
+	if cfg.API.JSONRPC {
+		h.SetJSONRPC(true)
+	}
+}
+
+// SetSharedDB marks the DB client as shared with other connections, as on
+// the HTTP server. One client must not open or close the DB for all others,
+// nor reach other paths on the host, so the sharedRefused requests fail.
+func (h *Handler) SetSharedDB(shared bool) {
+	h.sharedDB = shared
+}
+
+// SetMaxFrameSize sets the largest request line or frame Run accepts. Values <= 0 are ignored.
+func (h *Handler) SetMaxFrameSize(size int) {
+	if size > 0 {
+		h.maxFrameSize = size
+	}
+}
+
+// SetConcurrency sets how many requests Run executes at once and how many more
+// it accepts before it stops reading. Values <= 0 keep the current setting.
+func (h *Handler) SetConcurrency(workers, queueSize int) {
+	if workers > 0 {
+		h.workers = workers
+	}
+	if queueSize > 0 {
+		h.queueSize = queueSize
+	}
+}
+
+// codecFor returns the codec rule matching key, or "" when no config is attached.
+func (h *Handler) codecFor(key string) string {
+	if h.cfg == nil {
+		return ""
 	}
+	return h.cfg.CodecFor(key)
 }
 
 // Run starts reading from stdin and handling requests.
+// It returns once the input ends and every accepted request has been answered.
 func (h *Handler) Run(in io.Reader) {
-	scanner := bufio.NewScanner(in)
-	for scanner.Scan() {
-		line := scanner.Bytes()
+	d := newDispatcher(h.workers, h.queueSize)
+
+	br := bufio.NewReaderSize(in, 64<<10)
+	var frames frameReader = &lineReader{r: br, max: h.maxFrameSize}
+	first := true
+	for {
+		line, payload, err := frames.next()
+		if errors.Is(err, errFrameTooLarge) {
+			h.sendError("", CodeFrameTooLarge, fmt.Sprintf("Request exceeds the maximum frame size of %d bytes", h.maxFrameSize))
+			continue
+		}
+		if err != nil {
+			if err != io.EOF {
+				h.sendError("", CodeReadError, "Read error: "+err.Error())
+			}
+			break
+		}
 		if len(line) == 0 {
 			continue
 		}
-		go h.handleLine(line)
+
+		for _, req := range h.parseRequests(line) {
+			req.payload = payload
+
+			// Framing can only change before any other request is in flight
+			if first && req.Type == TypeFraming {
+				h.handleFraming(req)
+				if h.framing == FramingLengthPrefixed {
+					frames = &binaryReader{r: br, max: h.maxFrameSize}
+				}
+				first = false
+				continue
+			}
+			first = false
+
+			if isExclusive(req) {
+				d.exclusive(func() { h.handleRequest(req) })
+			} else {
+				d.submit(orderKey(req), func() { h.handleRequest(req) })
+			}
+		}
 	}
+
+	d.wait()
+	h.closeSubscriptions()
+	// Remove spool files of uploads that were never committed
+	h.uploads.closeAll()
 }
 
+// handleLine parses and handles one line synchronously.
 func (h *Handler) handleLine(line []byte) {
+	for _, req := range h.parseRequests(line) {
+		h.handleRequest(req)
+	}
+}
+
+// parseRequests parses a line into one request, or several for a JSON-RPC batch.
+func (h *Handler) parseRequests(line []byte) []Request {
+	if h.jsonrpc {
+		return h.parseJSONRPC(line)
+	}
+	if req, ok := h.parseRequest(line); ok {
+		return []Request{req}
+	}
+	return nil
+}
+
+func (h *Handler) parseRequest(line []byte) (Request, bool) {
 	var req Request
 	if err := json.Unmarshal(line, &req); err != nil {
-		h.sendError(req.ID, 1003, "Invalid request format")
+		h.sendError(req.ID, CodeInvalidRequest, "Invalid request format")
+		return req, false
+	}
+	return req, true
+}
+
+// sharedRefused lists the requests a shared connection may not make: they
+// open or close the DB, or open other DBs and read files on the host.
+var sharedRefused = map[string]bool{
+	TypeOpenDB:            true,
+	TypeCloseDB:           true,
+	TypeDiff:              true,
+	TypeDiffApply:         true,
+	TypeLoadDescriptorSet: true,
+}
+
+func (h *Handler) handleRequest(req Request) {
+	if h.sharedDB && sharedRefused[req.Type] {
+		h.reply(req, nil, errSharedDB)
 		return
 	}
 
@@ -86,56 +288,133 @@ func (h *Handler) handleLine(line []byte) {
 	var result interface{}
 
 	switch req.Type {
+	case TypeHello:
+		result, err = h.handleHello(req.Params)
 	case TypeOpenDB:
 		result, err = h.handleOpenDB(req.Params)
 	case TypeListKeys:
 		result, err = h.handleListKeys(req.Params)
 	case TypeGetValue:
-		result, err = h.handleGetValue(req.Params)
+		result, err = h.handleGetValue(req.replyID(), req.Params)
+	case TypeGetValueRange:
+		result, err = h.handleGetValueRange(req.Params)
 	case TypePutValue:
-		result, err = h.handlePutValue(req.ID, req.Params)
+		result, err = h.handlePutValue(req.ID, req.Params, req.payload)
 	case TypePutChunk:
-		result, err = h.handlePutChunk(req.Params)
+		result, err = h.handlePutChunk(req.Params, req.payload)
 	case TypePutCommit:
 		result, err = h.handlePutCommit(req.Params)
+	case TypeUploadAbort:
+		result, err = h.handleUploadAbort(req.Params)
 	case TypeDeleteKey:
 		result, err = h.handleDeleteKey(req.Params)
 	case TypeCloseDB:
 		result, err = h.handleCloseDB()
+	case TypeDiff:
+		result, err = h.handleDiff(req.replyID(), req.Params)
+	case TypeDiffApply:
+		result, err = h.handleDiffApply(req.Params)
+	case TypeLoadDescriptorSet:
+		result, err = h.handleLoadDescriptorSet(req.Params)
+	case TypeSubscribe:
+		result, err = h.handleSubscribe(req.ID, h.rpcID(req.replyID()), req.Params)
+	case TypeUnsubscribe:
+		result, err = h.handleUnsubscribe(req.Params)
+	case TypeListSavedQueries:
+		result, err = h.handleListSavedQueries(req.Params)
+	case TypeSaveQuery:
+		result, err = h.handleSaveQuery(req.Params)
+	case TypeDeleteSavedQuery:
+		result, err = h.handleDeleteSavedQuery(req.Params)
+	case TypeListBookmarks:
+		result, err = h.handleListBookmarks()
+	case TypeAddBookmark:
+		result, err = h.handleAddBookmark(req.Params)
+	case TypeRemoveBookmark:
+		result, err = h.handleRemoveBookmark(req.Params)
+	case TypeRenameKey:
+		result, err = h.handleRenameKey(req.Params)
+	case TypeCopyKey:
+		result, err = h.handleCopyKey(req.Params)
+	case TypeCountKeys:
+		result, err = h.handleCountKeys(req.Params)
+	case TypeFraming:
+		err = fmt.Errorf("framing must be negotiated by the first request")
 	default:
-		h.sendError(req.ID, 1000, "Unknown request type")
-		return
+		err = errUnknownRequest
 	}
 
+	h.reply(req, result, err)
+}
+
+// reply sends the final response of req, then runs the start function of a
+// startedResult.
+func (h *Handler) reply(req Request, result interface{}, err error) {
+	var start func()
+	if sr, ok := result.(startedResult); ok {
+		result, start = sr.result, sr.start
+	}
+	if h.jsonrpc {
+		h.replyJSONRPC(req.replyID(), result, err, start)
+		return
+	}
 	if err != nil {
-		h.sendError(req.ID, 1000, err.Error())
+		code, data := errorInfo(err)
+		h.sendErrorData(req.ID, code, err.Error(), data)
 	} else {
 		h.sendResponse(req.ID, req.Type+"_resp", result)
 	}
+	if start != nil {
+		start()
+	}
 }
 
 func (h *Handler) sendResponse(id, typeStr string, result interface{}) {
+	if h.jsonrpc {
+		h.sendJSONRPC(id, typeStr, result, nil)
+		return
+	}
 	resp := Response{
 		ID:     id,
 		Type:   typeStr,
 		Result: result,
 	}
+	if fr, ok := result.(framedResult); ok {
+		resp.Result = fr.result
+		h.writeMessage(resp, fr.payload)
+		return
+	}
 	h.writeJSON(resp)
 }
 
 func (h *Handler) sendError(id string, code int, msg string) {
+	h.sendErrorData(id, code, msg, nil)
+}
+
+func (h *Handler) sendErrorData(id string, code int, msg string, data *ErrorData) {
+	e := &Error{
+		Code:    code,
+		Message: msg,
+		Data:    data,
+	}
+	if h.jsonrpc {
+		h.sendJSONRPC(id, "", nil, e)
+		return
+	}
 	resp := Response{
-		ID:   id,
-		Type: "error",
-		Error: &Error{
-			Code:    code,
-			Message: msg,
-		},
+		ID:    id,
+		Type:  "error",
+		Error: e,
 	}
 	h.writeJSON(resp)
 }
 
 func (h *Handler) writeJSON(v interface{}) {
+	h.writeMessage(v, nil)
+}
+
+// writeMessage writes v as a JSON line, or as a frame with payload in length-prefixed mode.
+func (h *Handler) writeMessage(v interface{}, payload []byte) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
@@ -143,8 +422,12 @@ func (h *Handler) writeJSON(v interface{}) {
 	if err != nil {
 		return
 	}
-	h.out.Write(bytes)
-	h.out.Write([]byte("\n"))
+	if h.framing == FramingLengthPrefixed {
+		writeFrame(h.out, bytes, payload)
+		return
+	}
+	// One write per message, so message-based writers see whole messages
+	h.out.Write(append(bytes, '\n'))
 }
 
 // --- Handlers ---
@@ -164,11 +447,14 @@ func (h *Handler) handleOpenDB(params json.RawMessage) (interface{}, error) {
 }
 
 type ListKeysParams struct {
-	Prefix string `json:"prefix"`
-	Mode   string `json:"mode"`
-	Sort   string `json:"sort"` // "asc", "desc"
-	Limit  int    `json:"limit"`
-	Offset int    `json:"offset"`
+	Prefix     string `json:"prefix"`
+	Mode       string `json:"mode"`
+	Sort       string `json:"sort"` // "asc", "desc"
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	IgnoreCase bool   `json:"ignore_case,omitempty"`
+	Codec      string `json:"codec,omitempty"`  // Only keys whose codec rule selects this codec
+	Record     bool   `json:"record,omitempty"` // Add the prefix to the search history of the open DB
 }
 
 type ListKeysResult struct {
@@ -188,6 +474,10 @@ func (h *Handler) handleListKeys(params json.RawMessage) (interface{}, error) {
 		SortDesc: p.Sort == "desc",
 		Limit:    p.Limit,
 		Offset:   p.Offset,
+
+		PreviewCodec: h.codecFor,
+		IgnoreCase:   p.IgnoreCase,
+		CodecFilter:  p.Codec,
 	}
 
 	keys, hasMore, err := h.dbClient.ListKeys(opts)
@@ -195,112 +485,332 @@ func (h *Handler) handleListKeys(params json.RawMessage) (interface{}, error) {
 		return nil, err
 	}
 
+	if p.Record && h.cfg != nil {
+		h.cfg.AddSearchHistory(h.dbClient.GetPath(), p.Prefix)
+		if err := h.cfg.Save(); err != nil {
+			return nil, err
+		}
+	}
+
 	return ListKeysResult{Keys: keys, HasMore: hasMore}, nil
 }
 
+type CountKeysParams struct {
+	Prefix     string `json:"prefix"`
+	Mode       string `json:"mode"`
+	IgnoreCase bool   `json:"ignore_case,omitempty"`
+	Codec      string `json:"codec,omitempty"`
+	Estimate   bool   `json:"estimate,omitempty"` // Answer from the table indexes when the filter allows it
+}
+
+// handleCountKeys returns a db.KeyCount for the filter.
+func (h *Handler) handleCountKeys(params json.RawMessage) (interface{}, error) {
+	var p CountKeysParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	opts := db.ListKeysOptions{
+		Prefix:       p.Prefix,
+		Mode:         p.Mode,
+		PreviewCodec: h.codecFor,
+		IgnoreCase:   p.IgnoreCase,
+		CodecFilter:  p.Codec,
+	}
+	if p.Estimate {
+		if count, ok := h.dbClient.EstimateKeys(opts); ok {
+			return count, nil
+		}
+	}
+	return h.dbClient.CountKeys(h.ctx, opts)
+}
+
 type GetValueParams struct {
-	Key string `json:"key"`
+	Key       string `json:"key"`
+	Decode    string `json:"decode,omitempty"`     // Codec name or "auto" (codec rules, then detection); empty returns only the raw value
+	JSONPath  string `json:"jsonpath,omitempty"`   // Return only the matching sub-document of the decoded value
+	ChunkSize int    `json:"chunk_size,omitempty"` // Stream the raw value as "get_chunk" messages of this size
 }
 
 type GetValueResult struct {
-	Value string `json:"value"` // Base64 encoded
+	Value   string `json:"value"`             // Base64 encoded
+	Decoded string `json:"decoded,omitempty"` // Human-readable text when "decode" was requested
+	Codec   string `json:"codec,omitempty"`   // Codec chain that produced Decoded, e.g. "gzip+json"
+
+	// Set for chunked reads, where Value is empty and the data arrived as "get_chunk" messages
+	ValueLength int64 `json:"value_length,omitempty"`
+	Chunks      int   `json:"chunks,omitempty"`
 }
 
-func (h *Handler) handleGetValue(params json.RawMessage) (interface{}, error) {
+// GetChunk is streamed for chunked get_value requests, mirroring put_chunk.
+type GetChunk struct {
+	ChunkIndex int    `json:"chunk_index"`
+	Offset     int64  `json:"offset"`
+	Data       string `json:"data"` // Base64
+}
+
+func (h *Handler) handleGetValue(reqID string, params json.RawMessage) (interface{}, error) {
 	var p GetValueParams
 	if err := json.Unmarshal(params, &p); err != nil {
 		return nil, err
 	}
 
+	if p.ChunkSize > 0 {
+		if p.Decode != "" || p.JSONPath != "" {
+			return nil, fmt.Errorf("chunk_size cannot be combined with decode or jsonpath")
+		}
+		return h.sendValueChunks(reqID, p.Key, p.ChunkSize)
+	}
+
 	val, err := h.dbClient.GetValue(p.Key)
 	if err != nil {
 		return nil, err
 	}
 
-	return GetValueResult{Value: base64.StdEncoding.EncodeToString(val)}, nil
+	// A query needs a decoded document
+	if p.JSONPath != "" && p.Decode == "" {
+		p.Decode = codec.Auto
+	}
+
+	value, payload := h.encodeData(val)
+	result := GetValueResult{Value: value}
+	if p.Decode != "" {
+		name := p.Decode
+		if name == codec.Auto {
+			if rule := h.codecFor(p.Key); rule != "" {
+				name = rule
+			}
+		}
+		d, err := codec.Decode(name, val)
+		if err != nil {
+			return nil, err
+		}
+		result.Decoded = d.Text
+		result.Codec = d.Codec
+	}
+
+	if p.JSONPath != "" {
+		sub, err := codec.QueryJSON(result.Decoded, p.JSONPath)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := json.Marshal(sub)
+		if err != nil {
+			return nil, err
+		}
+		var pretty bytes.Buffer
+		json.Indent(&pretty, raw, "", "  ")
+		// The sub-document replaces the full value so large documents are not sent
+		result.Value, payload = h.encodeData(raw)
+		result.Decoded = pretty.String()
+	}
+	return withPayload(result, payload), nil
 }
 
+// sendValueChunks streams a value as "get_chunk" messages so no single line holds the whole value.
+func (h *Handler) sendValueChunks(reqID, key string, chunkSize int) (interface{}, error) {
+	index := 0
+	total, err := h.dbClient.ReadValueChunks(key, chunkSize, func(offset int64, chunk []byte) error {
+		data, payload := h.encodeData(chunk)
+		h.sendResponse(reqID, "get_chunk", withPayload(GetChunk{
+			ChunkIndex: index,
+			Offset:     offset,
+			Data:       data,
+		}, payload))
+		index++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetValueResult{ValueLength: total, Chunks: index}, nil
+}
+
+type GetValueRangeParams struct {
+	Key    string `json:"key"`
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"` // <= 0 reads to the end
+}
+
+type GetValueRangeResult struct {
+	Data   string `json:"data"` // Base64
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"` // Bytes actually returned
+	Total  int64  `json:"total"`
+}
+
+func (h *Handler) handleGetValueRange(params json.RawMessage) (interface{}, error) {
+	var p GetValueRangeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	data, total, err := h.dbClient.GetValueRange(p.Key, p.Offset, p.Length)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, payload := h.encodeData(data)
+	return withPayload(GetValueRangeResult{
+		Data:   encoded,
+		Offset: p.Offset,
+		Length: int64(len(data)),
+		Total:  total,
+	}, payload), nil
+}
+
+// Encodings of an inline put_value.
+const (
+	EncodingUTF8   = "utf8"
+	EncodingBase64 = "base64"
+	EncodingHex    = "hex"
+	EncodingJSON   = "json"
+)
+
 type PutValueParams struct {
 	Key         string `json:"key"`
 	ValueLength int    `json:"value_length"`
 	TTL         int    `json:"ttl"`
+	UserMeta    *int   `json:"user_meta,omitempty"` // 0-255
+
+	// Inline value, written immediately instead of opening an upload session
+	Value    json.RawMessage `json:"value,omitempty"`
+	Encoding string          `json:"encoding,omitempty"` // utf8, base64 (default), hex or json
 }
 
-func (h *Handler) handlePutValue(reqID string, params json.RawMessage) (interface{}, error) {
+// handlePutValue writes an inline value, or opens an upload session identified by the request ID.
+// In length-prefixed mode a non-empty frame payload is the inline value.
+func (h *Handler) handlePutValue(reqID string, params json.RawMessage, payload []byte) (interface{}, error) {
 	var p PutValueParams
 	if err := json.Unmarshal(params, &p); err != nil {
 		return nil, err
 	}
 
-	// If small enough, maybe value is included?
-	// Spec says: "put_value" (large value chunking).
-	// But what if it's small?
-	// Let's assume standard put_value is just init for chunking OR
-	// we can support a "Value" field in params for small puts.
-	// The spec example shows "value_length" and then "put_chunk".
-	// Let's support both: if "value" is present, do it. If not, init chunking.
+	var userMeta byte
+	if p.UserMeta != nil {
+		if *p.UserMeta < 0 || *p.UserMeta > 255 {
+			return nil, fmt.Errorf("user_meta must be between 0 and 255")
+		}
+		userMeta = byte(*p.UserMeta)
+	}
 
-	// For now, let's implement the chunking init as per spec example.
-	h.mu.Lock()
-	h.chunkBuffer[reqID] = make([]byte, 0, p.ValueLength)
-	h.mu.Unlock()
+	if len(payload) > 0 {
+		err := h.dbClient.SetValueWithMeta(p.Key, payload, p.TTL, userMeta)
+		return nil, err
+	}
+	if len(p.Value) > 0 {
+		val, err := decodeInlineValue(p.Value, p.Encoding)
+		if err != nil {
+			return nil, err
+		}
+		err = h.dbClient.SetValueWithMeta(p.Key, val, p.TTL, userMeta)
+		return nil, err
+	}
 
+	if err := h.uploads.open(reqID, p.Key, p.TTL, userMeta, int64(p.ValueLength)); err != nil {
+		return nil, err
+	}
 	return nil, nil // Acknowledge init
 }
 
+// decodeInlineValue converts the value field of put_value to raw bytes.
+// For the json encoding the value may be any JSON document and is stored compacted.
+func decodeInlineValue(raw json.RawMessage, encoding string) ([]byte, error) {
+	if encoding == EncodingJSON {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("value must be a string for encoding %q", encoding)
+	}
+	switch encoding {
+	case EncodingUTF8:
+		return []byte(s), nil
+	case EncodingBase64, "":
+		return base64.StdEncoding.DecodeString(s)
+	case EncodingHex:
+		return hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	default:
+		return nil, fmt.Errorf("unknown encoding: %s", encoding)
+	}
+}
+
 type PutChunkParams struct {
 	ID         string `json:"id"` // Original Request ID
 	ChunkIndex int    `json:"chunk_index"`
 	Data       string `json:"data"` // Base64
 }
 
-func (h *Handler) handlePutChunk(params json.RawMessage) (interface{}, error) {
+// handlePutChunk adds a chunk to an upload session. In length-prefixed mode the
+// chunk is the frame payload and data is omitted.
+func (h *Handler) handlePutChunk(params json.RawMessage, payload []byte) (interface{}, error) {
 	var p PutChunkParams
 	if err := json.Unmarshal(params, &p); err != nil {
 		return nil, err
 	}
 
-	data, err := base64.StdEncoding.DecodeString(p.Data)
-	if err != nil {
-		return nil, err
-	}
-
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	buf, ok := h.chunkBuffer[p.ID]
-	if !ok {
-		return nil, fmt.Errorf("unknown upload session: %s", p.ID)
+	data := payload
+	if len(data) == 0 {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(p.Data); err != nil {
+			return nil, err
+		}
 	}
 
-	// Append
-	h.chunkBuffer[p.ID] = append(buf, data...)
-	return nil, nil
+	return nil, h.uploads.addChunk(p.ID, p.ChunkIndex, data)
 }
 
 type PutCommitParams struct {
-	ID  string `json:"id"`
-	Key string `json:"key"`
-	TTL int    `json:"ttl"`
+	ID           string `json:"id"`
+	Key          string `json:"key"`                     // Defaults to the key given to put_value
+	TTL          int    `json:"ttl"`                     // Defaults to the TTL given to put_value
+	Checksum     string `json:"checksum,omitempty"`      // Hex encoded
+	ChecksumAlgo string `json:"checksum_algo,omitempty"` // "crc32" or "sha256" (default)
 }
 
+// handlePutCommit assembles the chunks in index order, verifies them and
+// writes the value. The session survives a failed commit.
 func (h *Handler) handlePutCommit(params json.RawMessage) (interface{}, error) {
 	var p PutCommitParams
 	if err := json.Unmarshal(params, &p); err != nil {
 		return nil, err
 	}
 
-	h.mu.Lock()
-	buf, ok := h.chunkBuffer[p.ID]
-	delete(h.chunkBuffer, p.ID)
-	h.mu.Unlock()
+	err := h.uploads.commit(p.ID, func(s *uploadSession, buf []byte) error {
+		if p.Checksum != "" {
+			if err := verifyChecksum(p.ChecksumAlgo, p.Checksum, buf); err != nil {
+				return err
+			}
+		}
+		key, ttl := s.key, s.ttl
+		if p.Key != "" {
+			key = p.Key
+		}
+		if p.TTL != 0 {
+			ttl = p.TTL
+		}
+		return h.dbClient.SetValueWithMeta(key, buf, ttl, s.userMeta)
+	})
+	return nil, err
+}
+
+type UploadAbortParams struct {
+	ID string `json:"id"`
+}
 
-	if !ok {
-		return nil, fmt.Errorf("unknown upload session: %s", p.ID)
+// handleUploadAbort drops an upload session and its spooled data.
+func (h *Handler) handleUploadAbort(params json.RawMessage) (interface{}, error) {
+	var p UploadAbortParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
 	}
 
-	err := h.dbClient.SetValue(p.Key, buf, p.TTL)
-	return nil, err
+	return nil, h.uploads.abort(p.ID)
 }
 
 type DeleteKeyParams struct {
@@ -321,3 +831,100 @@ func (h *Handler) handleCloseDB() (interface{}, error) {
 	err := h.dbClient.Close()
 	return nil, err
 }
+
+type DiffParams struct {
+	Path   string `json:"path"` // The other DB, compared against the open one
+	Prefix string `json:"prefix"`
+	Mode   string `json:"mode"`
+	Limit  int    `json:"limit"`
+}
+
+type DiffResult struct {
+	Count int `json:"count"`
+}
+
+// handleDiff streams one "diff_entry" line per differing key before the final response.
+func (h *Handler) handleDiff(reqID string, params json.RawMessage) (interface{}, error) {
+	var p DiffParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	other := db.NewDBClient()
+	if err := other.Open(p.Path); err != nil {
+		return nil, err
+	}
+	defer other.Close()
+
+	opts := db.ListKeysOptions{Prefix: p.Prefix, Mode: p.Mode, Limit: p.Limit}
+	count := 0
+	err := db.Diff(h.dbClient, other, opts, func(e db.DiffEntry) error {
+		count++
+		h.sendResponse(reqID, "diff_entry", e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffResult{Count: count}, nil
+}
+
+type DiffApplyParams struct {
+	Path      string   `json:"path"`
+	Keys      []string `json:"keys"`
+	Direction string   `json:"direction"` // "a_to_b" (open DB -> path) or "b_to_a"
+}
+
+type DiffApplyResult struct {
+	Synced int `json:"synced"`
+}
+
+func (h *Handler) handleDiffApply(params json.RawMessage) (interface{}, error) {
+	var p DiffApplyParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	other := db.NewDBClient()
+	if err := other.Open(p.Path); err != nil {
+		return nil, err
+	}
+	defer other.Close()
+
+	var src, dst *db.DBClient
+	switch p.Direction {
+	case "a_to_b":
+		src, dst = h.dbClient, other
+	case "b_to_a":
+		src, dst = other, h.dbClient
+	default:
+		return nil, fmt.Errorf("unknown direction: %s", p.Direction)
+	}
+
+	n, err := db.SyncKeys(src, dst, p.Keys)
+	if err != nil {
+		return nil, err
+	}
+	return DiffApplyResult{Synced: n}, nil
+}
+
+type LoadDescriptorSetParams struct {
+	Path string `json:"path"`
+}
+
+type LoadDescriptorSetResult struct {
+	Messages []string `json:"messages"` // All loaded message types
+}
+
+func (h *Handler) handleLoadDescriptorSet(params json.RawMessage) (interface{}, error) {
+	var p LoadDescriptorSetParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	if err := codec.LoadDescriptorSet(p.Path); err != nil {
+		return nil, err
+	}
+	return LoadDescriptorSetResult{Messages: codec.ProtoMessages()}, nil
+}
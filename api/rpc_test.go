@@ -1,12 +1,22 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"badger_explorer_core/config"
 	"badger_explorer_core/db"
 )
 
@@ -48,8 +58,7 @@ func TestAPIHandler(t *testing.T) {
 		t.Fatalf("OpenDB failed: %v", resp.Error)
 	}
 
-	// 2. Put Value (Small - using chunk logic for now as per implementation)
-	// Note: Our implementation expects put_value -> put_chunk -> put_commit sequence for everything currently.
+	// 2. Put Value through the chunked path (put_value -> put_chunk -> put_commit)
 	key := "test-key"
 	val := "Hello World"
 	valBytes := []byte(val)
@@ -116,3 +125,1067 @@ func TestAPIHandler(t *testing.T) {
 		t.Fatalf("CloseDB failed: %v", resp.Error)
 	}
 }
+
+func TestGetValueDecodeWithRules(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-decode-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// Looks like plain text, but the rule forces hex
+	client.SetValue("raw:1", []byte("abc"), 0)
+	client.SetValue("doc:1", []byte(`{"a":1}`), 0)
+
+	cfg := config.DefaultConfig()
+	cfg.SetCodecRules([]config.CodecRule{{Pattern: "raw:*", Codec: "hex"}})
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+	handler.SetConfig(cfg)
+
+	decode := func(key string) GetValueResult {
+		params, _ := json.Marshal(GetValueParams{Key: key, Decode: "auto"})
+		reqBytes, _ := json.Marshal(Request{ID: "1", Type: TypeGetValue, Params: params})
+		handler.handleLine(reqBytes)
+
+		var resp struct {
+			Result GetValueResult `json:"result"`
+			Error  *Error         `json:"error"`
+		}
+		line, _ := outBuf.ReadBytes('\n')
+		if err := json.Unmarshal(line, &resp); err != nil || resp.Error != nil {
+			t.Fatalf("GetValue failed: %s", line)
+		}
+		return resp.Result
+	}
+
+	if got := decode("raw:1"); got.Codec != "hex" {
+		t.Errorf("Expected rule codec hex, got %s", got.Codec)
+	}
+	if got := decode("doc:1"); got.Codec != "json" || got.Decoded != "{\n  \"a\": 1\n}" {
+		t.Errorf("Expected detected json, got %s %q", got.Codec, got.Decoded)
+	}
+}
+
+func TestGetValueJSONPath(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-jsonpath-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetValue("doc", []byte(`{"users":[{"name":"kim"},{"name":"lee"}]}`), 0)
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+
+	params, _ := json.Marshal(GetValueParams{Key: "doc", JSONPath: "$.users[1]"})
+	reqBytes, _ := json.Marshal(Request{ID: "1", Type: TypeGetValue, Params: params})
+	handler.handleLine(reqBytes)
+
+	var resp struct {
+		Result GetValueResult `json:"result"`
+		Error  *Error         `json:"error"`
+	}
+	line, _ := outBuf.ReadBytes('\n')
+	if err := json.Unmarshal(line, &resp); err != nil || resp.Error != nil {
+		t.Fatalf("GetValue failed: %s", line)
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString(resp.Result.Value)
+	if string(raw) != `{"name":"lee"}` {
+		t.Errorf("Expected sub-document, got %s", raw)
+	}
+}
+
+func TestGetValueChunked(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-chunked-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetValue("big", []byte("0123456789"), 0)
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+
+	// Range read
+	params, _ := json.Marshal(GetValueRangeParams{Key: "big", Offset: 2, Length: 5})
+	reqBytes, _ := json.Marshal(Request{ID: "1", Type: TypeGetValueRange, Params: params})
+	handler.handleLine(reqBytes)
+
+	var rangeResp struct {
+		Result GetValueRangeResult `json:"result"`
+		Error  *Error              `json:"error"`
+	}
+	line, _ := outBuf.ReadBytes('\n')
+	if err := json.Unmarshal(line, &rangeResp); err != nil || rangeResp.Error != nil {
+		t.Fatalf("GetValueRange failed: %s", line)
+	}
+	data, _ := base64.StdEncoding.DecodeString(rangeResp.Result.Data)
+	if string(data) != "23456" || rangeResp.Result.Total != 10 {
+		t.Errorf("Unexpected range result: %+v", rangeResp.Result)
+	}
+
+	// Chunked get_value: get_chunk messages followed by the final response
+	params, _ = json.Marshal(GetValueParams{Key: "big", ChunkSize: 4})
+	reqBytes, _ = json.Marshal(Request{ID: "2", Type: TypeGetValue, Params: params})
+	handler.handleLine(reqBytes)
+
+	var assembled []byte
+	for i := 0; i < 3; i++ {
+		var chunk struct {
+			Type   string   `json:"type"`
+			Result GetChunk `json:"result"`
+		}
+		line, _ := outBuf.ReadBytes('\n')
+		if err := json.Unmarshal(line, &chunk); err != nil || chunk.Type != "get_chunk" {
+			t.Fatalf("Expected get_chunk, got %s", line)
+		}
+		if chunk.Result.ChunkIndex != i {
+			t.Errorf("Expected chunk %d, got %d", i, chunk.Result.ChunkIndex)
+		}
+		part, _ := base64.StdEncoding.DecodeString(chunk.Result.Data)
+		assembled = append(assembled, part...)
+	}
+
+	var final struct {
+		Type   string         `json:"type"`
+		Result GetValueResult `json:"result"`
+	}
+	line, _ = outBuf.ReadBytes('\n')
+	json.Unmarshal(line, &final)
+	if final.Type != "get_value_resp" || final.Result.ValueLength != 10 || final.Result.Chunks != 3 {
+		t.Errorf("Unexpected final response: %s", line)
+	}
+	if string(assembled) != "0123456789" {
+		t.Errorf("Expected reassembled value, got %q", assembled)
+	}
+}
+
+func TestUploadSessions(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-upload-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+	// Spool everything to exercise the temp file path
+	handler.SetUploadLimits(UploadLimits{MaxSessionSize: 64, MaxSessions: 2, SessionTTL: time.Minute, SpoolThreshold: 1})
+
+	send := func(id, typ string, params interface{}) *Error {
+		p, _ := json.Marshal(params)
+		reqBytes, _ := json.Marshal(Request{ID: id, Type: typ, Params: p})
+		handler.handleLine(reqBytes)
+		var resp Response
+		line, _ := outBuf.ReadBytes('\n')
+		json.Unmarshal(line, &resp)
+		return resp.Error
+	}
+	chunk := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+	// Chunks out of order are assembled by index
+	if e := send("u1", TypePutValue, PutValueParams{Key: "k", ValueLength: 11}); e != nil {
+		t.Fatalf("put_value failed: %v", e.Message)
+	}
+	send("c2", TypePutChunk, PutChunkParams{ID: "u1", ChunkIndex: 1, Data: chunk(" World")})
+	send("c1", TypePutChunk, PutChunkParams{ID: "u1", ChunkIndex: 0, Data: chunk("Hello")})
+	if e := send("c3", TypePutChunk, PutChunkParams{ID: "u1", ChunkIndex: 0, Data: chunk("Hello")}); e == nil {
+		t.Error("Expected duplicate chunk to fail")
+	}
+	sum := sha256.Sum256([]byte("Hello World"))
+	if e := send("c4", TypePutCommit, PutCommitParams{ID: "u1", Checksum: hex.EncodeToString(sum[:])}); e != nil {
+		t.Fatalf("put_commit failed: %v", e.Message)
+	}
+	if val, _ := client.GetValue("k"); string(val) != "Hello World" {
+		t.Errorf("Expected Hello World, got %q", val)
+	}
+
+	// Bad checksum and short uploads are rejected but keep their session
+	send("u2", TypePutValue, PutValueParams{Key: "k2", ValueLength: 3})
+	send("c5", TypePutChunk, PutChunkParams{ID: "u2", ChunkIndex: 0, Data: chunk("abc")})
+	if e := send("c6", TypePutCommit, PutCommitParams{ID: "u2", Checksum: "00000000", ChecksumAlgo: ChecksumCRC32}); e == nil {
+		t.Error("Expected checksum mismatch")
+	}
+	if _, ok := handler.uploads.sessions["u2"]; !ok {
+		t.Error("Expected session to survive a checksum mismatch")
+	}
+	send("a0", TypeUploadAbort, UploadAbortParams{ID: "u2"})
+	send("u3", TypePutValue, PutValueParams{Key: "k3", ValueLength: 3})
+	send("c7", TypePutChunk, PutChunkParams{ID: "u3", ChunkIndex: 0, Data: chunk("ab")})
+	if e := send("c8", TypePutCommit, PutCommitParams{ID: "u3"}); e == nil {
+		t.Error("Expected length mismatch")
+	}
+	// The missing chunk can still be sent
+	send("c9", TypePutChunk, PutChunkParams{ID: "u3", ChunkIndex: 1, Data: chunk("c")})
+	if e := send("c10", TypePutCommit, PutCommitParams{ID: "u3"}); e != nil {
+		t.Fatalf("put_commit after resend failed: %v", e.Message)
+	}
+	if val, _ := client.GetValue("k3"); string(val) != "abc" {
+		t.Errorf("Expected abc, got %q", val)
+	}
+	if _, err := client.GetValue("k2"); err == nil {
+		t.Error("Expected k2 not to be written")
+	}
+
+	// Limits
+	if e := send("u4", TypePutValue, PutValueParams{Key: "big", ValueLength: 65}); e == nil {
+		t.Error("Expected size limit error")
+	}
+	send("u5", TypePutValue, PutValueParams{Key: "a", ValueLength: 1})
+	send("u6", TypePutValue, PutValueParams{Key: "b", ValueLength: 1})
+	if e := send("u7", TypePutValue, PutValueParams{Key: "c", ValueLength: 1}); e == nil {
+		t.Error("Expected session count limit error")
+	}
+
+	// Abort frees a slot; expiry frees the rest
+	if e := send("a1", TypeUploadAbort, UploadAbortParams{ID: "u5"}); e != nil {
+		t.Fatalf("upload_abort failed: %v", e.Message)
+	}
+	handler.uploads.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if e := send("c11", TypePutChunk, PutChunkParams{ID: "u6", ChunkIndex: 0, Data: chunk("x")}); e == nil {
+		t.Error("Expected expired session to be gone")
+	}
+	if n := len(handler.uploads.sessions); n != 0 {
+		t.Errorf("Expected no sessions left, got %d", n)
+	}
+}
+
+func TestUploadSweep(t *testing.T) {
+	var outBuf bytes.Buffer
+	handler := NewHandler(db.NewDBClient(), &outBuf)
+	handler.SetUploadLimits(UploadLimits{MaxSessionSize: 64, MaxSessions: 2, SessionTTL: time.Millisecond, SpoolThreshold: 1})
+	defer handler.uploads.closeAll()
+
+	if err := handler.uploads.open("u1", "k", 0, 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	// Idle sessions go away without a further request
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		handler.uploads.mu.Lock()
+		n := len(handler.uploads.sessions)
+		handler.uploads.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the idle session to be swept")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPutValueInline(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-inline-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+
+	tests := []struct {
+		params string
+		want   string
+	}{
+		{`{"key":"a","value":"héllo","encoding":"utf8"}`, "héllo"},
+		{`{"key":"b","value":"aGk="}`, "hi"},
+		{`{"key":"c","value":"68 69","encoding":"hex"}`, "hi"},
+		{`{"key":"d","value":{"x": [1, 2]},"encoding":"json","user_meta":7,"ttl":60}`, `{"x":[1,2]}`},
+	}
+	for _, tt := range tests {
+		reqBytes, _ := json.Marshal(Request{ID: "1", Type: TypePutValue, Params: json.RawMessage(tt.params)})
+		handler.handleLine(reqBytes)
+
+		var resp Response
+		line, _ := outBuf.ReadBytes('\n')
+		json.Unmarshal(line, &resp)
+		if resp.Error != nil {
+			t.Fatalf("put_value %s failed: %v", tt.params, resp.Error.Message)
+		}
+	}
+	for i, key := range []string{"a", "b", "c", "d"} {
+		if val, _ := client.GetValue(key); string(val) != tests[i].want {
+			t.Errorf("Key %s: expected %q, got %q", key, tests[i].want, val)
+		}
+	}
+
+	// No upload session is left behind by inline puts
+	if n := len(handler.uploads.sessions); n != 0 {
+		t.Errorf("Expected no upload sessions, got %d", n)
+	}
+
+	reqBytes, _ := json.Marshal(Request{ID: "2", Type: TypePutValue, Params: json.RawMessage(`{"key":"e","value":1,"encoding":"utf8"}`)})
+	handler.handleLine(reqBytes)
+	var resp Response
+	line, _ := outBuf.ReadBytes('\n')
+	json.Unmarshal(line, &resp)
+	if resp.Error == nil {
+		t.Error("Expected error for non-string utf8 value")
+	}
+}
+
+func TestRunOrdersUploadsAndDrains(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-run-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	defer client.Close()
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+	handler.SetConcurrency(2, 1)
+
+	// One stream: open, then an upload of many chunks interleaved with reads
+	var in bytes.Buffer
+	enc := json.NewEncoder(&in)
+	write := func(id, typ string, params interface{}) {
+		p, _ := json.Marshal(params)
+		enc.Encode(Request{ID: id, Type: typ, Params: p})
+	}
+	write("open", TypeOpenDB, OpenDBParams{Path: tmpDir})
+
+	const chunks = 50
+	write("up", TypePutValue, PutValueParams{Key: "k", ValueLength: chunks})
+	for i := 0; i < chunks; i++ {
+		write(fmt.Sprintf("c%d", i), TypePutChunk, PutChunkParams{ID: "up", ChunkIndex: i, Data: base64.StdEncoding.EncodeToString([]byte{byte('a' + i%26)})})
+		write(fmt.Sprintf("l%d", i), TypeListKeys, ListKeysParams{Limit: 1})
+	}
+	write("commit", TypePutCommit, PutCommitParams{ID: "up"})
+
+	handler.Run(&in)
+
+	// Every request has been answered by the time Run returns
+	responses := 0
+	for _, line := range bytes.Split(bytes.TrimSpace(outBuf.Bytes()), []byte("\n")) {
+		var resp Response
+		if err := json.Unmarshal(line, &resp); err != nil {
+			t.Fatalf("Bad response line %s", line)
+		}
+		if resp.Error != nil {
+			t.Errorf("Request %s failed: %s", resp.ID, resp.Error.Message)
+		}
+		responses++
+	}
+	if want := 3 + 2*chunks; responses != want {
+		t.Errorf("Expected %d responses, got %d", want, responses)
+	}
+
+	val, err := client.GetValue("k")
+	if err != nil || len(val) != chunks {
+		t.Fatalf("Expected %d byte value, got %q (%v)", chunks, val, err)
+	}
+}
+
+func TestRunFraming(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-framing-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// Oversized lines get an error and reading continues
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+	handler.SetMaxFrameSize(100)
+	in := `{"id":"1","type":"put_value","params":{"key":"big","value":"` + strings.Repeat("x", 200) + `","encoding":"utf8"}}` + "\n" +
+		`{"id":"2","type":"put_value","params":{"key":"small","value":"ok","encoding":"utf8"}}` + "\n"
+	handler.Run(strings.NewReader(in))
+
+	var resp Response
+	dec := json.NewDecoder(&outBuf)
+	dec.Decode(&resp)
+	if resp.Error == nil || resp.Error.Code != 1004 {
+		t.Errorf("Expected frame size error, got %+v", resp)
+	}
+	resp = Response{}
+	dec.Decode(&resp)
+	if resp.ID != "2" || resp.Error != nil {
+		t.Errorf("Expected request after oversized line to succeed, got %+v", resp)
+	}
+
+	// Length-prefixed frames carry raw bytes in both directions
+	runFrames := func(header string, payload []byte) {
+		outBuf.Reset()
+		handler = NewHandler(client, &outBuf)
+		var frames bytes.Buffer
+		frames.WriteString(`{"id":"f","type":"framing","params":{"mode":"length_prefixed"}}` + "\n")
+		writeFrame(&frames, []byte(header), payload)
+		handler.Run(&frames)
+	}
+	raw := []byte{0x00, 0xff, '\n', 0x10}
+	runFrames(`{"id":"p","type":"put_value","params":{"key":"bin"}}`, raw)
+	if val, _ := client.GetValue("bin"); !bytes.Equal(val, raw) {
+		t.Errorf("Expected raw value %v, got %v", raw, val)
+	}
+	runFrames(`{"id":"g","type":"get_value","params":{"key":"bin"}}`, nil)
+
+	line, _ := outBuf.ReadBytes('\n')
+	json.Unmarshal(line, &resp)
+	if resp.Type != "framing_resp" {
+		t.Fatalf("Expected framing_resp line, got %s", line)
+	}
+
+	br := bufio.NewReader(&outBuf)
+	reader := &binaryReader{r: br, max: DefaultMaxFrameSize}
+	got := map[string][]byte{}
+	for {
+		header, payload, err := reader.next()
+		if err != nil {
+			break
+		}
+		var r Response
+		json.Unmarshal(header, &r)
+		if r.Error != nil {
+			t.Fatalf("Request %s failed: %s", r.ID, r.Error.Message)
+		}
+		got[r.ID] = payload
+	}
+	if !bytes.Equal(got["g"], raw) {
+		t.Errorf("Expected raw payload %v, got %v", raw, got["g"])
+	}
+}
+
+func TestJSONRPCMode(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-jsonrpc-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetValue("k", []byte("value"), 0)
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+	handler.SetJSONRPC(true)
+
+	call := func(line string) string {
+		outBuf.Reset()
+		handler.handleLine([]byte(line))
+		return strings.TrimSpace(outBuf.String())
+	}
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`{"jsonrpc":"2.0","method":"put_value","params":{"key":"a","value":"x","encoding":"utf8"},"id":1}`,
+			`{"jsonrpc":"2.0","id":1,"result":null}`},
+		{`{"jsonrpc":"2.0","method":"nope","id":"x"}`,
+			`{"jsonrpc":"2.0","id":"x","error":{"code":-32601,"message":"Unknown request type"}}`},
+		{`{"jsonrpc":"2.0","method":"get_value","params":{"key":1},"id":2}`,
+			`"code":-32602`},
+		{`{"jsonrpc":"2.0","method":"get_value","params":{"key":"missing"},"id":3}`,
+			`"code":1104,"message":"key not found: missing","data":{"key":"missing"}`},
+		{`{not json`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error"}}`},
+		{`{"method":"get_value","id":4}`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request"}}`},
+		{`[]`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request"}}`},
+		// Notifications get no response
+		{`{"jsonrpc":"2.0","method":"put_value","params":{"key":"b","value":"y","encoding":"utf8"}}`,
+			``},
+	}
+	for _, tt := range tests {
+		got := call(tt.line)
+		if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+			t.Errorf("%s:\n got %s\nwant %s", tt.line, got, tt.want)
+		}
+	}
+	if val, _ := client.GetValue("b"); string(val) != "y" {
+		t.Errorf("Notification was not executed")
+	}
+
+	// Batch: one array with a response per non-notification request
+	got := call(`[{"jsonrpc":"2.0","method":"get_value","params":{"key":"k"},"id":1},` +
+		`{"jsonrpc":"2.0","method":"put_value","params":{"key":"c","value":"z","encoding":"utf8"}},` +
+		`{"jsonrpc":"2.0","method":"nope","id":2},1]`)
+	var batch []rpcResponse
+	if err := json.Unmarshal([]byte(got), &batch); err != nil || len(batch) != 3 {
+		t.Fatalf("Expected 3 batch responses, got %s", got)
+	}
+
+	// "1" and 1 are different calls; a duplicate in-flight id is refused
+	outBuf.Reset()
+	pending := handler.parseRequests([]byte(`{"jsonrpc":"2.0","method":"get_value","params":{"key":"k"},"id":1}`))
+	pending = append(pending, handler.parseRequests([]byte(`{"jsonrpc":"2.0","method":"get_value","params":{"key":"a"},"id":"1"}`))...)
+	if len(pending) != 2 || outBuf.Len() != 0 {
+		t.Fatalf("Expected both calls to be accepted, got %d: %s", len(pending), outBuf.String())
+	}
+	handler.parseRequests([]byte(`{"jsonrpc":"2.0","method":"get_value","params":{"key":"a"},"id":1}`))
+	if want := `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"Duplicate request id"}}`; strings.TrimSpace(outBuf.String()) != want {
+		t.Errorf("Expected %s, got %s", want, outBuf.String())
+	}
+	outBuf.Reset()
+	for _, req := range pending {
+		handler.handleRequest(req)
+	}
+	if want := `{"jsonrpc":"2.0","id":1,"result":{"value":"dmFsdWU="}`; !strings.Contains(outBuf.String(), want) {
+		t.Errorf("Expected %s, got %s", want, outBuf.String())
+	}
+	if want := `{"jsonrpc":"2.0","id":"1","result":{"value":"eA=="}`; !strings.Contains(outBuf.String(), want) {
+		t.Errorf("Expected %s, got %s", want, outBuf.String())
+	}
+	// Once answered, the id can be used again
+	if got := call(`{"jsonrpc":"2.0","method":"get_value","params":{"key":"a"},"id":1}`); !strings.Contains(got, `"result"`) {
+		t.Errorf("Expected a result for a reused id, got %s", got)
+	}
+
+	// Streaming messages become notifications carrying the request id
+	got = call(`{"jsonrpc":"2.0","method":"get_value","params":{"key":"k","chunk_size":4},"id":"s"}`)
+	lines := strings.Split(got, "\n")
+	if len(lines) != 3 || !strings.Contains(lines[0], `"method":"get_chunk","params":{"id":"s"`) {
+		t.Errorf("Unexpected stream: %s", got)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-errors-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+
+	send := func(typ, params string) *Error {
+		outBuf.Reset()
+		reqBytes, _ := json.Marshal(Request{ID: "1", Type: typ, Params: json.RawMessage(params)})
+		handler.handleLine(reqBytes)
+		var resp Response
+		json.Unmarshal(outBuf.Bytes(), &resp)
+		if resp.Error == nil {
+			t.Fatalf("%s %s: expected an error", typ, params)
+		}
+		return resp.Error
+	}
+
+	if e := send(TypeGetValue, `{"key":"k"}`); e.Code != CodeNotOpen {
+		t.Errorf("Expected not open code, got %d", e.Code)
+	}
+	if e := send(TypeOpenDB, `{"path":"`+tmpDir+`/missing"}`); e.Code != CodePathNotFound || e.Data == nil || e.Data.Path == "" {
+		t.Errorf("Expected path not found with path, got %+v", e)
+	}
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if e := send(TypeOpenDB, `{"path":"`+tmpDir+`"}`); e.Code != CodeAlreadyOpen {
+		t.Errorf("Expected already open code, got %d", e.Code)
+	}
+	if e := send(TypeGetValue, `{"key":"missing"}`); e.Code != CodeKeyNotFound || e.Data == nil || e.Data.Key != "missing" {
+		t.Errorf("Expected key not found with key, got %+v", e)
+	}
+	if e := send(TypeListKeys, `{"prefix":"(","mode":"regex"}`); e.Code != CodeInvalidRegex || e.Data == nil || e.Data.Pattern != "(" {
+		t.Errorf("Expected invalid regex with pattern, got %+v", e)
+	}
+	if e := send(TypeGetValue, `{"key":1}`); e.Code != CodeInvalidParams {
+		t.Errorf("Expected invalid params code, got %d", e.Code)
+	}
+	if e := send("nope", `{}`); e.Code != CodeUnknownRequest {
+		t.Errorf("Expected unknown request code, got %d", e.Code)
+	}
+}
+
+func TestHello(t *testing.T) {
+	var outBuf bytes.Buffer
+	handler := NewHandler(db.NewDBClient(), &outBuf)
+
+	send := func(params string) Response {
+		outBuf.Reset()
+		reqBytes, _ := json.Marshal(Request{ID: "1", Type: TypeHello, Params: json.RawMessage(params)})
+		handler.handleLine(reqBytes)
+		var resp Response
+		json.Unmarshal(outBuf.Bytes(), &resp)
+		return resp
+	}
+
+	resp := send(`{"client":"test","protocol_versions":[1,99]}`)
+	if resp.Error != nil {
+		t.Fatalf("hello failed: %v", resp.Error.Message)
+	}
+	resultBytes, _ := json.Marshal(resp.Result)
+	var hello HelloResult
+	json.Unmarshal(resultBytes, &hello)
+
+	if hello.ProtocolVersion != 1 || hello.CoreVersion == "" || hello.Limits.MaxFrameSize != DefaultMaxFrameSize {
+		t.Errorf("Unexpected hello result: %+v", hello)
+	}
+	if len(hello.Codecs) == 0 || len(hello.SearchModes) != 3 {
+		t.Errorf("Expected codecs and search modes, got %+v", hello)
+	}
+
+	// Every advertised request type is handled
+	for _, typ := range hello.RequestTypes {
+		outBuf.Reset()
+		reqBytes, _ := json.Marshal(Request{ID: "1", Type: typ, Params: json.RawMessage(`{}`)})
+		handler.handleLine(reqBytes)
+		var r Response
+		json.Unmarshal(outBuf.Bytes(), &r)
+		if r.Error != nil && r.Error.Code == CodeUnknownRequest {
+			t.Errorf("Advertised type %s is not handled", typ)
+		}
+	}
+
+	if resp := send(`{"protocol_versions":[99]}`); resp.Error == nil {
+		t.Error("Expected error without a common protocol version")
+	}
+}
+
+// lockedBuffer is an output buffer that can be read while the handler writes to it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSubscribe(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-subscribe-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	out := &lockedBuffer{}
+	handler := NewHandler(client, out)
+
+	params, _ := json.Marshal(SubscribeParams{Prefixes: []string{"user:"}})
+	reqBytes, _ := json.Marshal(Request{ID: "sub1", Type: TypeSubscribe, Params: params})
+	handler.handleLine(reqBytes)
+	if !strings.Contains(out.String(), `"type":"subscribe_resp"`) {
+		t.Fatalf("subscribe failed: %s", out.String())
+	}
+
+	// The subscription starts asynchronously; write until an event shows up
+	seen := false
+	for i := 0; i < 100 && !seen; i++ {
+		client.SetValue("other", []byte("x"), 0)
+		client.SetValue("user:1", []byte("x"), 0)
+		time.Sleep(20 * time.Millisecond)
+		seen = strings.Contains(out.String(), `"type":"key_changed","result":{"key":"user:1"`)
+	}
+	if !seen {
+		t.Fatalf("No key_changed event: %s", out.String())
+	}
+	if strings.Contains(out.String(), `"key":"other"`) {
+		t.Error("Received event for a key outside the prefixes")
+	}
+
+	params, _ = json.Marshal(UnsubscribeParams{ID: "sub1"})
+	reqBytes, _ = json.Marshal(Request{ID: "2", Type: TypeUnsubscribe, Params: params})
+	handler.handleLine(reqBytes)
+	if !strings.Contains(out.String(), `"type":"unsubscribe_resp"`) {
+		t.Fatalf("unsubscribe failed: %s", out.String())
+	}
+
+	before := out.String()
+	client.SetValue("user:2", []byte("x"), 0)
+	time.Sleep(50 * time.Millisecond)
+	if strings.Contains(strings.TrimPrefix(out.String(), before), "key_changed") {
+		t.Error("Received event after unsubscribe")
+	}
+}
+
+func TestSubscribeAfterResponse(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-subscribe-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	out := &lockedBuffer{}
+	handler := NewHandler(client, out)
+	handler.SetJSONRPC(true)
+	defer handler.closeSubscriptions()
+
+	// A write in the same batch comes before the batch response, so no event may precede it
+	handler.handleLine([]byte(`[{"jsonrpc":"2.0","method":"subscribe","params":{"prefixes":["user:"]},"id":1},` +
+		`{"jsonrpc":"2.0","method":"put_value","params":{"key":"user:0","value":"x","encoding":"utf8"},"id":2}]`))
+	seen := false
+	for i := 0; i < 100 && !seen; i++ {
+		client.SetValue("user:1", []byte("x"), 0)
+		time.Sleep(20 * time.Millisecond)
+		seen = strings.Contains(out.String(), `"method":"key_changed"`)
+	}
+	if !seen {
+		t.Fatalf("No key_changed event: %s", out.String())
+	}
+	if first, _, _ := strings.Cut(out.String(), "\n"); !strings.HasPrefix(first, "[") || !strings.Contains(first, `"result":{"id":"1"}`) {
+		t.Errorf("Expected the batch response first, got %s", out.String())
+	}
+}
+
+func TestSavedQueries(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-queries-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetValue("User:1", []byte("a"), 0)
+	client.SetValue("user:2", []byte("b"), 0)
+	client.SetValue("raw:1", []byte("c"), 0)
+
+	cfgPath := tmpDir + "-config.json"
+	defer os.Remove(cfgPath)
+	cfg, _ := config.LoadConfig(cfgPath)
+	cfg.SetCodecRules([]config.CodecRule{{Pattern: "raw:*", Codec: "hex"}})
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+	handler.SetConfig(cfg)
+
+	call := func(typ string, params interface{}, result interface{}) *Error {
+		p, _ := json.Marshal(params)
+		reqBytes, _ := json.Marshal(Request{ID: "1", Type: typ, Params: p})
+		handler.handleLine(reqBytes)
+
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+			Error  *Error          `json:"error"`
+		}
+		line, _ := outBuf.ReadBytes('\n')
+		if err := json.Unmarshal(line, &resp); err != nil {
+			t.Fatalf("Bad response %s", line)
+		}
+		if result != nil && resp.Error == nil {
+			json.Unmarshal(resp.Result, result)
+		}
+		return resp.Error
+	}
+
+	// Case-insensitive search, recorded in the history
+	var list ListKeysResult
+	if e := call(TypeListKeys, ListKeysParams{Prefix: "user:", Mode: "prefix", Limit: 10, IgnoreCase: true, Record: true}, &list); e != nil {
+		t.Fatal(e.Message)
+	}
+	if len(list.Keys) != 2 {
+		t.Errorf("Expected 2 keys ignoring case, got %d", len(list.Keys))
+	}
+	if e := call(TypeListKeys, ListKeysParams{Mode: "prefix", Limit: 10, Codec: "hex"}, &list); e != nil || len(list.Keys) != 1 || list.Keys[0].Key != "raw:1" {
+		t.Errorf("Codec filter returned %+v", list.Keys)
+	}
+
+	q := config.SavedQuery{Name: "users", Term: "user:", Mode: "prefix", IgnoreCase: true}
+	if e := call(TypeSaveQuery, q, nil); e != nil {
+		t.Fatal(e.Message)
+	}
+	if e := call(TypeSaveQuery, config.SavedQuery{Term: "x"}, nil); e == nil {
+		t.Error("Expected error for a query without name")
+	}
+
+	var saved ListSavedQueriesResult
+	if e := call(TypeListSavedQueries, nil, &saved); e != nil {
+		t.Fatal(e.Message)
+	}
+	if len(saved.Saved) != 1 || saved.Saved[0] != q {
+		t.Errorf("Unexpected saved queries: %+v", saved.Saved)
+	}
+	if len(saved.History) != 1 || saved.History[0] != "user:" {
+		t.Errorf("Unexpected history: %v", saved.History)
+	}
+
+	// Persisted to the config file
+	reloaded, err := config.LoadConfig(cfgPath)
+	if err != nil || len(reloaded.GetSavedQueries()) != 1 || len(reloaded.GetSearchHistory(tmpDir)) != 1 {
+		t.Errorf("Queries not persisted: %v", err)
+	}
+
+	if e := call(TypeDeleteSavedQuery, DeleteSavedQueryParams{Name: "users"}, nil); e != nil {
+		t.Fatal(e.Message)
+	}
+	if e := call(TypeDeleteSavedQuery, DeleteSavedQueryParams{Name: "users"}, nil); e == nil {
+		t.Error("Expected error deleting an unknown query")
+	}
+
+	// A history that cannot be saved fails the request
+	unwritable, err := config.LoadConfig(filepath.Join(tmpDir, "missing", "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.SetConfig(unwritable)
+	if e := call(TypeListKeys, ListKeysParams{Prefix: "user:", Mode: "prefix", Record: true}, &list); e == nil {
+		t.Error("Expected error when the config cannot be saved")
+	}
+}
+
+func TestBookmarks(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-bookmarks-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetValue("flag:beta", []byte("on"), 0)
+
+	cfgPath := tmpDir + "-config.json"
+	defer os.Remove(cfgPath)
+	cfg, _ := config.LoadConfig(cfgPath)
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+	handler.SetConfig(cfg)
+
+	call := func(typ string, params interface{}, result interface{}) *Error {
+		p, _ := json.Marshal(params)
+		reqBytes, _ := json.Marshal(Request{ID: "1", Type: typ, Params: p})
+		handler.handleLine(reqBytes)
+
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+			Error  *Error          `json:"error"`
+		}
+		line, _ := outBuf.ReadBytes('\n')
+		if err := json.Unmarshal(line, &resp); err != nil {
+			t.Fatalf("Bad response %s", line)
+		}
+		if result != nil && resp.Error == nil {
+			json.Unmarshal(resp.Result, result)
+		}
+		return resp.Error
+	}
+
+	for _, key := range []string{"flag:beta", "schema:version", "flag:beta"} {
+		if e := call(TypeAddBookmark, BookmarkParams{Key: key}, nil); e != nil {
+			t.Fatal(e.Message)
+		}
+	}
+	client.SetValue("flag:beta", []byte("off"), 0)
+
+	var list ListBookmarksResult
+	if e := call(TypeListBookmarks, nil, &list); e != nil {
+		t.Fatal(e.Message)
+	}
+	if len(list.Bookmarks) != 2 {
+		t.Fatalf("Expected 2 bookmarks, got %+v", list.Bookmarks)
+	}
+	beta, missing := list.Bookmarks[0], list.Bookmarks[1]
+	if beta.Key != "flag:beta" || !beta.Exists || beta.Preview != "off" || beta.Version == 0 {
+		t.Errorf("Unexpected bookmark %+v", beta)
+	}
+	if missing.Key != "schema:version" || missing.Exists {
+		t.Errorf("Expected a missing bookmark, got %+v", missing)
+	}
+
+	reloaded, err := config.LoadConfig(cfgPath)
+	if err != nil || len(reloaded.GetBookmarks(tmpDir)) != 2 {
+		t.Errorf("Bookmarks not persisted: %v", err)
+	}
+
+	if e := call(TypeRemoveBookmark, BookmarkParams{Key: "schema:version"}, nil); e != nil {
+		t.Fatal(e.Message)
+	}
+	if e := call(TypeRemoveBookmark, BookmarkParams{Key: "schema:version"}, nil); e == nil {
+		t.Error("Expected error removing a key that is not bookmarked")
+	}
+	if got := cfg.GetBookmarks(tmpDir); len(got) != 1 || got[0] != "flag:beta" {
+		t.Errorf("Unexpected bookmarks after removal: %v", got)
+	}
+}
+
+func TestRenameAndCopyKey(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-rename-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetValue("v1/a", []byte("1"), 0)
+	client.SetValue("v1/b", []byte("2"), 0)
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+
+	call := func(typ string, params interface{}, result interface{}) *Error {
+		p, _ := json.Marshal(params)
+		reqBytes, _ := json.Marshal(Request{ID: "1", Type: typ, Params: p})
+		handler.handleLine(reqBytes)
+
+		var resp struct {
+			Result json.RawMessage `json:"result"`
+			Error  *Error          `json:"error"`
+		}
+		line, _ := outBuf.ReadBytes('\n')
+		if err := json.Unmarshal(line, &resp); err != nil {
+			t.Fatalf("Bad response %s", line)
+		}
+		if result != nil && resp.Error == nil {
+			json.Unmarshal(resp.Result, result)
+		}
+		return resp.Error
+	}
+
+	if e := call(TypeCopyKey, RenameKeyParams{From: "v1/a", To: "v1/c"}, nil); e != nil {
+		t.Fatal(e.Message)
+	}
+	if e := call(TypeRenameKey, RenameKeyParams{From: "v1/a", To: "v1/b"}, nil); e == nil || e.Code != CodeKeyExists {
+		t.Errorf("Expected code %d, got %+v", CodeKeyExists, e)
+	}
+
+	var res db.BulkResult
+	if e := call(TypeRenameKey, RenameKeyParams{From: "v1/", To: "v2/", Prefix: true}, &res); e != nil {
+		t.Fatal(e.Message)
+	}
+	if res.Done != 3 {
+		t.Errorf("Expected 3 keys renamed, got %+v", res)
+	}
+	if v, err := client.GetValue("v2/c"); err != nil || string(v) != "1" {
+		t.Errorf("v2/c = %q, %v", v, err)
+	}
+	if n, _ := client.CountKeys(context.Background(), db.ListKeysOptions{Prefix: "v1/", Mode: "prefix"}); n.Keys != 0 {
+		t.Errorf("Expected no keys left under v1/, got %d", n.Keys)
+	}
+}
+
+func TestCountKeys(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-api-count-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.SetValue("user:1", []byte("abc"), 0)
+	client.SetValue("user:2", []byte("de"), 0)
+	client.SetValue("order:1", []byte("f"), 0)
+
+	var outBuf bytes.Buffer
+	handler := NewHandler(client, &outBuf)
+
+	count := func(params CountKeysParams) db.KeyCount {
+		p, _ := json.Marshal(params)
+		reqBytes, _ := json.Marshal(Request{ID: "1", Type: TypeCountKeys, Params: p})
+		handler.handleLine(reqBytes)
+
+		var resp struct {
+			Result db.KeyCount `json:"result"`
+			Error  *Error      `json:"error"`
+		}
+		line, _ := outBuf.ReadBytes('\n')
+		if err := json.Unmarshal(line, &resp); err != nil || resp.Error != nil {
+			t.Fatalf("Bad response %s", line)
+		}
+		return resp.Result
+	}
+
+	if got := count(CountKeysParams{Prefix: "user:", Mode: "prefix"}); got != (db.KeyCount{Keys: 2, Bytes: 5}) {
+		t.Errorf("Unexpected count %+v", got)
+	}
+	if got := count(CountKeysParams{Prefix: ":1", Mode: "substring"}); got.Keys != 2 || got.Approx {
+		t.Errorf("Unexpected count %+v", got)
+	}
+	// Substring searches cannot be estimated and are counted exactly
+	if got := count(CountKeysParams{Prefix: "USER", Mode: "substring", IgnoreCase: true, Estimate: true}); got.Keys != 2 || got.Approx {
+		t.Errorf("Unexpected count %+v", got)
+	}
+	if got := count(CountKeysParams{Mode: "prefix", Estimate: true}); !got.Approx {
+		t.Errorf("Expected an estimate, got %+v", got)
+	}
+
+	// Cancelling the handler's context stops counts
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	handler.SetContext(ctx)
+	p, _ := json.Marshal(CountKeysParams{Prefix: "order:", Mode: "prefix"})
+	reqBytes, _ := json.Marshal(Request{ID: "2", Type: TypeCountKeys, Params: p})
+	handler.handleLine(reqBytes)
+	var resp Response
+	json.Unmarshal(outBuf.Bytes(), &resp)
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, "context canceled") {
+		t.Errorf("Expected a cancelled count, got %s", outBuf.String())
+	}
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"badger_explorer_core/db"
+)
+
+type SubscribeParams struct {
+	Prefixes []string `json:"prefixes"` // Empty watches every key
+}
+
+type SubscribeResult struct {
+	ID string `json:"id"` // Subscription ID, the ID of the subscribe request
+}
+
+type UnsubscribeParams struct {
+	ID string `json:"id"`
+}
+
+// SubscriptionClosed is sent when a subscription ends without unsubscribe,
+// e.g. because the database was closed.
+type SubscriptionClosed struct {
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+type subscription struct {
+	cancel context.CancelFunc
+	rpcID  json.RawMessage // Request id in JSON-RPC mode
+	done   chan struct{}
+}
+
+// subscriptions tracks the active subscriptions of a handler.
+type subscriptions struct {
+	mu   sync.Mutex
+	subs map[string]*subscription
+}
+
+// startedResult carries a result with work that may only start once the
+// response is written, such as pushing messages that refer to it.
+type startedResult struct {
+	result interface{}
+	start  func()
+}
+
+// handleSubscribe starts pushing "key_changed" messages for the request ID
+// once the response is sent. rpcID is the raw id of the request in JSON-RPC mode.
+func (h *Handler) handleSubscribe(reqID string, rpcID json.RawMessage, params json.RawMessage) (interface{}, error) {
+	var p SubscribeParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+	}
+	if !h.dbClient.IsOpen() {
+		return nil, db.ErrNotOpen
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &subscription{cancel: cancel, rpcID: rpcID, done: make(chan struct{})}
+
+	h.subs.mu.Lock()
+	if _, ok := h.subs.subs[reqID]; ok {
+		h.subs.mu.Unlock()
+		cancel()
+		return nil, fmt.Errorf("subscription already exists: %s", reqID)
+	}
+	h.subs.subs[reqID] = sub
+	h.subs.mu.Unlock()
+
+	start := func() {
+		go h.deliver(ctx, reqID, sub, p.Prefixes)
+	}
+	return startedResult{result: SubscribeResult{ID: reqID}, start: start}, nil
+}
+
+// deliver pushes the events of a subscription until it is cancelled or the
+// database ends it.
+func (h *Handler) deliver(ctx context.Context, reqID string, sub *subscription, prefixes []string) {
+	defer close(sub.done)
+	err := h.dbClient.Subscribe(ctx, prefixes, func(events []db.KeyEvent) error {
+		for _, e := range events {
+			h.sendEvent(reqID, sub.rpcID, "key_changed", e)
+		}
+		return nil
+	})
+
+	// Ended by the database rather than by unsubscribe
+	if ctx.Err() == nil {
+		h.subs.mu.Lock()
+		delete(h.subs.subs, reqID)
+		h.subs.mu.Unlock()
+
+		closed := SubscriptionClosed{ID: reqID}
+		if err != nil {
+			closed.Error = err.Error()
+		}
+		h.sendEvent(reqID, sub.rpcID, "subscription_closed", closed)
+	}
+}
+
+func (h *Handler) handleUnsubscribe(params json.RawMessage) (interface{}, error) {
+	var p UnsubscribeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	h.subs.mu.Lock()
+	sub, ok := h.subs.subs[p.ID]
+	delete(h.subs.subs, p.ID)
+	h.subs.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown subscription: %s", p.ID)
+	}
+	sub.cancel()
+	<-sub.done // No events follow the response
+	return nil, nil
+}
+
+// closeSubscriptions ends every subscription.
+func (h *Handler) closeSubscriptions() {
+	h.subs.mu.Lock()
+	subs := h.subs.subs
+	h.subs.subs = make(map[string]*subscription)
+	h.subs.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.cancel()
+		<-sub.done
+	}
+}
+
+// sendEvent pushes a message for a long-lived request, after its response was sent.
+func (h *Handler) sendEvent(id string, rpcID json.RawMessage, typeStr string, result interface{}) {
+	if !h.jsonrpc {
+		h.sendResponse(id, typeStr, result)
+		return
+	}
+	h.writeJSON(rpcNotification{
+		JSONRPC: "2.0",
+		Method:  typeStr,
+		Params:  rpcStreamParams{ID: rpcID, Result: result},
+	})
+}
@@ -0,0 +1,304 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UploadLimits bounds the chunked upload sessions of a handler.
+type UploadLimits struct {
+	MaxSessionSize int64         // Largest value a session may upload
+	MaxSessions    int           // Sessions open at the same time
+	SessionTTL     time.Duration // Idle time after which a session is dropped
+	SpoolThreshold int64         // Uploads at least this large are spooled to a temp file
+}
+
+// DefaultUploadLimits are used unless the handler is given other limits.
+var DefaultUploadLimits = UploadLimits{
+	MaxSessionSize: 512 << 20,
+	MaxSessions:    16,
+	SessionTTL:     5 * time.Minute,
+	SpoolThreshold: 8 << 20,
+}
+
+// Checksum algorithms accepted by put_commit.
+const (
+	ChecksumCRC32  = "crc32"
+	ChecksumSHA256 = "sha256"
+)
+
+// uploadChunk is one received chunk, held in memory or as a segment of the spool file.
+type uploadChunk struct {
+	data   []byte
+	offset int64
+	size   int64
+}
+
+type uploadSession struct {
+	key         string
+	ttl         int
+	userMeta    byte
+	valueLength int64
+	received    int64
+	chunks      map[int]uploadChunk
+	spool       *os.File // nil when chunks are kept in memory
+	spoolSize   int64
+	lastActive  time.Time
+	committing  bool // Being assembled and written; not expired meanwhile
+}
+
+// add stores a chunk. Chunks may arrive in any order but each index only once.
+func (s *uploadSession) add(index int, data []byte) error {
+	if index < 0 {
+		return fmt.Errorf("invalid chunk_index: %d", index)
+	}
+	if _, ok := s.chunks[index]; ok {
+		return fmt.Errorf("chunk %d already received", index)
+	}
+	if s.received+int64(len(data)) > s.valueLength {
+		return fmt.Errorf("upload exceeds value_length of %d bytes", s.valueLength)
+	}
+
+	c := uploadChunk{size: int64(len(data))}
+	if s.spool != nil {
+		if _, err := s.spool.WriteAt(data, s.spoolSize); err != nil {
+			return err
+		}
+		c.offset = s.spoolSize
+		s.spoolSize += c.size
+	} else {
+		c.data = data
+	}
+
+	s.chunks[index] = c
+	s.received += c.size
+	return nil
+}
+
+// assemble joins the chunks in index order, failing if any index is missing.
+func (s *uploadSession) assemble() ([]byte, error) {
+	if s.received != s.valueLength {
+		return nil, fmt.Errorf("received %d bytes, expected value_length %d", s.received, s.valueLength)
+	}
+
+	indices := make([]int, 0, len(s.chunks))
+	for i := range s.chunks {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	buf := make([]byte, 0, s.valueLength)
+	for n, i := range indices {
+		if i != n {
+			return nil, fmt.Errorf("missing chunk %d", n)
+		}
+		c := s.chunks[i]
+		if s.spool == nil {
+			buf = append(buf, c.data...)
+			continue
+		}
+		seg := buf[len(buf) : len(buf)+int(c.size)]
+		if _, err := s.spool.ReadAt(seg, c.offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		buf = buf[:len(buf)+int(c.size)]
+	}
+	return buf, nil
+}
+
+func (s *uploadSession) close() {
+	if s.spool != nil {
+		s.spool.Close()
+		os.Remove(s.spool.Name())
+		s.spool = nil
+	}
+	s.chunks = nil
+}
+
+// uploadStore holds the open upload sessions, keyed by the ID of their put_value request.
+type uploadStore struct {
+	mu       sync.Mutex
+	limits   UploadLimits
+	sessions map[string]*uploadSession
+	now      func() time.Time
+	sweep    *time.Timer // Expires idle sessions while any are open
+}
+
+func newUploadStore(limits UploadLimits) *uploadStore {
+	return &uploadStore{
+		limits:   limits,
+		sessions: make(map[string]*uploadSession),
+		now:      time.Now,
+	}
+}
+
+// expire drops sessions idle for longer than SessionTTL. Callers hold mu.
+func (u *uploadStore) expire() {
+	if u.limits.SessionTTL <= 0 {
+		return
+	}
+	deadline := u.now().Add(-u.limits.SessionTTL)
+	for id, s := range u.sessions {
+		if !s.committing && s.lastActive.Before(deadline) {
+			s.close()
+			delete(u.sessions, id)
+		}
+	}
+}
+
+// scheduleSweep arms a timer that expires idle sessions, so their spool
+// files go away even when no further request arrives. It rearms itself
+// while sessions remain. Callers hold mu.
+func (u *uploadStore) scheduleSweep() {
+	if u.sweep != nil || len(u.sessions) == 0 || u.limits.SessionTTL <= 0 {
+		return
+	}
+	u.sweep = time.AfterFunc(max(u.limits.SessionTTL/2, time.Second), func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		u.sweep = nil
+		u.expire()
+		u.scheduleSweep()
+	})
+}
+
+func (u *uploadStore) open(id, key string, ttl int, userMeta byte, valueLength int64) error {
+	if valueLength < 0 {
+		return fmt.Errorf("invalid value_length: %d", valueLength)
+	}
+	if u.limits.MaxSessionSize > 0 && valueLength > u.limits.MaxSessionSize {
+		return fmt.Errorf("value_length %d exceeds the upload limit of %d bytes", valueLength, u.limits.MaxSessionSize)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.expire()
+	if _, ok := u.sessions[id]; ok {
+		return fmt.Errorf("upload session already exists: %s", id)
+	}
+	if u.limits.MaxSessions > 0 && len(u.sessions) >= u.limits.MaxSessions {
+		return fmt.Errorf("too many upload sessions (max %d)", u.limits.MaxSessions)
+	}
+
+	s := &uploadSession{
+		key:         key,
+		ttl:         ttl,
+		userMeta:    userMeta,
+		valueLength: valueLength,
+		chunks:      make(map[int]uploadChunk),
+		lastActive:  u.now(),
+	}
+	if u.limits.SpoolThreshold > 0 && valueLength >= u.limits.SpoolThreshold {
+		f, err := os.CreateTemp("", "badger-explorer-upload-*")
+		if err != nil {
+			return err
+		}
+		s.spool = f
+	}
+	u.sessions[id] = s
+	u.scheduleSweep()
+	return nil
+}
+
+func (u *uploadStore) addChunk(id string, index int, data []byte) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.expire()
+	s, ok := u.sessions[id]
+	if !ok {
+		return fmt.Errorf("unknown upload session: %s", id)
+	}
+	s.lastActive = u.now()
+	return s.add(index, data)
+}
+
+// commit assembles a session and hands its value to write. The session is
+// removed once write succeeds; after a failure it stays open, so the client
+// can send missing chunks and commit again, or abort it.
+func (u *uploadStore) commit(id string, write func(s *uploadSession, data []byte) error) error {
+	u.mu.Lock()
+	u.expire()
+	s, ok := u.sessions[id]
+	if ok {
+		s.committing = true
+	}
+	u.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("unknown upload session: %s", id)
+	}
+
+	data, err := s.assemble()
+	if err == nil {
+		err = write(s, data)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	s.committing = false
+	s.lastActive = u.now()
+	if err == nil {
+		s.close()
+		delete(u.sessions, id)
+	}
+	return err
+}
+
+func (u *uploadStore) abort(id string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s, ok := u.sessions[id]
+	if !ok {
+		return fmt.Errorf("unknown upload session: %s", id)
+	}
+	s.close()
+	delete(u.sessions, id)
+	return nil
+}
+
+// closeAll drops every session, removing spool files.
+func (u *uploadStore) closeAll() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for id, s := range u.sessions {
+		s.close()
+		delete(u.sessions, id)
+	}
+	if u.sweep != nil {
+		u.sweep.Stop()
+		u.sweep = nil
+	}
+}
+
+// verifyChecksum compares data against a hex encoded checksum.
+func verifyChecksum(algo, want string, data []byte) error {
+	var h hash.Hash
+	switch strings.ToLower(algo) {
+	case ChecksumCRC32:
+		h = crc32.NewIEEE()
+	case ChecksumSHA256, "":
+		h = sha256.New()
+	default:
+		return fmt.Errorf("unknown checksum algorithm: %s", algo)
+	}
+	h.Write(data)
+
+	got := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(got, want) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", want, got)
+	}
+	return nil
+}
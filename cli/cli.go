@@ -0,0 +1,66 @@
+// Package cli implements the non-interactive subcommands of badger_explorer_core.
+package cli
+
+import (
+	"io"
+
+	"badger_explorer_core/codec"
+	"badger_explorer_core/config"
+)
+
+// Command runs a subcommand with its arguments and returns the process exit code.
+type Command func(args []string, stdout, stderr io.Writer) int
+
+// Exit codes shared by the subcommands.
+const (
+	ExitOK       = 0
+	ExitError    = 1 // The command failed, e.g. the DB could not be opened
+	ExitUsage    = 2 // Bad flags or arguments
+	ExitNotFound = 3 // A requested key does not exist
+)
+
+var commands = map[string]Command{
+	"diff":  RunDiff,
+	"ls":    RunLs,
+	"get":   RunGet,
+	"put":   RunPut,
+	"del":   RunDel,
+	"count": RunCount,
+	"stats": RunStats,
+	"shell": RunShell,
+}
+
+// cfg supplies the codec rules and default codec; nil until SetConfig.
+var cfg *config.Config
+
+// SetConfig attaches the config whose codec settings the subcommands decode
+// values with, as the TUI and the API do.
+func SetConfig(c *config.Config) {
+	cfg = c
+}
+
+// codecFor returns the codec rule matching key, or "" when none matches.
+func codecFor(key string) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.CodecFor(key)
+}
+
+// keyCodec returns the codec a key is decoded with: its codec rule, then
+// the default codec, then auto-detection.
+func keyCodec(key string) string {
+	if name := codecFor(key); name != "" {
+		return name
+	}
+	if cfg != nil && cfg.Codec.DefaultCodec != "" {
+		return cfg.Codec.DefaultCodec
+	}
+	return codec.Auto
+}
+
+// Lookup returns the subcommand registered under name.
+func Lookup(name string) (Command, bool) {
+	cmd, ok := commands[name]
+	return cmd, ok
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"badger_explorer_core/db"
+)
+
+// Sync directions accepted by -apply.
+const (
+	ApplyAToB = "a_to_b"
+	ApplyBToA = "b_to_a"
+)
+
+// RunDiff compares two stores and prints every differing key as a JSON line.
+// With -apply the differences are synced in the given direction afterwards.
+// Both stores are opened read-only, except the destination of -apply.
+func RunDiff(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	pathA := fs.String("a", "", "Path of the first DB")
+	pathB := fs.String("b", "", "Path of the second DB")
+	prefix := fs.String("prefix", "", "Search term (prefix, substring or regex)")
+	mode := fs.String("mode", "prefix", "Search mode: prefix | substring | regex")
+	limit := fs.Int("limit", 0, "Maximum number of differences to report (0 = no limit)")
+	apply := fs.String("apply", "", "Sync differences afterwards: a_to_b | b_to_a")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *pathA == "" || *pathB == "" {
+		fmt.Fprintln(stderr, "diff: both -a and -b are required")
+		return 2
+	}
+	if *apply != "" && *apply != ApplyAToB && *apply != ApplyBToA {
+		fmt.Fprintf(stderr, "diff: unknown -apply direction %q\n", *apply)
+		return 2
+	}
+
+	a, err := openStore(*pathA, *apply == ApplyBToA)
+	if err != nil {
+		fmt.Fprintf(stderr, "diff: %v\n", err)
+		return 1
+	}
+	defer a.Close()
+
+	b, err := openStore(*pathB, *apply == ApplyAToB)
+	if err != nil {
+		fmt.Fprintf(stderr, "diff: %v\n", err)
+		return 1
+	}
+	defer b.Close()
+
+	opts := db.ListKeysOptions{Prefix: *prefix, Mode: *mode, Limit: *limit}
+	enc := json.NewEncoder(stdout)
+	var keys []string
+	err = db.Diff(a, b, opts, func(e db.DiffEntry) error {
+		keys = append(keys, e.Key)
+		return enc.Encode(e)
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "diff: %v\n", err)
+		return 1
+	}
+
+	if *apply != "" {
+		src, dst := a, b
+		if *apply == ApplyBToA {
+			src, dst = b, a
+		}
+		n, err := db.SyncKeys(src, dst, keys)
+		if err != nil {
+			fmt.Fprintf(stderr, "diff: apply failed: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "synced %d keys (%s)\n", n, *apply)
+	}
+
+	return 0
+}
+
+// openStore opens path read-write if it is written to, read-only otherwise.
+func openStore(path string, write bool) (*db.DBClient, error) {
+	client := db.NewDBClient()
+	var err error
+	if write {
+		err = client.Open(path)
+	} else {
+		err = client.OpenReadOnly(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"badger_explorer_core/db"
+)
+
+func TestDiffReadOnly(t *testing.T) {
+	pathA, pathB := testDB(t), testDB(t)
+	if code, _, stderr := run(RunPut, "--db", pathA, "user:3", "carol"); code != ExitOK {
+		t.Fatalf("put: exit %d: %s", code, stderr)
+	}
+
+	// Another reader holds A, which only a read-only open can share
+	reader := db.NewDBClient()
+	if err := reader.OpenReadOnly(pathA); err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	code, stdout, stderr := run(RunDiff, "-a", pathA, "-b", pathB)
+	if code != ExitOK || strings.Count(stdout, "\n") != 1 || !strings.Contains(stdout, `"key":"user:3"`) {
+		t.Fatalf("Expected one difference, got exit %d: %s%s", code, stdout, stderr)
+	}
+	// Only the destination of -apply is written
+	if code, _, stderr := run(RunDiff, "-a", pathA, "-b", pathB, "-apply", ApplyAToB); code != ExitOK {
+		t.Fatalf("diff -apply a_to_b: exit %d: %s", code, stderr)
+	}
+	if code, _, _ := run(RunDiff, "-a", pathA, "-b", pathB, "-apply", ApplyBToA); code != ExitError {
+		t.Errorf("Expected -apply b_to_a to fail while A is held, got exit %d", code)
+	}
+	if _, stdout, _ := run(RunGet, "--db", pathB, "user:3"); stdout != "carol" {
+		t.Errorf("Expected user:3 to be synced to B, got %q", stdout)
+	}
+}
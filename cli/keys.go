@@ -0,0 +1,475 @@
+package cli
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"badger_explorer_core/codec"
+	"badger_explorer_core/db"
+)
+
+// Output formats accepted by --output.
+const (
+	OutputJSON  = "json"  // One JSON object per line
+	OutputTable = "table" // Aligned columns with a header
+	OutputRaw   = "raw"   // Bare values for piping into other tools
+)
+
+// commonFlags are accepted by every key subcommand.
+type commonFlags struct {
+	name     string
+	path     *string
+	readOnly *bool
+	output   *string
+}
+
+func newFlagSet(name, defaultOutput string, stderr io.Writer) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs, &commonFlags{
+		name:     name,
+		path:     fs.String("db", "", "Path of the DB (required)"),
+		readOnly: fs.Bool("readonly", false, "Open the DB read-only (not supported on Windows)"),
+		output:   fs.String("output", defaultOutput, "Output format: json | table | raw"),
+	}
+}
+
+// parse parses flags placed before, between or after the positional
+// arguments and returns the positional arguments. Everything after "--"
+// is positional, so keys and values may start with "-".
+func (c *commonFlags) parse(fs *flag.FlagSet, args []string, stderr io.Writer) ([]string, bool) {
+	var positional []string
+	for len(args) > 0 {
+		if err := fs.Parse(args); err != nil {
+			return nil, false
+		}
+		rest := fs.Args()
+		// Parse consumes the "--" it stops at
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	switch {
+	case *c.path == "":
+		fmt.Fprintf(stderr, "%s: --db is required\n", c.name)
+		return nil, false
+	case *c.output != OutputJSON && *c.output != OutputTable && *c.output != OutputRaw:
+		fmt.Fprintf(stderr, "%s: unknown output format %q\n", c.name, *c.output)
+		return nil, false
+	}
+	return positional, true
+}
+
+func (c *commonFlags) open(stderr io.Writer) (*db.DBClient, bool) {
+	client := db.NewDBClient()
+	var err error
+	if *c.readOnly {
+		err = client.OpenReadOnly(*c.path)
+	} else {
+		err = client.Open(*c.path)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", c.name, err)
+		return nil, false
+	}
+	return client, true
+}
+
+// searchModes are the values of --mode.
+var searchModes = []string{"prefix", "substring", "regex"}
+
+// oneOf reports whether the value of a flag is one of choices, and names
+// the flag otherwise.
+func (c *commonFlags) oneOf(stderr io.Writer, flag, value string, choices ...string) bool {
+	if slices.Contains(choices, value) {
+		return true
+	}
+	fmt.Fprintf(stderr, "%s: unknown --%s %q, want %s\n", c.name, flag, value, strings.Join(choices, " | "))
+	return false
+}
+
+// fail reports err and returns the matching exit code.
+func (c *commonFlags) fail(stderr io.Writer, err error) int {
+	fmt.Fprintf(stderr, "%s: %v\n", c.name, err)
+	if errors.Is(err, db.ErrKeyNotFound) {
+		return ExitNotFound
+	}
+	return ExitError
+}
+
+// RunLs lists keys: ls [PREFIX] --mode prefix|substring|regex --limit N --sort asc|desc.
+func RunLs(args []string, stdout, stderr io.Writer) int {
+	fs, common := newFlagSet("ls", OutputTable, stderr)
+	mode := fs.String("mode", "prefix", "Search mode: prefix | substring | regex")
+	limit := fs.Int("limit", 0, "Maximum number of keys (0 = no limit)")
+	offset := fs.Int("offset", 0, "Number of matching keys to skip")
+	sortOrder := fs.String("sort", "asc", "Key order: asc | desc")
+	args, ok := common.parse(fs, args, stderr)
+	if !ok || len(args) > 1 ||
+		!common.oneOf(stderr, "mode", *mode, searchModes...) ||
+		!common.oneOf(stderr, "sort", *sortOrder, "asc", "desc") {
+		return ExitUsage
+	}
+
+	client, ok := common.open(stderr)
+	if !ok {
+		return ExitError
+	}
+	defer client.Close()
+
+	opts := db.ListKeysOptions{
+		Mode:         *mode,
+		SortDesc:     *sortOrder == "desc",
+		Limit:        *limit,
+		Offset:       *offset,
+		PreviewCodec: codecFor,
+	}
+	if len(args) == 1 {
+		opts.Prefix = args[0]
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = math.MaxInt
+	}
+
+	keys, _, err := client.ListKeys(opts)
+	if err != nil {
+		return common.fail(stderr, err)
+	}
+
+	switch *common.output {
+	case OutputJSON:
+		enc := json.NewEncoder(stdout)
+		for _, k := range keys {
+			enc.Encode(keyRow{Key: k.Key, Size: k.Size, ExpiresAt: k.ExpiresAt, Preview: k.ValuePreview, Codec: k.Codec})
+		}
+	case OutputTable:
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tSIZE\tEXPIRES\tPREVIEW")
+		for _, k := range keys {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", k.Key, k.Size, formatExpiry(k.ExpiresAt), k.ValuePreview)
+		}
+		tw.Flush()
+	default:
+		for _, k := range keys {
+			fmt.Fprintln(stdout, k.Key)
+		}
+	}
+	return ExitOK
+}
+
+type keyRow struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size"`
+	ExpiresAt uint64 `json:"expires_at"`
+	Preview   string `json:"preview"`
+	Codec     string `json:"codec,omitempty"`
+}
+
+// Value encodings accepted by get --encoding.
+const (
+	EncodingRaw     = "raw"
+	EncodingHex     = "hex"
+	EncodingBase64  = "base64"
+	EncodingDecoded = "decoded" // Human-readable text from a codec
+)
+
+type getResult struct {
+	db.KeyInfo
+	Value    string `json:"value"`
+	Encoding string `json:"encoding"`
+	Codec    string `json:"codec,omitempty"`
+}
+
+// RunGet prints the value of a key. A missing key exits with ExitNotFound.
+func RunGet(args []string, stdout, stderr io.Writer) int {
+	fs, common := newFlagSet("get", OutputRaw, stderr)
+	encoding := fs.String("encoding", EncodingRaw, "Value encoding: raw | hex | base64 | decoded")
+	codecName := fs.String("codec", "", "Codec for --encoding decoded (default: codec rules, then default_codec)")
+	args, ok := common.parse(fs, args, stderr)
+	if !ok {
+		return ExitUsage
+	}
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "get: exactly one key is required")
+		return ExitUsage
+	}
+
+	client, ok := common.open(stderr)
+	if !ok {
+		return ExitError
+	}
+	defer client.Close()
+
+	key := args[0]
+	info, err := client.GetKeyInfo(key)
+	if err != nil {
+		return common.fail(stderr, err)
+	}
+	value, err := client.GetValue(key)
+	if err != nil {
+		return common.fail(stderr, err)
+	}
+
+	res := getResult{KeyInfo: info, Encoding: *encoding}
+	switch *encoding {
+	case EncodingRaw:
+		res.Value = string(value)
+	case EncodingHex:
+		res.Value = hex.EncodeToString(value)
+	case EncodingBase64:
+		res.Value = base64.StdEncoding.EncodeToString(value)
+	case EncodingDecoded:
+		name := *codecName
+		if name == "" {
+			name = keyCodec(key)
+		}
+		d, err := codec.Decode(name, value)
+		if err != nil {
+			return common.fail(stderr, err)
+		}
+		res.Value, res.Codec = d.Text, d.Codec
+	default:
+		fmt.Fprintf(stderr, "get: unknown encoding %q\n", *encoding)
+		return ExitUsage
+	}
+
+	switch *common.output {
+	case OutputJSON:
+		json.NewEncoder(stdout).Encode(res)
+	case OutputTable:
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tSIZE\tEXPIRES\tUSER META\tVERSION")
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%d\n", info.Key, info.Size, formatExpiry(info.ExpiresAt), info.UserMeta, info.Version)
+		tw.Flush()
+		fmt.Fprintln(stdout)
+		fmt.Fprintln(stdout, res.Value)
+	default:
+		io.WriteString(stdout, res.Value)
+		if *encoding == EncodingHex || *encoding == EncodingBase64 {
+			fmt.Fprintln(stdout)
+		}
+	}
+	return ExitOK
+}
+
+// RunPut writes a value: put KEY [VALUE], or the value from --file or stdin.
+func RunPut(args []string, stdout, stderr io.Writer) int {
+	fs, common := newFlagSet("put", OutputRaw, stderr)
+	file := fs.String("file", "", "Read the value from this file (- for stdin)")
+	ttl := fs.Int("ttl", 0, "TTL in seconds (0 = no expiry)")
+	userMeta := fs.Int("meta", -1, "User meta byte (0-255)")
+	args, ok := common.parse(fs, args, stderr)
+	if !ok {
+		return ExitUsage
+	}
+	if len(args) < 1 || len(args) > 2 || (len(args) == 2 && *file != "") {
+		fmt.Fprintln(stderr, "put: usage: put KEY [VALUE] or put KEY --file PATH")
+		return ExitUsage
+	}
+	if *userMeta > 255 || *ttl < 0 {
+		fmt.Fprintln(stderr, "put: --meta must be 0-255 and --ttl >= 0")
+		return ExitUsage
+	}
+
+	var value []byte
+	var err error
+	switch {
+	case len(args) == 2:
+		value = []byte(args[1])
+	case *file != "" && *file != "-":
+		value, err = os.ReadFile(*file)
+	default:
+		value, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return common.fail(stderr, err)
+	}
+
+	client, ok := common.open(stderr)
+	if !ok {
+		return ExitError
+	}
+	defer client.Close()
+
+	key := args[0]
+	if *userMeta >= 0 {
+		err = client.SetValueWithMeta(key, value, *ttl, byte(*userMeta))
+	} else {
+		err = client.SetValue(key, value, *ttl)
+	}
+	if err != nil {
+		return common.fail(stderr, err)
+	}
+
+	switch *common.output {
+	case OutputJSON:
+		json.NewEncoder(stdout).Encode(struct {
+			Key  string `json:"key"`
+			Size int    `json:"size"`
+		}{key, len(value)})
+	case OutputTable:
+		fmt.Fprintf(stdout, "stored %s (%d bytes)\n", key, len(value))
+	}
+	return ExitOK
+}
+
+// RunDel deletes keys: del KEY... Keys that do not exist are reported and
+// make the command exit with ExitNotFound after the others were deleted.
+func RunDel(args []string, stdout, stderr io.Writer) int {
+	fs, common := newFlagSet("del", OutputRaw, stderr)
+	args, ok := common.parse(fs, args, stderr)
+	if !ok {
+		return ExitUsage
+	}
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "del: at least one key is required")
+		return ExitUsage
+	}
+
+	client, ok := common.open(stderr)
+	if !ok {
+		return ExitError
+	}
+	defer client.Close()
+
+	code := ExitOK
+	enc := json.NewEncoder(stdout)
+	for _, key := range args {
+		_, err := client.GetKeyInfo(key)
+		if err == nil {
+			err = client.DeleteKey(key)
+		}
+		deleted := err == nil
+		if err != nil {
+			if c := common.fail(stderr, err); c == ExitError || code == ExitOK {
+				code = c // Errors win over not-found
+			}
+		}
+
+		switch *common.output {
+		case OutputJSON:
+			enc.Encode(struct {
+				Key     string `json:"key"`
+				Deleted bool   `json:"deleted"`
+			}{key, deleted})
+		case OutputTable:
+			if deleted {
+				fmt.Fprintf(stdout, "deleted %s\n", key)
+			}
+		}
+	}
+	return code
+}
+
+// RunCount prints the number of keys matching: count [PREFIX] --mode ...
+func RunCount(args []string, stdout, stderr io.Writer) int {
+	fs, common := newFlagSet("count", OutputRaw, stderr)
+	mode := fs.String("mode", "prefix", "Search mode: prefix | substring | regex")
+	args, ok := common.parse(fs, args, stderr)
+	if !ok || len(args) > 1 || !common.oneOf(stderr, "mode", *mode, searchModes...) {
+		return ExitUsage
+	}
+
+	client, ok := common.open(stderr)
+	if !ok {
+		return ExitError
+	}
+	defer client.Close()
+
+	opts := db.ListKeysOptions{Mode: *mode}
+	if len(args) == 1 {
+		opts.Prefix = args[0]
+	}
+	n, err := client.CountKeys(context.Background(), opts)
+	if err != nil {
+		return common.fail(stderr, err)
+	}
+
+	switch *common.output {
+	case OutputJSON:
+		json.NewEncoder(stdout).Encode(struct {
+			Count int64 `json:"count"`
+			Bytes int64 `json:"bytes"`
+		}{n.Keys, n.Bytes})
+	case OutputTable:
+		fmt.Fprintf(stdout, "COUNT\tBYTES\n%d\t%d\n", n.Keys, n.Bytes)
+	default:
+		fmt.Fprintln(stdout, n.Keys)
+	}
+	return ExitOK
+}
+
+// RunStats prints key counts and on-disk sizes of the DB.
+func RunStats(args []string, stdout, stderr io.Writer) int {
+	fs, common := newFlagSet("stats", OutputTable, stderr)
+	args, ok := common.parse(fs, args, stderr)
+	if !ok || len(args) > 0 {
+		return ExitUsage
+	}
+
+	client, ok := common.open(stderr)
+	if !ok {
+		return ExitError
+	}
+	defer client.Close()
+
+	st, err := client.Stats(context.Background())
+	if err != nil {
+		return common.fail(stderr, err)
+	}
+
+	if *common.output == OutputJSON {
+		json.NewEncoder(stdout).Encode(st)
+		return ExitOK
+	}
+	fields := []struct{ name, value string }{
+		{"path", st.Path},
+		{"readonly", strconv.FormatBool(st.ReadOnly)},
+		{"keys", strconv.FormatInt(st.Keys, 10)},
+		{"value_bytes", strconv.FormatInt(st.ValueBytes, 10)},
+		{"lsm_size", strconv.FormatInt(st.LSMSize, 10)},
+		{"vlog_size", strconv.FormatInt(st.VLogSize, 10)},
+		{"tables", strconv.Itoa(st.Tables)},
+		{"max_version", strconv.FormatUint(st.MaxVersion, 10)},
+	}
+	if *common.output == OutputRaw {
+		for _, f := range fields {
+			fmt.Fprintf(stdout, "%s=%s\n", f.name, f.value)
+		}
+		return ExitOK
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, f := range fields {
+		fmt.Fprintf(tw, "%s\t%s\n", f.name, f.value)
+	}
+	tw.Flush()
+	return ExitOK
+}
+
+// formatExpiry renders a Badger expiry timestamp, or "-" for keys without TTL.
+func formatExpiry(expiresAt uint64) string {
+	if expiresAt == 0 {
+		return "-"
+	}
+	return time.Unix(int64(expiresAt), 0).Format(time.RFC3339)
+}
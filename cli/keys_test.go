@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"badger_explorer_core/config"
+)
+
+// testDB returns the path of a DB holding user:1=alice and user:2=bob.
+func testDB(t *testing.T) string {
+	tmpDir, err := os.MkdirTemp("", "badger-cli-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	for _, kv := range [][2]string{{"user:1", "alice"}, {"user:2", "bob"}} {
+		if code, _, stderr := run(RunPut, "--db", tmpDir, kv[0], kv[1]); code != ExitOK {
+			t.Fatalf("put %s: exit %d: %s", kv[0], code, stderr)
+		}
+	}
+	return tmpDir
+}
+
+func run(cmd Command, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := cmd(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestExitCodes(t *testing.T) {
+	path := testDB(t)
+	notDB := filepath.Join(path, "not-a-db")
+	if err := os.WriteFile(notDB, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		cmd  Command
+		args []string
+		want int
+	}{
+		{"get existing", RunGet, []string{"--db", path, "user:1"}, ExitOK},
+		{"get missing", RunGet, []string{"--db", path, "user:9"}, ExitNotFound},
+		{"get without key", RunGet, []string{"--db", path}, ExitUsage},
+		{"get without db", RunGet, []string{"user:1"}, ExitUsage},
+		{"unknown flag", RunGet, []string{"--db", path, "--nope", "user:1"}, ExitUsage},
+		{"unknown output", RunGet, []string{"--db", path, "--output", "xml", "user:1"}, ExitUsage},
+		{"unknown encoding", RunGet, []string{"--db", path, "--encoding", "rot13", "user:1"}, ExitUsage},
+		{"unopenable db", RunGet, []string{"--db", notDB, "user:1"}, ExitError},
+		{"put meta out of range", RunPut, []string{"--db", path, "--meta", "300", "k", "v"}, ExitUsage},
+		{"put too many args", RunPut, []string{"--db", path, "k", "v", "w"}, ExitUsage},
+		{"del partly missing", RunDel, []string{"--db", path, "user:9", "user:2"}, ExitNotFound},
+		{"del without keys", RunDel, []string{"--db", path}, ExitUsage},
+		{"ls extra args", RunLs, []string{"--db", path, "a", "b"}, ExitUsage},
+		{"ls bad regex", RunLs, []string{"--db", path, "--mode", "regex", "("}, ExitError},
+		{"ls unknown mode", RunLs, []string{"--db", path, "--mode", "substr", "user"}, ExitUsage},
+		{"ls unknown sort", RunLs, []string{"--db", path, "--sort", "DESC"}, ExitUsage},
+		{"count unknown mode", RunCount, []string{"--db", path, "--mode", "glob"}, ExitUsage},
+		{"count substring", RunCount, []string{"--db", path, "--mode", "substring", "1"}, ExitOK},
+		{"stats", RunStats, []string{"--db", path}, ExitOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _, stderr := run(tt.cmd, tt.args...); code != tt.want {
+				t.Errorf("Expected exit %d, got %d: %s", tt.want, code, stderr)
+			}
+		})
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	path := testDB(t)
+
+	tests := []struct {
+		name string
+		cmd  Command
+		args []string
+		want string
+	}{
+		{"ls json", RunLs, []string{"--db", path, "--output", "json"},
+			`{"key":"user:1","size":5,"expires_at":0,"preview":"alice"}` + "\n" +
+				`{"key":"user:2","size":3,"expires_at":0,"preview":"bob"}` + "\n"},
+		{"ls table", RunLs, []string{"--db", path, "--output", "table"},
+			"KEY     SIZE  EXPIRES  PREVIEW\n" +
+				"user:1  5     -        alice\n" +
+				"user:2  3     -        bob\n"},
+		{"ls raw", RunLs, []string{"--db", path, "--output", "raw", "--sort", "desc"}, "user:2\nuser:1\n"},
+		{"get raw", RunGet, []string{"--db", path, "user:1"}, "alice"},
+		{"get hex", RunGet, []string{"--db", path, "--encoding", "hex", "user:2"}, "626f62\n"},
+		{"count json", RunCount, []string{"--db", path, "--output", "json", "user:"}, `{"count":2,"bytes":8}` + "\n"},
+		{"count table", RunCount, []string{"--db", path, "--output", "table"}, "COUNT\tBYTES\n2\t8\n"},
+		{"count raw", RunCount, []string{"--db", path, "user:2"}, "1\n"},
+		{"put json", RunPut, []string{"--db", path, "--output", "json", "k", "abc"}, `{"key":"k","size":3}` + "\n"},
+		{"put table", RunPut, []string{"--db", path, "--output", "table", "k", "abc"}, "stored k (3 bytes)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(tt.cmd, tt.args...)
+			if code != ExitOK {
+				t.Fatalf("Expected exit 0, got %d: %s", code, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, stdout)
+			}
+		})
+	}
+
+	// del json reports each key, deleted or not
+	code, stdout, _ := run(RunDel, "--db", path, "--output", "json", "k", "k")
+	if want := `{"key":"k","deleted":true}` + "\n" + `{"key":"k","deleted":false}` + "\n"; code != ExitNotFound || stdout != want {
+		t.Errorf("Expected %q with exit 3, got %q with exit %d", want, stdout, code)
+	}
+
+	// get json carries the key info along with the value
+	code, stdout, _ = run(RunGet, "--db", path, "--output", "json", "--encoding", "base64", "user:2")
+	if code != ExitOK || !bytes.Contains([]byte(stdout), []byte(`"key":"user:2"`)) || !bytes.Contains([]byte(stdout), []byte(`"value":"Ym9i","encoding":"base64"`)) {
+		t.Errorf("Unexpected get json output: %s", stdout)
+	}
+}
+
+func TestDoubleDash(t *testing.T) {
+	path := testDB(t)
+
+	// Flags are still parsed between positional arguments
+	if code, _, stderr := run(RunPut, "k", "--db", path, "v", "--ttl", "0"); code != ExitOK {
+		t.Fatalf("put with flags between arguments: exit %d: %s", code, stderr)
+	}
+	// After "--" keys and values may start with "-"
+	if code, _, stderr := run(RunPut, "--db", path, "--", "-k", "-5"); code != ExitOK {
+		t.Fatalf("put after --: exit %d: %s", code, stderr)
+	}
+	if code, stdout, stderr := run(RunGet, "--db", path, "--", "-k"); code != ExitOK || stdout != "-5" {
+		t.Errorf("Expected -5, got %q (exit %d: %s)", stdout, code, stderr)
+	}
+	if code, _, _ := run(RunPut, "--db", path, "k", "-5"); code != ExitUsage {
+		t.Errorf("Expected -5 without -- to be a usage error, got %d", code)
+	}
+	// "--" ends flag parsing even after a positional argument
+	if code, _, stderr := run(RunPut, "--db", path, "k2", "--", "--ttl"); code != ExitOK {
+		t.Fatalf("put with -- after the key: exit %d: %s", code, stderr)
+	}
+	if _, stdout, _ := run(RunGet, "--db", path, "k2"); stdout != "--ttl" {
+		t.Errorf("Expected --ttl, got %q", stdout)
+	}
+}
+
+func TestCodecConfig(t *testing.T) {
+	path := testDB(t)
+	if code, _, stderr := run(RunPut, "--db", path, "doc:1", `{"a":1}`); code != ExitOK {
+		t.Fatalf("put: exit %d: %s", code, stderr)
+	}
+
+	c := config.DefaultConfig()
+	c.SetCodecRules([]config.CodecRule{{Pattern: "user:*", Codec: "hex"}})
+	c.Codec.DefaultCodec = "json"
+	SetConfig(c)
+	t.Cleanup(func() { SetConfig(nil) })
+
+	// Codec rules pick the codec of decoded values and of ls previews
+	if _, stdout, _ := run(RunGet, "--db", path, "--encoding", "decoded", "--output", "json", "user:1"); !strings.Contains(stdout, `"codec":"hex"`) {
+		t.Errorf("Expected the hex rule to apply, got %s", stdout)
+	}
+	if _, stdout, _ := run(RunLs, "--db", path, "--output", "json", "user:1"); !strings.Contains(stdout, `"codec":"hex"`) {
+		t.Errorf("Expected the hex rule in the preview, got %s", stdout)
+	}
+	// Keys without a rule use the default codec, and --codec wins over both
+	if _, stdout, _ := run(RunGet, "--db", path, "--encoding", "decoded", "--output", "json", "doc:1"); !strings.Contains(stdout, `"codec":"json"`) {
+		t.Errorf("Expected the default codec, got %s", stdout)
+	}
+	if _, stdout, _ := run(RunGet, "--db", path, "--encoding", "decoded", "--codec", "text", "user:1"); stdout != "alice" {
+		t.Errorf("Expected --codec text to print alice, got %q", stdout)
+	}
+}
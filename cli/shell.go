@@ -0,0 +1,416 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"badger_explorer_core/codec"
+	"badger_explorer_core/db"
+)
+
+// shellListLimit is the number of keys ls prints before it stops.
+const shellListLimit = 1000
+
+// shellSeparators end a key segment for cd and completion, e.g. "user:" or "logs/".
+const shellSeparators = ":/"
+
+// errQuit is returned by exec for the exit command.
+var errQuit = errors.New("quit")
+
+// shellCommands lists the commands with their usage, in help order.
+var shellCommands = []struct{ name, args, desc string }{
+	{"ls", "[PREFIX]", "List keys under the current prefix"},
+	{"get", "KEY [CODEC]", "Print a value, decoded with CODEC if given"},
+	{"set", "KEY VALUE [TTL]", "Write a value, TTL in seconds"},
+	{"del", "KEY...", "Delete keys"},
+	{"ttl", "KEY [SECONDS]", "Show the TTL, or set it (0 removes it)"},
+	{"count", "[PREFIX]", "Count keys under the current prefix"},
+	{"cd", "PREFIX | .. | /", "Scope the commands to a prefix"},
+	{"pwd", "", "Print the current prefix"},
+	{"help", "", "Show this help"},
+	{"exit", "", "Leave the shell"},
+}
+
+// shell runs the commands of the REPL against one DB. Keys given to commands
+// are relative to the current prefix; a leading "/" makes them absolute.
+type shell struct {
+	client *db.DBClient
+	cwd    string // Current prefix
+}
+
+// RunShell starts the REPL: shell --db PATH [--script FILE] [--history FILE].
+// Without a terminal on stdin the commands are read from it like a script.
+func RunShell(args []string, stdout, stderr io.Writer) int {
+	fs, common := newFlagSet("shell", OutputTable, stderr)
+	script := fs.String("script", "", "Run the commands of this file and exit (- for stdin)")
+	history := fs.String("history", defaultHistoryPath(), "History file of the interactive shell")
+	args, ok := common.parse(fs, args, stderr)
+	if !ok || len(args) > 0 {
+		return ExitUsage
+	}
+
+	client, ok := common.open(stderr)
+	if !ok {
+		return ExitError
+	}
+	defer client.Close()
+
+	sh := &shell{client: client}
+	switch {
+	case *script == "-" || (*script == "" && !isTerminal(os.Stdin)):
+		return sh.runScript(os.Stdin, "stdin", stdout, stderr)
+	case *script != "":
+		f, err := os.Open(*script)
+		if err != nil {
+			fmt.Fprintf(stderr, "shell: %v\n", err)
+			return ExitError
+		}
+		defer f.Close()
+		return sh.runScript(f, *script, stdout, stderr)
+	}
+
+	if err := runInteractive(sh, *history); err != nil {
+		fmt.Fprintf(stderr, "shell: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// runScript executes one command per line. Empty lines and lines starting
+// with "#" are skipped. It stops at the first failing command.
+func (sh *shell) runScript(r io.Reader, name string, stdout, stderr io.Writer) int {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		err := sh.exec(text, stdout)
+		if errors.Is(err, errQuit) {
+			return ExitOK
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "%s:%d: %v\n", name, line, err)
+			if errors.Is(err, db.ErrKeyNotFound) {
+				return ExitNotFound
+			}
+			return ExitError
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stderr, "shell: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// exec runs one command line, writing its output to w.
+func (sh *shell) exec(line string, w io.Writer) error {
+	args, err := splitArgs(line)
+	if err != nil || len(args) == 0 {
+		return err
+	}
+	cmd, args := args[0], args[1:]
+
+	switch cmd {
+	case "ls":
+		return sh.ls(args, w)
+	case "get":
+		if len(args) < 1 || len(args) > 2 {
+			return usageErr(cmd)
+		}
+		val, err := sh.client.GetValue(sh.resolve(args[0]))
+		if err != nil {
+			return err
+		}
+		if len(args) == 2 {
+			d, err := codec.Decode(args[1], val)
+			if err != nil {
+				return err
+			}
+			val = []byte(d.Text)
+		}
+		fmt.Fprintln(w, string(val))
+	case "set":
+		if len(args) < 2 || len(args) > 3 {
+			return usageErr(cmd)
+		}
+		ttl := 0
+		if len(args) == 3 {
+			if ttl, err = strconv.Atoi(args[2]); err != nil {
+				return fmt.Errorf("invalid TTL: %s", args[2])
+			}
+		}
+		return sh.client.SetValue(sh.resolve(args[0]), []byte(args[1]), ttl)
+	case "del":
+		if len(args) == 0 {
+			return usageErr(cmd)
+		}
+		for _, arg := range args {
+			key := sh.resolve(arg)
+			if _, err := sh.client.GetKeyInfo(key); err != nil {
+				return err
+			}
+			if err := sh.client.DeleteKey(key); err != nil {
+				return err
+			}
+		}
+	case "ttl":
+		return sh.ttl(args, w)
+	case "count":
+		if len(args) > 1 {
+			return usageErr(cmd)
+		}
+		prefix := sh.cwd
+		if len(args) == 1 {
+			prefix = sh.resolve(args[0])
+		}
+		n, err := sh.client.CountKeys(context.Background(), db.ListKeysOptions{Prefix: prefix, Mode: "prefix"})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, n.Keys)
+	case "cd":
+		if len(args) != 1 {
+			return usageErr(cmd)
+		}
+		sh.cd(args[0])
+	case "pwd":
+		fmt.Fprintf(w, "/%s\n", sh.cwd)
+	case "help":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, c := range shellCommands {
+			fmt.Fprintf(tw, "%s %s\t%s\n", c.name, c.args, c.desc)
+		}
+		tw.Flush()
+	case "exit", "quit":
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command: %s (try help)", cmd)
+	}
+	return nil
+}
+
+func (sh *shell) ls(args []string, w io.Writer) error {
+	if len(args) > 1 {
+		return usageErr("ls")
+	}
+	prefix := sh.cwd
+	if len(args) == 1 {
+		prefix = sh.resolve(args[0])
+	}
+
+	keys, hasMore, err := sh.client.ListKeys(db.ListKeysOptions{Prefix: prefix, Mode: "prefix", Limit: shellListLimit})
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, k := range keys {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", strings.TrimPrefix(k.Key, sh.cwd), k.Size, formatExpiry(k.ExpiresAt))
+	}
+	tw.Flush()
+	if hasMore {
+		fmt.Fprintf(w, "... more than %d keys, narrow the prefix\n", shellListLimit)
+	}
+	return nil
+}
+
+func (sh *shell) ttl(args []string, w io.Writer) error {
+	if len(args) < 1 || len(args) > 2 {
+		return usageErr("ttl")
+	}
+	key := sh.resolve(args[0])
+	if len(args) == 2 {
+		seconds, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid TTL: %s", args[1])
+		}
+		return sh.client.SetTTL(key, seconds)
+	}
+
+	info, err := sh.client.GetKeyInfo(key)
+	if err != nil {
+		return err
+	}
+	if info.ExpiresAt == 0 {
+		fmt.Fprintln(w, "no expiry")
+		return nil
+	}
+	left := time.Until(time.Unix(int64(info.ExpiresAt), 0)).Round(time.Second)
+	fmt.Fprintf(w, "%s (expires %s)\n", left, formatExpiry(info.ExpiresAt))
+	return nil
+}
+
+// cd changes the current prefix. ".." drops the last segment and "/" goes to the root.
+func (sh *shell) cd(arg string) {
+	switch arg {
+	case "/":
+		sh.cwd = ""
+	case "..":
+		sh.cwd = parentPrefix(sh.cwd)
+	default:
+		sh.cwd = sh.resolve(arg)
+	}
+}
+
+// resolve turns a command argument into a full key.
+func (sh *shell) resolve(arg string) string {
+	if abs, ok := strings.CutPrefix(arg, "/"); ok {
+		return abs
+	}
+	return sh.cwd + arg
+}
+
+// parentPrefix drops the last segment of a prefix: "user:1:" becomes "user:".
+func parentPrefix(prefix string) string {
+	trimmed := prefix
+	if trimmed != "" && strings.ContainsRune(shellSeparators, rune(trimmed[len(trimmed)-1])) {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+	return trimmed[:strings.LastIndexAny(trimmed, shellSeparators)+1]
+}
+
+// complete returns the candidates for the last word of line: command names
+// for the first word, keys for the others. start is the offset of the word
+// in line. The candidates are unquoted; quoteArg them before inserting.
+func (sh *shell) complete(line string) (start int, candidates []string) {
+	args, start, _ := scanArgs(line)
+	var word string
+	if start < len(line) {
+		word, args = args[len(args)-1], args[:len(args)-1]
+	}
+	if len(args) == 0 {
+		for _, c := range shellCommands {
+			if strings.HasPrefix(c.name, word) {
+				candidates = append(candidates, c.name)
+			}
+		}
+		return start, candidates
+	}
+
+	// Complete one segment at a time, like directories
+	prefix := sh.resolve(word)
+	segments, _, err := sh.client.KeySegments(prefix, shellSeparators, shellListLimit)
+	if err != nil {
+		return start, nil
+	}
+	for _, segment := range segments {
+		candidates = append(candidates, word+segment[len(prefix):])
+	}
+	return start, candidates
+}
+
+// commonPrefix returns the longest prefix shared by all of words.
+func commonPrefix(words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// splitArgs splits a command line on spaces. Single or double quotes group
+// words, and a backslash escapes the next character outside single quotes.
+func splitArgs(line string) ([]string, error) {
+	args, _, open := scanArgs(line)
+	if open {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	return args, nil
+}
+
+// scanArgs splits line like splitArgs, keeping a last word that is still
+// open. It returns the offset of the last word, or len(line) when line ends
+// between words, and whether a quote or escape was left open.
+func scanArgs(line string) (args []string, last int, open bool) {
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	last = len(line)
+
+	for i, r := range line {
+		if !inWord && quote == 0 && !escaped && r != ' ' && r != '\t' {
+			last = i
+		}
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+			last = len(line)
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, cur.String())
+	}
+	return args, last, quote != 0 || escaped
+}
+
+// quoteArg escapes the characters splitArgs would otherwise split or
+// unquote on, so that s reads back as a single word.
+func quoteArg(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(" \t\"'\\", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func usageErr(cmd string) error {
+	for _, c := range shellCommands {
+		if c.name == cmd {
+			return fmt.Errorf("usage: %s %s", c.name, c.args)
+		}
+	}
+	return fmt.Errorf("usage: %s", cmd)
+}
+
+func defaultHistoryPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".badger_explorer_history")
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyLimit is the number of commands kept in the history file.
+const historyLimit = 1000
+
+// shellModel is the line editor of the interactive shell. Command output is
+// printed above it, so the terminal scrollback keeps the whole session.
+type shellModel struct {
+	sh    *shell
+	input textinput.Model
+
+	history     []string
+	historyPos  int    // Index into history while browsing, len(history) otherwise
+	draft       string // Line being typed before browsing started
+	historyPath string
+}
+
+func runInteractive(sh *shell, historyPath string) error {
+	ti := textinput.New()
+	ti.Focus()
+	ti.Prompt = sh.prompt()
+
+	history := loadHistory(historyPath)
+	m := shellModel{
+		sh:          sh,
+		input:       ti,
+		history:     history,
+		historyPos:  len(history),
+		historyPath: historyPath,
+	}
+	_, err := tea.NewProgram(m).Run()
+	return err
+}
+
+func (sh *shell) prompt() string {
+	return fmt.Sprintf("/%s> ", sh.cwd)
+}
+
+func (m shellModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, tea.Println(`Type "help" for commands, Tab to complete keys.`))
+}
+
+func (m shellModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		m.input, cmd = m.input.Update(msg)
+		return m, cmd
+	}
+
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "ctrl+d":
+		if m.input.Value() == "" {
+			return m, tea.Quit
+		}
+	case "enter":
+		return m.run()
+	case "tab":
+		return m.complete()
+	case "up":
+		if m.historyPos > 0 {
+			if m.historyPos == len(m.history) {
+				m.draft = m.input.Value()
+			}
+			m.historyPos--
+			m.setLine(m.history[m.historyPos])
+		}
+		return m, nil
+	case "down":
+		if m.historyPos < len(m.history) {
+			m.historyPos++
+			if m.historyPos == len(m.history) {
+				m.setLine(m.draft)
+			} else {
+				m.setLine(m.history[m.historyPos])
+			}
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// run executes the current line and prints it with its output.
+func (m shellModel) run() (tea.Model, tea.Cmd) {
+	line := strings.TrimSpace(m.input.Value())
+	echo := m.input.Prompt + line
+	m.setLine("")
+	if line == "" {
+		return m, tea.Println(echo)
+	}
+
+	m.addHistory(line)
+
+	var out bytes.Buffer
+	err := m.sh.exec(line, &out)
+	if errors.Is(err, errQuit) {
+		return m, tea.Sequence(tea.Println(echo), tea.Quit)
+	}
+	if err != nil {
+		fmt.Fprintf(&out, "error: %v\n", err)
+	}
+	m.input.Prompt = m.sh.prompt()
+
+	text := echo
+	if out.Len() > 0 {
+		text += "\n" + strings.TrimRight(out.String(), "\n")
+	}
+	return m, tea.Println(text)
+}
+
+// complete extends the last word to the longest unambiguous completion and
+// lists the candidates when there is nothing left to add.
+func (m shellModel) complete() (tea.Model, tea.Cmd) {
+	line := m.input.Value()
+	start, candidates := m.sh.complete(line)
+	if len(candidates) == 0 {
+		return m, nil
+	}
+
+	word := line[start:]
+	shared := commonPrefix(candidates)
+	completion := quoteArg(shared)
+	if len(candidates) == 1 && !strings.ContainsAny(shared[len(shared)-1:], shellSeparators) {
+		completion += " " // A whole key or command
+	}
+	if completion != word {
+		m.setLine(line[:start] + completion)
+		return m, nil
+	}
+	return m, tea.Println(m.input.Prompt + line + "\n" + strings.Join(candidates, "  "))
+}
+
+func (m *shellModel) setLine(s string) {
+	m.input.SetValue(s)
+	m.input.CursorEnd()
+}
+
+func (m shellModel) View() string {
+	return m.input.View()
+}
+
+// addHistory records line in memory and appends it to the history file.
+func (m *shellModel) addHistory(line string) {
+	if n := len(m.history); n == 0 || m.history[n-1] != line {
+		m.history = append(m.history, line)
+		if m.historyPath != "" {
+			if f, err := os.OpenFile(m.historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600); err == nil {
+				fmt.Fprintln(f, line)
+				f.Close()
+			}
+		}
+	}
+	m.historyPos = len(m.history)
+	m.draft = ""
+}
+
+// loadHistory reads the history file, trimming it to historyLimit lines.
+func loadHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	f.Close()
+
+	if len(lines) > historyLimit {
+		lines = lines[len(lines)-historyLimit:]
+		os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600)
+	}
+	return lines
+}
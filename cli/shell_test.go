@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"badger_explorer_core/db"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"  ls  ", []string{"ls"}},
+		{"set k v 10", []string{"set", "k", "v", "10"}},
+		{`set "a b" 'c d'`, []string{"set", "a b", "c d"}},
+		{`set a\ b "x\"y" 'p\q'`, []string{"set", "a b", `x"y`, `p\q`}},
+		{`get ""`, []string{"get", ""}},
+		{"get\tk", []string{"get", "k"}},
+		{`get ab"c d"e`, []string{"get", "abc de"}},
+	}
+	for _, tt := range tests {
+		got, err := splitArgs(tt.line)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, %v; want %q", tt.line, got, err, tt.want)
+		}
+	}
+	for _, line := range []string{`get "a`, `get 'a`, `get a\`} {
+		if _, err := splitArgs(line); err == nil {
+			t.Errorf("Expected splitArgs(%q) to fail", line)
+		}
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	for _, s := range []string{"plain", "a b", `x"y`, "it's", `back\slash`, "tab\there", ""} {
+		got, err := splitArgs("get " + quoteArg(s) + "x")
+		if want := []string{"get", s + "x"}; err != nil || !slices.Equal(got, want) {
+			t.Errorf("quoteArg(%q) read back as %q, %v", s, got, err)
+		}
+	}
+}
+
+func TestResolveAndCd(t *testing.T) {
+	sh := &shell{}
+	steps := []struct {
+		cd, cwd string
+	}{
+		{"user:", "user:"},
+		{"1:", "user:1:"},
+		{"..", "user:"},
+		{"/logs/2024/", "logs/2024/"},
+		{"..", "logs/"},
+		{"..", ""},
+		{"..", ""},
+		{"a:b", "a:b"},
+		{"/", ""},
+	}
+	for _, s := range steps {
+		sh.cd(s.cd)
+		if sh.cwd != s.cwd {
+			t.Errorf("cd %s: expected prefix %q, got %q", s.cd, s.cwd, sh.cwd)
+		}
+	}
+
+	sh.cwd = "user:"
+	for arg, want := range map[string]string{"1": "user:1", "/1": "1", "": "user:", "/": ""} {
+		if got := sh.resolve(arg); got != want {
+			t.Errorf("resolve(%q) = %q, want %q", arg, got, want)
+		}
+	}
+}
+
+func TestParentPrefix(t *testing.T) {
+	for prefix, want := range map[string]string{
+		"":           "",
+		"user:":      "",
+		"user:1:":    "user:",
+		"user:1":     "user:",
+		"logs/2024/": "logs/",
+		"a:b/c":      "a:b/",
+		"plain":      "",
+	} {
+		if got := parentPrefix(prefix); got != want {
+			t.Errorf("parentPrefix(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+}
+
+func openShell(t *testing.T) *shell {
+	tmpDir, err := os.MkdirTemp("", "badger-shell-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	for _, k := range []string{"user:1:name", "user:1:mail", "user:2:name", "user a:x", "logs/1"} {
+		if err := client.SetValue(k, []byte("v"), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &shell{client: client}
+}
+
+func TestShellScoping(t *testing.T) {
+	sh := openShell(t)
+	var out bytes.Buffer
+	for _, line := range []string{"cd user:1:", "set age 30", "get age", "get /logs/1", "count", "pwd"} {
+		if err := sh.exec(line, &out); err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+	}
+	if want := "30\nv\n3\n/user:1:\n"; out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+	if val, err := sh.client.GetValue("user:1:age"); err != nil || string(val) != "30" {
+		t.Errorf("Expected user:1:age to be set, got %q, %v", val, err)
+	}
+
+	// ls prints keys relative to the current prefix
+	out.Reset()
+	if err := sh.exec("ls", &out); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		keys = append(keys, strings.Fields(line)[0])
+	}
+	if want := []string{"age", "mail", "name"}; !slices.Equal(keys, want) {
+		t.Errorf("Expected %q, got %q", want, keys)
+	}
+}
+
+func TestShellComplete(t *testing.T) {
+	sh := openShell(t)
+	tests := []struct {
+		line  string
+		start int
+		want  []string
+	}{
+		{"", 0, []string{"ls", "get", "set", "del", "ttl", "count", "cd", "pwd", "help", "exit"}},
+		{"c", 0, []string{"count", "cd"}},
+		{"  ge", 2, []string{"get"}},
+		{"get ", 4, []string{"logs/", "user a:", "user:"}},
+		{"get user:", 4, []string{"user:1:", "user:2:"}},
+		{"get user:1:n", 4, []string{"user:1:name"}},
+		{`get "user a`, 4, []string{"user a:"}},
+		{`get user\ a:`, 4, []string{"user a:x"}},
+		{"get /lo", 4, []string{"/logs/"}},
+	}
+	for _, tt := range tests {
+		start, got := sh.complete(tt.line)
+		if start != tt.start || !slices.Equal(got, tt.want) {
+			t.Errorf("complete(%q) = %d, %q; want %d, %q", tt.line, start, got, tt.start, tt.want)
+		}
+	}
+
+	// Relative to the current prefix
+	sh.cd("user:")
+	if _, got := sh.complete("get 1:m"); !slices.Equal(got, []string{"1:mail"}) {
+		t.Errorf("Expected [1:mail], got %q", got)
+	}
+}
+
+func TestShellScript(t *testing.T) {
+	path := testDB(t)
+	script := filepath.Join(t.TempDir(), "script")
+
+	tests := []struct {
+		name, script string
+		want         int
+		stdout       string
+		stderr       string
+	}{
+		{"ok", "# comment\n\nset k 1\nget k\n", ExitOK, "1\n", ""},
+		{"exit stops", "get user:1\nexit\nget missing\n", ExitOK, "alice\n", ""},
+		{"stops at missing key", "get user:1\nget missing\nget user:2\n", ExitNotFound, "alice\n", ":2: "},
+		{"stops at error", "set k\nget user:1\n", ExitError, "", ":1: usage: set"},
+		{"bad quote", "get \"user:1\n", ExitError, "", ":1: unterminated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(script, []byte(tt.script), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			code, stdout, stderr := run(RunShell, "--db", path, "--script", script)
+			if code != tt.want || stdout != tt.stdout || !strings.Contains(stderr, tt.stderr) {
+				t.Errorf("Expected exit %d with %q and %q on stderr, got %d with %q and %q", tt.want, tt.stdout, tt.stderr, code, stdout, stderr)
+			}
+		})
+	}
+}
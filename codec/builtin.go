@@ -0,0 +1,331 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func init() {
+	// Order matters: it is the auto-detection order.
+	// Containers come first, then structured formats, then plain text.
+	Register(gzipCodec{})
+	Register(zstdCodec{})
+	Register(snappyCodec{})
+	Register(utf16Codec{})
+	Register(jsonCodec{})
+	Register(msgpackCodec{})
+	Register(cborCodec{})
+	Register(textCodec{})
+	Register(hexCodec{})
+	Register(gobCodec{})
+	Register(protoRawCodec{})
+}
+
+// --- text ---
+
+type textCodec struct{}
+
+func (textCodec) Name() string { return "text" }
+
+func (textCodec) Detect(data []byte) bool {
+	return utf8.Valid(data) && bytes.IndexByte(data, 0) < 0
+}
+
+func (textCodec) Decode(data []byte) (string, error) {
+	return string(data), nil
+}
+
+func (textCodec) EditText(data []byte) (string, error) {
+	return string(data), nil
+}
+
+func (textCodec) Encode(text string, _ []byte) ([]byte, error) {
+	return []byte(text), nil
+}
+
+// --- hex ---
+
+type hexCodec struct{}
+
+func (hexCodec) Name() string { return "hex" }
+
+func (hexCodec) Decode(data []byte) (string, error) {
+	return hex.Dump(data), nil
+}
+
+// EditText returns plain hex, 16 space-separated bytes per line.
+func (hexCodec) EditText(data []byte) (string, error) {
+	var sb strings.Builder
+	for i, b := range data {
+		if i > 0 {
+			if i%16 == 0 {
+				sb.WriteByte('\n')
+			} else {
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString(hex.EncodeToString([]byte{b}))
+	}
+	return sb.String(), nil
+}
+
+// Encode accepts hex digits with any whitespace in between.
+func (hexCodec) Encode(text string, _ []byte) ([]byte, error) {
+	return hex.DecodeString(strings.Join(strings.Fields(text), ""))
+}
+
+// --- json ---
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Detect(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return false
+	}
+	return json.Valid(trimmed)
+}
+
+func (jsonCodec) Decode(data []byte) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, bytes.TrimSpace(data), "", "  "); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (c jsonCodec) EditText(data []byte) (string, error) {
+	return c.Decode(data)
+}
+
+// Encode keeps the value compact unless the original was already multi-line.
+func (jsonCodec) Encode(text string, original []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if bytes.Contains(original, []byte("\n")) {
+		if err := json.Indent(&buf, []byte(strings.TrimSpace(text)), "", "  "); err != nil {
+			return nil, err
+		}
+	} else if err := json.Compact(&buf, []byte(text)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// --- msgpack ---
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+// Detect only accepts maps and arrays that decode completely.
+// Scalars are too ambiguous to tell apart from other binary data.
+func (msgpackCodec) Detect(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	c := data[0]
+	isContainer := (c >= 0x80 && c <= 0x9f) || c == 0xdc || c == 0xdd || c == 0xde || c == 0xdf
+	if !isContainer {
+		return false
+	}
+	_, err := decodeMsgpack(data)
+	return err == nil
+}
+
+func (msgpackCodec) Decode(data []byte) (string, error) {
+	v, err := decodeMsgpack(data)
+	if err != nil {
+		return "", err
+	}
+	return prettyJSON(v)
+}
+
+func (c msgpackCodec) EditText(data []byte) (string, error) {
+	return c.Decode(data)
+}
+
+// Encode reads the JSON produced by EditText. Binary fields were shown as
+// base64 strings and are written back as strings.
+func (msgpackCodec) Encode(text string, _ []byte) ([]byte, error) {
+	v, err := fromJSON(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.UseCompactInts(true) // Match what most producers write
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeMsgpack(data []byte) (interface{}, error) {
+	r := bytes.NewReader(data)
+	dec := msgpack.NewDecoder(r)
+	// Accept non-string map keys; they are stringified by prettyJSON
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) {
+		return d.DecodeUntypedMap()
+	})
+	v, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d bytes of trailing data", r.Len())
+	}
+	return v, nil
+}
+
+// --- cbor ---
+
+type cborCodec struct{}
+
+func (cborCodec) Name() string { return "cbor" }
+
+// Detect accepts the self-describe tag or a map/array that decodes completely.
+func (cborCodec) Detect(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	selfDescribed := len(data) >= 3 && data[0] == 0xd9 && data[1] == 0xd9 && data[2] == 0xf7
+	major := data[0] >> 5
+	if !selfDescribed && major != 4 && major != 5 {
+		return false
+	}
+	var v interface{}
+	return cbor.Unmarshal(data, &v) == nil
+}
+
+func (cborCodec) Decode(data []byte) (string, error) {
+	var v interface{}
+	if err := cbor.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+	return prettyJSON(v)
+}
+
+func (c cborCodec) EditText(data []byte) (string, error) {
+	return c.Decode(data)
+}
+
+// Encode reads the JSON produced by EditText, like msgpackCodec.Encode.
+func (cborCodec) Encode(text string, _ []byte) ([]byte, error) {
+	v, err := fromJSON(text)
+	if err != nil {
+		return nil, err
+	}
+	return cbor.Marshal(v)
+}
+
+// --- gob ---
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+// Decode tries the generic shapes gob can decode without the producer's types.
+// Streams of named struct types cannot be decoded this way.
+func (gobCodec) Decode(data []byte) (string, error) {
+	targets := []func() interface{}{
+		func() interface{} { return new(map[string]interface{}) },
+		func() interface{} { return new(map[string]string) },
+		func() interface{} { return new([]interface{}) },
+		func() interface{} { return new([]string) },
+		func() interface{} { return new(string) },
+		func() interface{} { return new(int64) },
+		func() interface{} { return new(float64) },
+		func() interface{} { return new([]byte) },
+	}
+
+	var lastErr error
+	for _, target := range targets {
+		v := target()
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(v); err != nil {
+			lastErr = err
+			continue
+		}
+		return prettyJSON(v)
+	}
+	return "", fmt.Errorf("unsupported gob stream: %w", lastErr)
+}
+
+// prettyJSON renders a decoded value as indented JSON.
+func prettyJSON(v interface{}) (string, error) {
+	out, err := json.MarshalIndent(normalize(v), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// fromJSON parses edited JSON, keeping integers as int64 so they are not re-encoded as floats.
+func fromJSON(text string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return fromJSONNumbers(v), nil
+}
+
+func fromJSONNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = fromJSONNumbers(val)
+		}
+		return t
+	case []interface{}:
+		for i, val := range t {
+			t[i] = fromJSONNumbers(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// normalize converts maps with non-string keys so they can be marshalled to JSON.
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = normalize(val)
+		}
+		return m
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = normalize(val)
+		}
+		return t
+	case []interface{}:
+		for i, val := range t {
+			t[i] = normalize(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,163 @@
+// Package codec turns raw Badger values into human-readable text.
+// Codecs are kept in a registry and can be picked by name or detected from the data.
+package codec
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Auto selects a codec by inspecting the value.
+const Auto = "auto"
+
+// Codec decodes raw value bytes into text.
+type Codec interface {
+	Name() string
+	Decode(data []byte) (string, error)
+}
+
+// Detector is implemented by codecs that can recognise their own format.
+type Detector interface {
+	Detect(data []byte) bool
+}
+
+// Unwrapper is implemented by container formats (compression, text encodings).
+// The unwrapped payload is decoded again with auto-detection.
+type Unwrapper interface {
+	Unwrap(data []byte) ([]byte, error)
+}
+
+// Editor is implemented by codecs whose values can be edited as text and encoded back.
+type Editor interface {
+	// EditText returns the value as editable text.
+	EditText(data []byte) (string, error)
+	// Encode parses edited text back into the codec's format.
+	// original is the value before editing and may be used to keep its formatting.
+	Encode(text string, original []byte) ([]byte, error)
+}
+
+// Wrapper is implemented by container codecs that can re-wrap an edited payload.
+type Wrapper interface {
+	Wrap(payload, original []byte) ([]byte, error)
+}
+
+// Decoded is the result of decoding a value.
+type Decoded struct {
+	Codec string // Codec chain that produced Text, e.g. "gzip+json"
+	Text  string
+}
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Codec{}
+	order    []string // Registration order, also the detection order
+)
+
+// Register adds c to the registry. Registering a name twice replaces the codec
+// but keeps its original position.
+func Register(c Codec) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := registry[c.Name()]; !ok {
+		order = append(order, c.Name())
+	}
+	registry[c.Name()] = c
+}
+
+// Get returns the codec registered under name.
+// Names of the form "protobuf:<message>" resolve to a message from the loaded descriptor sets.
+func Get(name string) (Codec, bool) {
+	if msgName, ok := strings.CutPrefix(name, ProtobufPrefix); ok {
+		return protoMessageCodec(msgName)
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := registry[name]
+	return c, ok
+}
+
+// Names returns "auto", every registered codec and every loaded protobuf message type.
+func Names() []string {
+	mu.RLock()
+	names := append([]string{Auto}, order...)
+	mu.RUnlock()
+
+	for _, msg := range ProtoMessages() {
+		names = append(names, ProtobufPrefix+msg)
+	}
+	return names
+}
+
+// Detect returns the name of the first registered codec that recognises data.
+// Values no detector claims are shown as a hex dump.
+func Detect(data []byte) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, name := range order {
+		if d, ok := registry[name].(Detector); ok && d.Detect(data) {
+			return name
+		}
+	}
+	return "hex"
+}
+
+// MaxNesting bounds how many containers Decode unwraps, so a value that
+// unwraps to itself (a gzip quine, say) cannot recurse without end.
+const MaxNesting = 4
+
+// Decode decodes data with the named codec, or with the detected one when name is Auto or empty.
+func Decode(name string, data []byte) (Decoded, error) {
+	return decode(name, data, 0)
+}
+
+// decode is Decode below depth containers.
+func decode(name string, data []byte, depth int) (Decoded, error) {
+	if name == "" || name == Auto {
+		name = Detect(data)
+	}
+
+	c, ok := Get(name)
+	if !ok {
+		return Decoded{}, fmt.Errorf("unknown codec: %s", name)
+	}
+
+	if u, ok := c.(Unwrapper); ok {
+		if depth >= MaxNesting {
+			return Decoded{}, errTooDeep
+		}
+		inner, err := u.Unwrap(data)
+		if err != nil {
+			return Decoded{}, fmt.Errorf("%s: %w", name, err)
+		}
+		d, err := decode(Auto, inner, depth+1)
+		if err != nil {
+			return Decoded{}, err
+		}
+		d.Codec = name + "+" + d.Codec
+		return d, nil
+	}
+
+	text, err := c.Decode(data)
+	if err != nil {
+		return Decoded{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return Decoded{Codec: name, Text: text}, nil
+}
+
+var errTooDeep = fmt.Errorf("containers nested more than %d deep", MaxNesting)
+
+// Next returns the codec name following current in Names, wrapping around.
+// It is used by the detail view to cycle through codecs.
+func Next(current string) string {
+	names := Names()
+	for i, n := range names {
+		if n == current {
+			return names[(i+1)%len(names)]
+		}
+	}
+	return names[0]
+}
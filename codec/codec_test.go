@@ -0,0 +1,328 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestDetect(t *testing.T) {
+	mp, _ := msgpack.Marshal(map[string]interface{}{"name": "badger"})
+	cb, _ := cbor.Marshal(map[string]interface{}{"name": "badger"})
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(`{"name":"badger"}`))
+	w.Close()
+
+	tests := []struct {
+		name  string
+		data  []byte
+		codec string
+	}{
+		{"json", []byte(`{"name":"badger"}`), "json"},
+		{"msgpack", mp, "msgpack"},
+		{"cbor", cb, "cbor"},
+		{"gzip", gz.Bytes(), "gzip+json"},
+		{"utf16", []byte{0xff, 0xfe, 'h', 0, 'i', 0}, "utf16+text"},
+		{"text", []byte("hello"), "text"},
+		{"binary", []byte{0x00, 0x01, 0x02}, "hex"},
+	}
+
+	for _, tt := range tests {
+		d, err := Decode(Auto, tt.data)
+		if err != nil {
+			t.Errorf("%s: decode failed: %v", tt.name, err)
+			continue
+		}
+		if d.Codec != tt.codec {
+			t.Errorf("%s: expected codec %s, got %s", tt.name, tt.codec, d.Codec)
+		}
+		if tt.codec != "hex" && tt.codec != "utf16+text" && tt.codec != "text" && !strings.Contains(d.Text, `"name": "badger"`) {
+			t.Errorf("%s: unexpected text %q", tt.name, d.Text)
+		}
+	}
+}
+
+func TestProtobufDescriptorSet(t *testing.T) {
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("user.proto"),
+		Package: proto.String("acme.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("User"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("name"),
+				JsonName: proto.String("name"),
+				Number:   proto.Int32(1),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			}},
+		}},
+	}
+	set, _ := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{fdp}})
+
+	path := filepath.Join(t.TempDir(), "user.pb")
+	if err := os.WriteFile(path, set, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadDescriptorSet(path); err != nil {
+		t.Fatalf("LoadDescriptorSet failed: %v", err)
+	}
+
+	// Encode a message with the same descriptor
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := dynamicpb.NewMessage(fd.Messages().Get(0))
+	msg.Set(fd.Messages().Get(0).Fields().Get(0), protoreflect.ValueOfString("badger"))
+	data, _ := proto.Marshal(msg)
+
+	d, err := Decode("protobuf:acme.v1.User", data)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !strings.Contains(d.Text, `"name": "badger"`) {
+		t.Errorf("Unexpected text %q", d.Text)
+	}
+
+	// Schema-less dump still shows the field
+	d, err = Decode("protobuf", data)
+	if err != nil {
+		t.Fatalf("Raw decode failed: %v", err)
+	}
+	if !strings.Contains(d.Text, `1: "badger"`) {
+		t.Errorf("Unexpected raw dump %q", d.Text)
+	}
+}
+
+func TestEditRoundTrip(t *testing.T) {
+	mp, _ := msgpack.Marshal(map[string]interface{}{"count": 1})
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(`{"a":1}`))
+	w.Close()
+
+	tests := []struct {
+		chain string
+		data  []byte
+	}{
+		{"json", []byte(`{"a":1}`)},
+		{"gzip+json", gz.Bytes()},
+		{"msgpack", mp},
+		{"hex", []byte{0x00, 0xff, 0x10}},
+	}
+
+	for _, tt := range tests {
+		text, err := EditText(tt.chain, tt.data)
+		if err != nil {
+			t.Errorf("%s: EditText failed: %v", tt.chain, err)
+			continue
+		}
+		encoded, err := Encode(tt.chain, text, tt.data)
+		if err != nil {
+			t.Errorf("%s: Encode failed: %v", tt.chain, err)
+			continue
+		}
+		// Compare decoded forms; compressed bytes need not be identical
+		before, _ := Decode(tt.chain, tt.data)
+		after, _ := Decode(tt.chain, encoded)
+		if before.Text != after.Text {
+			t.Errorf("%s: round trip changed value: %q -> %q", tt.chain, before.Text, after.Text)
+		}
+	}
+
+	// Integers stay integers in msgpack
+	encoded, _ := Encode("msgpack", `{"count": 2}`, mp)
+	var v map[string]interface{}
+	msgpack.Unmarshal(encoded, &v)
+	if n, ok := v["count"].(int8); !ok || n != 2 {
+		t.Errorf("Expected integer count, got %T", v["count"])
+	}
+
+	if _, err := Encode("json", `{"a":`, nil); err == nil {
+		t.Error("Expected invalid JSON to be rejected")
+	}
+	if CanEdit("gob") {
+		t.Error("gob should not be editable")
+	}
+}
+
+func TestUnwrapLimit(t *testing.T) {
+	defer SetMaxUnwrapSize(0)
+	payload := bytes.Repeat([]byte("a"), 4096)
+
+	tests := []struct {
+		name  string
+		codec interface {
+			Unwrapper
+			Wrap(payload, original []byte) ([]byte, error)
+		}
+		original []byte
+	}{
+		{"gzip", gzipCodec{}, nil},
+		{"zstd", zstdCodec{}, nil},
+		{"snappy stream", snappyCodec{}, snappyStreamMagic},
+		{"snappy block", snappyCodec{}, nil},
+	}
+	for _, tt := range tests {
+		data, err := tt.codec.Wrap(payload, tt.original)
+		if err != nil {
+			t.Fatalf("%s: Wrap failed: %v", tt.name, err)
+		}
+
+		SetMaxUnwrapSize(int64(len(payload)))
+		if out, err := tt.codec.Unwrap(data); err != nil || !bytes.Equal(out, payload) {
+			t.Errorf("%s: Unwrap at the limit failed: %v", tt.name, err)
+		}
+		SetMaxUnwrapSize(int64(len(payload) - 1))
+		if _, err := tt.codec.Unwrap(data); err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+			t.Errorf("%s: Expected the limit to be enforced, got %v", tt.name, err)
+		}
+	}
+}
+
+// loopCodec is a container whose payload is the value itself, like a gzip quine.
+type loopCodec struct{}
+
+func (loopCodec) Name() string                       { return "test-loop" }
+func (loopCodec) Detect(data []byte) bool            { return bytes.HasPrefix(data, []byte("\x00loop")) }
+func (loopCodec) Decode(data []byte) (string, error) { return decodeUnwrapped(loopCodec{}, data) }
+func (loopCodec) Unwrap(data []byte) ([]byte, error) { return data, nil }
+
+func TestDecodeNesting(t *testing.T) {
+	nest := func(n int) []byte {
+		data := []byte(`{"a":1}`)
+		for range n {
+			data, _ = gzipCodec{}.Wrap(data, nil)
+		}
+		return data
+	}
+	d, err := Decode(Auto, nest(MaxNesting))
+	if want := strings.Repeat("gzip+", MaxNesting) + "json"; err != nil || d.Codec != want {
+		t.Errorf("Expected %s, got %q, %v", want, d.Codec, err)
+	}
+	if _, err := Decode(Auto, nest(MaxNesting+1)); err == nil || !strings.Contains(err.Error(), "nested") {
+		t.Errorf("Expected nesting error, got %v", err)
+	}
+
+	Register(loopCodec{})
+	loop := []byte("\x00loop")
+	if _, err := Decode(Auto, loop); err == nil || !strings.Contains(err.Error(), "nested") {
+		t.Errorf("Expected nesting error for a self-unwrapping value, got %v", err)
+	}
+	if _, err := (loopCodec{}).Decode(loop); err == nil {
+		t.Error("Expected nesting error from Codec.Decode")
+	}
+}
+
+func TestQueryJSON(t *testing.T) {
+	doc := `{"users":[{"name":"kim","age":31,"tags":["a"]},{"name":"lee","age":25,"email":"l@x"}],"meta":{"total":2}}`
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"$.meta.total", `2`},
+		{".users[0].name", `"kim"`},
+		{"$.users[-1].name", `"lee"`},
+		{"$['meta']['total']", `2`},
+		{"$.users[*].name", `["kim","lee"]`},
+		{".users[].age", `[31,25]`},
+		{"$..name", `["kim","lee"]`},
+		{"$.users[0:1].name", `["kim"]`},
+		{"$.users[?(@.age > 30)].name", `["kim"]`},
+		{"$.users[?(@.name == 'lee')].age", `[25]`},
+		{"$.users[?(@.email)].name", `["lee"]`},
+		{"$.users[?(@.tags == [])].name", `[]`},
+		{`$.users[?(@.tags == ["a"])].name`, `["kim"]`},
+		{"$.users[?(@.tags != [])].name", `["kim"]`},
+		{`$.users[?(@.meta == {"x":1})].name`, `[]`},
+		{`$.users[?(@.tags < ["b"])].name`, `[]`},
+	}
+
+	for _, tt := range tests {
+		v, err := QueryJSON(doc, tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		got, _ := json.Marshal(v)
+		if string(got) != tt.want {
+			t.Errorf("%s: expected %s, got %s", tt.expr, tt.want, got)
+		}
+	}
+
+	if _, err := QueryJSON(doc, "$.missing"); err == nil {
+		t.Error("Expected error for missing definite path")
+	}
+	if _, err := QueryJSON(doc, "$.users[0"); err == nil {
+		t.Error("Expected error for unclosed bracket")
+	}
+	for _, expr := range []string{`$['a'b']`, `$['a\']`} {
+		if _, err := QueryJSON(doc, expr); err == nil {
+			t.Errorf("Expected error for %s", expr)
+		}
+	}
+}
+
+func TestQueryJSONQuoting(t *testing.T) {
+	// Operators inside quoted literals and names are not operators
+	doc := `[{"x":"a<b","y":1},{"x":"a==b","y":2},{"x<y":3,"y":4}]`
+	for expr, want := range map[string]string{
+		"$[?(@.x == 'a<b')].y":    `[1]`,
+		"$[?(@.x == \"a==b\")].y": `[2]`,
+		"$[?(@.x != 'a<b')].y":    `[2]`,
+		"$[?(@['x<y'] > 2)].y":    `[4]`,
+		"$[?(@.x == 'it\\'s')].y": `[]`,
+		"$[?(@.x == 'a]b')].y":    `[]`,
+		"$[?(@.x == 'a,b')].y":    `[]`,
+	} {
+		v, err := QueryJSON(doc, expr)
+		if err != nil {
+			t.Errorf("%s: %v", expr, err)
+			continue
+		}
+		if got, _ := json.Marshal(v); string(got) != want {
+			t.Errorf("%s: expected %s, got %s", expr, want, got)
+		}
+	}
+
+	// Every name survives QuoteName and the parser
+	names := []string{"plain", "a b", "it's", `back\slash`, `\'`, `end\`, `"`, "a]b", "a,b", "a<b"}
+	obj := map[string]int{}
+	for i, name := range names {
+		obj[name] = i
+	}
+	b, _ := json.Marshal(obj)
+	for i, name := range names {
+		v, err := QueryJSON(string(b), "$"+QuoteName(name))
+		if err != nil {
+			t.Errorf("%q: %v", name, err)
+			continue
+		}
+		if got, _ := json.Marshal(v); string(got) != strconv.Itoa(i) {
+			t.Errorf("%q: expected %d, got %s", name, i, got)
+		}
+	}
+	// Union of quoted names with commas and escapes
+	v, err := QueryJSON(string(b), `$['a,b','it\'s']`)
+	if got, _ := json.Marshal(v); err != nil || string(got) != `[8,2]` {
+		t.Errorf("Expected [8,2], got %s, %v", got, err)
+	}
+}
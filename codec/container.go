@@ -0,0 +1,204 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// DefaultMaxUnwrapSize bounds the decompressed size of a value unless
+// SetMaxUnwrapSize sets another limit.
+const DefaultMaxUnwrapSize = 256 << 20
+
+var maxUnwrapSize atomic.Int64
+
+func init() {
+	maxUnwrapSize.Store(DefaultMaxUnwrapSize)
+}
+
+// SetMaxUnwrapSize bounds the output of the gzip, zstd and snappy codecs, so
+// a small value cannot expand without limit. n <= 0 restores the default.
+func SetMaxUnwrapSize(n int64) {
+	if n <= 0 {
+		n = DefaultMaxUnwrapSize
+	}
+	maxUnwrapSize.Store(n)
+}
+
+func unwrapLimitErr(limit int64) error {
+	return fmt.Errorf("decompressed value exceeds the limit of %d bytes", limit)
+}
+
+// readLimited reads r to the end, failing once it yields more than the unwrap limit.
+func readLimited(r io.Reader) ([]byte, error) {
+	limit := maxUnwrapSize.Load()
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, unwrapLimitErr(limit)
+	}
+	return data, nil
+}
+
+// --- gzip ---
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string { return "gzip" }
+
+func (gzipCodec) Detect(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+func (gzipCodec) Decode(data []byte) (string, error) { return decodeUnwrapped(gzipCodec{}, data) }
+
+func (gzipCodec) Unwrap(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return readLimited(r)
+}
+
+func (gzipCodec) Wrap(payload, _ []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// --- zstd ---
+
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+type zstdCodec struct{}
+
+func (zstdCodec) Name() string { return "zstd" }
+
+func (zstdCodec) Detect(data []byte) bool {
+	return bytes.HasPrefix(data, zstdMagic)
+}
+
+func (zstdCodec) Decode(data []byte) (string, error) { return decodeUnwrapped(zstdCodec{}, data) }
+
+func (zstdCodec) Unwrap(data []byte) ([]byte, error) {
+	dec, err := zstd.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	return readLimited(dec)
+}
+
+func (zstdCodec) Wrap(payload, _ []byte) ([]byte, error) {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer enc.Close()
+	return enc.EncodeAll(payload, nil), nil
+}
+
+// --- snappy ---
+
+// snappyStreamMagic is the stream identifier chunk of the framed snappy format.
+var snappyStreamMagic = []byte("\xff\x06\x00\x00sNaPpY")
+
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string { return "snappy" }
+
+// Detect only recognises the framed format; raw snappy blocks have no magic
+// and must be selected explicitly.
+func (snappyCodec) Detect(data []byte) bool {
+	return bytes.HasPrefix(data, snappyStreamMagic)
+}
+
+func (snappyCodec) Decode(data []byte) (string, error) { return decodeUnwrapped(snappyCodec{}, data) }
+
+func (snappyCodec) Unwrap(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, snappyStreamMagic) {
+		return readLimited(s2.NewReader(bytes.NewReader(data)))
+	}
+	// Raw blocks state their decoded size up front
+	n, err := s2.DecodedLen(data)
+	if err != nil {
+		return nil, err
+	}
+	if limit := maxUnwrapSize.Load(); int64(n) > limit {
+		return nil, unwrapLimitErr(limit)
+	}
+	return s2.Decode(nil, data)
+}
+
+// Wrap keeps the framing of the original: framed stream or raw block.
+func (snappyCodec) Wrap(payload, original []byte) ([]byte, error) {
+	if !bytes.HasPrefix(original, snappyStreamMagic) {
+		return s2.EncodeSnappy(nil, payload), nil
+	}
+	var buf bytes.Buffer
+	w := s2.NewWriter(&buf, s2.WriterSnappyCompat())
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// --- utf16 ---
+
+type utf16Codec struct{}
+
+func (utf16Codec) Name() string { return "utf16" }
+
+// Detect requires a byte order mark.
+func (utf16Codec) Detect(data []byte) bool {
+	return len(data) >= 2 && ((data[0] == 0xff && data[1] == 0xfe) || (data[0] == 0xfe && data[1] == 0xff))
+}
+
+func (utf16Codec) Decode(data []byte) (string, error) { return decodeUnwrapped(utf16Codec{}, data) }
+
+// Unwrap converts to UTF-8, honouring the BOM and defaulting to little endian.
+func (utf16Codec) Unwrap(data []byte) ([]byte, error) {
+	dec := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewDecoder()
+	return dec.Bytes(data)
+}
+
+// Wrap writes a BOM with the byte order of the original.
+func (utf16Codec) Wrap(payload, original []byte) ([]byte, error) {
+	order := unicode.LittleEndian
+	if len(original) >= 2 && original[0] == 0xfe && original[1] == 0xff {
+		order = unicode.BigEndian
+	}
+	enc := unicode.UTF16(order, unicode.UseBOM).NewEncoder()
+	return enc.Bytes(payload)
+}
+
+// decodeUnwrapped satisfies Codec.Decode for container codecs used directly.
+func decodeUnwrapped(u Unwrapper, data []byte) (string, error) {
+	inner, err := u.Unwrap(data)
+	if err != nil {
+		return "", err
+	}
+	d, err := decode(Auto, inner, 1)
+	if err != nil {
+		return "", err
+	}
+	return d.Text, nil
+}
@@ -0,0 +1,92 @@
+package codec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EditText returns editable text for data using a codec chain as reported in Decoded.Codec,
+// e.g. "gzip+json". Containers in the chain are unwrapped first.
+func EditText(chain string, data []byte) (string, error) {
+	name, rest, nested := strings.Cut(chain, "+")
+
+	c, ok := Get(name)
+	if !ok {
+		return "", fmt.Errorf("unknown codec: %s", name)
+	}
+
+	if nested {
+		u, ok := c.(Unwrapper)
+		if !ok {
+			return "", fmt.Errorf("codec %s is not a container", name)
+		}
+		inner, err := u.Unwrap(data)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+		return EditText(rest, inner)
+	}
+
+	e, ok := c.(Editor)
+	if !ok {
+		return "", fmt.Errorf("codec %s does not support editing", name)
+	}
+	return e.EditText(data)
+}
+
+// Encode is the inverse of EditText: it parses text with the innermost codec of the chain
+// and re-wraps the result with every container, using original to keep the value's format.
+func Encode(chain string, text string, original []byte) ([]byte, error) {
+	name, rest, nested := strings.Cut(chain, "+")
+
+	c, ok := Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown codec: %s", name)
+	}
+
+	if nested {
+		u, uok := c.(Unwrapper)
+		w, wok := c.(Wrapper)
+		if !uok || !wok {
+			return nil, fmt.Errorf("codec %s is not a container", name)
+		}
+		inner, err := u.Unwrap(original)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		payload, err := Encode(rest, text, inner)
+		if err != nil {
+			return nil, err
+		}
+		return w.Wrap(payload, original)
+	}
+
+	e, ok := c.(Editor)
+	if !ok {
+		return nil, fmt.Errorf("codec %s does not support editing", name)
+	}
+	data, err := e.Encode(text, original)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return data, nil
+}
+
+// CanEdit reports whether every codec in chain supports editing.
+func CanEdit(chain string) bool {
+	for {
+		name, rest, nested := strings.Cut(chain, "+")
+		c, ok := Get(name)
+		if !ok {
+			return false
+		}
+		if !nested {
+			_, ok := c.(Editor)
+			return ok
+		}
+		if _, ok := c.(Wrapper); !ok {
+			return false
+		}
+		chain = rest
+	}
+}
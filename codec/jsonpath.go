@@ -0,0 +1,556 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryJSON evaluates a JSONPath expression against JSON text.
+// Both "$.users[0].name" and the jq-like ".users[0].name" forms are accepted.
+// Supported: child (.name, ['name']), wildcard (*, [*], .[]), index (negative too),
+// slice ([a:b]), union ([0,2] / ['a','b']), recursive descent (..name) and
+// filters ([?(@.age > 30)], [?(@.email)]). Inside quotes a backslash escapes
+// the next character, e.g. ['it\'s'].
+// A definite path returns the matched value; other paths return an array of matches.
+func QueryJSON(text, expr string) (interface{}, error) {
+	steps, err := parsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("value is not JSON: %w", err)
+	}
+
+	matches := []interface{}{doc}
+	definite := true
+	for _, s := range steps {
+		if !s.definite() {
+			definite = false
+		}
+		var next []interface{}
+		for _, v := range matches {
+			next = s.apply(v, next)
+		}
+		matches = next
+	}
+
+	if definite {
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no match for %s", expr)
+		}
+		return matches[0], nil
+	}
+	if matches == nil {
+		matches = []interface{}{}
+	}
+	return matches, nil
+}
+
+// QueryJSONPretty runs QueryJSON and renders the result as indented JSON.
+func QueryJSONPretty(text, expr string) (string, error) {
+	v, err := QueryJSON(text, expr)
+	if err != nil {
+		return "", err
+	}
+	return prettyJSON(v)
+}
+
+type stepKind int
+
+const (
+	stepChild stepKind = iota
+	stepWildcard
+	stepIndex
+	stepSlice
+	stepFilter
+)
+
+type pathStep struct {
+	kind      stepKind
+	recursive bool
+	names     []string // stepChild, more than one for unions
+	indices   []int    // stepIndex, more than one for unions
+	start     *int     // stepSlice
+	end       *int
+	filter    *pathFilter
+}
+
+type pathFilter struct {
+	path  []pathStep
+	op    string // "" for an existence check
+	value interface{}
+}
+
+func (s pathStep) definite() bool {
+	return !s.recursive && (s.kind == stepChild && len(s.names) == 1 || s.kind == stepIndex && len(s.indices) == 1)
+}
+
+func (s pathStep) apply(v interface{}, out []interface{}) []interface{} {
+	out = s.applyHere(v, out)
+	if s.recursive {
+		// Descend into every child and apply the step there as well
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for _, k := range sortedKeys(t) {
+				out = s.apply(t[k], out)
+			}
+		case []interface{}:
+			for _, c := range t {
+				out = s.apply(c, out)
+			}
+		}
+	}
+	return out
+}
+
+func (s pathStep) applyHere(v interface{}, out []interface{}) []interface{} {
+	switch s.kind {
+	case stepChild:
+		if m, ok := v.(map[string]interface{}); ok {
+			for _, name := range s.names {
+				if c, ok := m[name]; ok {
+					out = append(out, c)
+				}
+			}
+		}
+	case stepWildcard:
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for _, k := range sortedKeys(t) {
+				out = append(out, t[k])
+			}
+		case []interface{}:
+			out = append(out, t...)
+		}
+	case stepIndex:
+		if a, ok := v.([]interface{}); ok {
+			for _, i := range s.indices {
+				if i < 0 {
+					i += len(a)
+				}
+				if i >= 0 && i < len(a) {
+					out = append(out, a[i])
+				}
+			}
+		}
+	case stepSlice:
+		if a, ok := v.([]interface{}); ok {
+			start, end := 0, len(a)
+			if s.start != nil {
+				start = clampIndex(*s.start, len(a))
+			}
+			if s.end != nil {
+				end = clampIndex(*s.end, len(a))
+			}
+			for i := start; i < end; i++ {
+				out = append(out, a[i])
+			}
+		}
+	case stepFilter:
+		var children []interface{}
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for _, k := range sortedKeys(t) {
+				children = append(children, t[k])
+			}
+		case []interface{}:
+			children = t
+		}
+		for _, c := range children {
+			if s.filter.match(c) {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
+
+func clampIndex(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > n {
+		return n
+	}
+	return i
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	// Insertion order is lost in Go maps; sort for stable results
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *pathFilter) match(v interface{}) bool {
+	matches := []interface{}{v}
+	for _, s := range f.path {
+		var next []interface{}
+		for _, m := range matches {
+			next = s.apply(m, next)
+		}
+		matches = next
+	}
+	if f.op == "" {
+		return len(matches) > 0
+	}
+	for _, m := range matches {
+		if compareJSON(m, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareJSON(left interface{}, op string, right interface{}) bool {
+	if ln, ok := toFloat(left); ok {
+		if rn, ok := toFloat(right); ok {
+			switch op {
+			case "==":
+				return ln == rn
+			case "!=":
+				return ln != rn
+			case "<":
+				return ln < rn
+			case "<=":
+				return ln <= rn
+			case ">":
+				return ln > rn
+			case ">=":
+				return ln >= rn
+			}
+			return false
+		}
+	}
+	if ls, ok := left.(string); ok {
+		if rs, ok := right.(string); ok {
+			switch op {
+			case "==":
+				return ls == rs
+			case "!=":
+				return ls != rs
+			case "<":
+				return ls < rs
+			case "<=":
+				return ls <= rs
+			case ">":
+				return ls > rs
+			case ">=":
+				return ls >= rs
+			}
+			return false
+		}
+	}
+	// Arrays and objects are not comparable with ==
+	switch op {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	}
+	return false
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case float64:
+		return t, true
+	}
+	return 0, false
+}
+
+// --- parser ---
+
+func parsePath(expr string) ([]pathStep, error) {
+	p := &pathParser{src: strings.TrimSpace(expr)}
+	if strings.HasPrefix(p.src, "$") || strings.HasPrefix(p.src, "@") {
+		p.pos = 1
+	}
+	var steps []pathStep
+	for p.pos < len(p.src) {
+		s, err := p.step()
+		if err != nil {
+			return nil, fmt.Errorf("invalid path %q: %w", expr, err)
+		}
+		if s != nil {
+			steps = append(steps, *s)
+		}
+	}
+	return steps, nil
+}
+
+type pathParser struct {
+	src string
+	pos int
+}
+
+func (p *pathParser) peek(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+func (p *pathParser) step() (*pathStep, error) {
+	recursive := false
+	switch {
+	case p.peek(".."):
+		recursive = true
+		p.pos += 2
+	case p.peek("."):
+		p.pos++
+	}
+
+	if p.pos >= len(p.src) {
+		if recursive {
+			return nil, fmt.Errorf("missing name after '..'")
+		}
+		return nil, nil // Trailing "." (jq identity)
+	}
+
+	if p.peek("[") {
+		s, err := p.bracket()
+		if err != nil {
+			return nil, err
+		}
+		s.recursive = recursive
+		return s, nil
+	}
+
+	if p.peek("*") {
+		p.pos++
+		return &pathStep{kind: stepWildcard, recursive: recursive}, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(".[ ", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, fmt.Errorf("unexpected %q at %d", p.src[p.pos], p.pos)
+	}
+	return &pathStep{kind: stepChild, recursive: recursive, names: []string{p.src[start:p.pos]}}, nil
+}
+
+func (p *pathParser) bracket() (*pathStep, error) {
+	end := p.matchingBracket()
+	if end < 0 {
+		return nil, fmt.Errorf("unclosed '['")
+	}
+	inner := strings.TrimSpace(p.src[p.pos+1 : end])
+	p.pos = end + 1
+
+	switch {
+	case inner == "" || inner == "*":
+		return &pathStep{kind: stepWildcard}, nil
+	case strings.HasPrefix(inner, "?"):
+		f, err := parseFilter(inner[1:])
+		if err != nil {
+			return nil, err
+		}
+		return &pathStep{kind: stepFilter, filter: f}, nil
+	case strings.HasPrefix(inner, "'") || strings.HasPrefix(inner, "\""):
+		var names []string
+		for _, part := range splitUnion(inner) {
+			name, err := unquote(part)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+		}
+		return &pathStep{kind: stepChild, names: names}, nil
+	case strings.Contains(inner, ":"):
+		parts := strings.SplitN(inner, ":", 2)
+		s := &pathStep{kind: stepSlice}
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid slice bound %q", part)
+			}
+			if i == 0 {
+				s.start = &n
+			} else {
+				s.end = &n
+			}
+		}
+		return s, nil
+	default:
+		var indices []int
+		for _, part := range splitUnion(inner) {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q", part)
+			}
+			indices = append(indices, n)
+		}
+		return &pathStep{kind: stepIndex, indices: indices}, nil
+	}
+}
+
+// quoteScan follows quoted text through a left-to-right scan. Inside quotes
+// a backslash escapes the next character.
+type quoteScan struct {
+	quote   byte
+	escaped bool
+}
+
+// outside reports whether c lies outside quoted text, quotes included.
+func (q *quoteScan) outside(c byte) bool {
+	switch {
+	case q.escaped:
+		q.escaped = false
+	case q.quote != 0:
+		if c == '\\' {
+			q.escaped = true
+		} else if c == q.quote {
+			q.quote = 0
+		}
+	case c == '\'' || c == '"':
+		q.quote = c
+	default:
+		return true
+	}
+	return false
+}
+
+// matchingBracket returns the index of the ']' closing the '[' at p.pos, skipping quoted text.
+func (p *pathParser) matchingBracket() int {
+	depth := 0
+	var q quoteScan
+	for i := p.pos; i < len(p.src); i++ {
+		c := p.src[i]
+		switch {
+		case !q.outside(c):
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func splitUnion(s string) []string {
+	var parts []string
+	var q quoteScan
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if q.outside(s[i]) && s[i] == ',' {
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// unquote strips the quotes around s and resolves its backslash escapes.
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != s[len(s)-1] || (s[0] != '\'' && s[0] != '"') {
+		return "", fmt.Errorf("invalid quoted name %s", s)
+	}
+	var sb strings.Builder
+	for i := 1; i < len(s)-1; i++ {
+		c := s[i]
+		if c == s[0] {
+			return "", fmt.Errorf("invalid quoted name %s", s)
+		}
+		if c == '\\' {
+			i++
+			if i == len(s)-1 {
+				return "", fmt.Errorf("invalid quoted name %s", s)
+			}
+			c = s[i]
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), nil
+}
+
+var nameEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// QuoteName returns the bracket step selecting the member name, e.g. ['it\'s'].
+func QuoteName(name string) string {
+	return "['" + nameEscaper.Replace(name) + "']"
+}
+
+var filterOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// findOp returns the position of the first comparison operator in s outside
+// quoted text, or an empty op if there is none.
+func findOp(s string) (int, string) {
+	var q quoteScan
+	for i := 0; i < len(s); i++ {
+		if !q.outside(s[i]) {
+			continue
+		}
+		for _, op := range filterOps {
+			if strings.HasPrefix(s[i:], op) {
+				return i, op
+			}
+		}
+	}
+	return -1, ""
+}
+
+// parseFilter parses "(@.path op literal)" or "(@.path)".
+func parseFilter(s string) (*pathFilter, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("filter must be wrapped in parentheses")
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+	if !strings.HasPrefix(s, "@") {
+		return nil, fmt.Errorf("filter must start with @")
+	}
+
+	f := &pathFilter{}
+	left := s
+	i, op := findOp(s)
+	if op != "" {
+		left = strings.TrimSpace(s[:i])
+		f.op = op
+		lit := strings.TrimSpace(s[i+len(op):])
+		if strings.HasPrefix(lit, "'") {
+			// Single-quoted strings are common in JSONPath but not valid JSON
+			name, err := unquote(lit)
+			if err != nil {
+				return nil, err
+			}
+			f.value = name
+		} else {
+			dec := json.NewDecoder(strings.NewReader(lit))
+			dec.UseNumber()
+			if err := dec.Decode(&f.value); err != nil {
+				return nil, fmt.Errorf("invalid filter value %q", lit)
+			}
+		}
+	}
+
+	path, err := parsePath(left)
+	if err != nil {
+		return nil, err
+	}
+	f.path = path
+	return f, nil
+}
@@ -0,0 +1,271 @@
+package codec
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ProtobufPrefix prefixes codec names that decode a specific message type,
+// e.g. "protobuf:acme.v1.User".
+const ProtobufPrefix = "protobuf:"
+
+var (
+	protoMu    sync.RWMutex
+	protoFiles = new(protoregistry.Files)
+)
+
+// LoadDescriptorSet loads a serialized FileDescriptorSet (as produced by
+// `protoc --descriptor_set_out=x.pb --include_imports`) and makes its messages available as codecs.
+func LoadDescriptorSet(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &fds); err != nil {
+		return fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+
+	files, err := protodesc.NewFiles(&fds)
+	if err != nil {
+		return fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+
+	protoMu.Lock()
+	defer protoMu.Unlock()
+
+	var regErr error
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		// Files already loaded from another set are skipped
+		if _, err := protoFiles.FindFileByPath(fd.Path()); err == nil {
+			return true
+		}
+		if err := protoFiles.RegisterFile(fd); err != nil {
+			regErr = err
+			return false
+		}
+		return true
+	})
+	return regErr
+}
+
+// ProtoMessages returns the full names of all loaded message types, sorted.
+func ProtoMessages() []string {
+	protoMu.RLock()
+	defer protoMu.RUnlock()
+
+	var names []string
+	protoFiles.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		collectMessages(fd.Messages(), &names)
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
+func collectMessages(msgs protoreflect.MessageDescriptors, names *[]string) {
+	for i := 0; i < msgs.Len(); i++ {
+		md := msgs.Get(i)
+		if md.IsMapEntry() {
+			continue
+		}
+		*names = append(*names, string(md.FullName()))
+		collectMessages(md.Messages(), names)
+	}
+}
+
+func protoMessageCodec(name string) (Codec, bool) {
+	protoMu.RLock()
+	defer protoMu.RUnlock()
+
+	desc, err := protoFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, false
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, false
+	}
+	return protoMessage{md: md}, true
+}
+
+// --- protobuf:<message> ---
+
+type protoMessage struct {
+	md protoreflect.MessageDescriptor
+}
+
+func (c protoMessage) Name() string { return ProtobufPrefix + string(c.md.FullName()) }
+
+func (c protoMessage) Decode(data []byte) (string, error) {
+	msg := dynamicpb.NewMessage(c.md)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return "", err
+	}
+	protoMu.RLock()
+	defer protoMu.RUnlock()
+	opts := protojson.MarshalOptions{Resolver: dynamicResolver{}}
+	out, err := opts.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	// protojson deliberately randomizes whitespace; re-indent for stable output
+	return jsonCodec{}.Decode(out)
+}
+
+// EditText returns the message in protobuf text format.
+func (c protoMessage) EditText(data []byte) (string, error) {
+	msg := dynamicpb.NewMessage(c.md)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return "", err
+	}
+	protoMu.RLock()
+	defer protoMu.RUnlock()
+	opts := prototext.MarshalOptions{Multiline: true, Indent: "  ", Resolver: dynamicResolver{}}
+	out, err := opts.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func (c protoMessage) Encode(text string, _ []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(c.md)
+	protoMu.RLock()
+	opts := prototext.UnmarshalOptions{Resolver: dynamicResolver{}}
+	err := opts.Unmarshal([]byte(text), msg)
+	protoMu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+// dynamicResolver resolves google.protobuf.Any payloads against the loaded descriptor sets.
+type dynamicResolver struct{}
+
+func (dynamicResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	desc, err := protoFiles.FindDescriptorByName(name)
+	if err != nil {
+		return protoregistry.GlobalTypes.FindMessageByName(name)
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, protoregistry.NotFound
+	}
+	return dynamicpb.NewMessageType(md), nil
+}
+
+func (r dynamicResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	name := url
+	if i := strings.LastIndexByte(url, '/'); i >= 0 {
+		name = url[i+1:]
+	}
+	return r.FindMessageByName(protoreflect.FullName(name))
+}
+
+func (dynamicResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+func (dynamicResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
+
+// --- protobuf (schema-less) ---
+
+// protoRawCodec dumps the wire format by field number when no message type is known.
+type protoRawCodec struct{}
+
+func (protoRawCodec) Name() string { return "protobuf" }
+
+func (protoRawCodec) Decode(data []byte) (string, error) {
+	var sb strings.Builder
+	if err := dumpWire(&sb, data, 0); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func dumpWire(sb *strings.Builder, data []byte, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fmt.Fprintf(sb, "%s%d: %d\n", indent, num, v)
+			data = data[n:]
+		case protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fmt.Fprintf(sb, "%s%d: 0x%08x\n", indent, num, v)
+			data = data[n:]
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			fmt.Fprintf(sb, "%s%d: 0x%016x\n", indent, num, v)
+			data = data[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+
+			// Length-delimited fields may be strings, nested messages or raw bytes.
+			// Printable text is checked first since short strings often parse as valid wire data.
+			var nested strings.Builder
+			if isPrintable(v) {
+				fmt.Fprintf(sb, "%s%d: %q\n", indent, num, v)
+			} else if dumpWire(&nested, v, depth+1) == nil {
+				fmt.Fprintf(sb, "%s%d: {\n%s%s}\n", indent, num, nested.String(), indent)
+			} else {
+				fmt.Fprintf(sb, "%s%d: 0x%x\n", indent, num, v)
+			}
+		default:
+			return fmt.Errorf("unsupported wire type %d", typ)
+		}
+	}
+	return nil
+}
+
+func isPrintable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
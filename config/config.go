@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 )
 
@@ -16,11 +18,19 @@ type Config struct {
 	Search       SearchConfig `json:"search"`
 	UI           UIConfig     `json:"ui"`
 	DB           DBConfig     `json:"db"`
+	Codec        CodecConfig  `json:"codec"`
+	API          APIConfig    `json:"api"`
+	Queries      QueryConfig  `json:"queries"`
+	Bookmarks    Bookmarks    `json:"bookmarks"`
 	RecentDBs    []string     `json:"recent_dbs"`
 	Localization string       `json:"localization"`
 
 	configPath string
 	mu         sync.RWMutex
+
+	// Compiled codec rule patterns, keyed by pattern text
+	ruleCache map[string]*regexp.Regexp
+	ruleMu    sync.Mutex
 }
 
 type SearchConfig struct {
@@ -30,10 +40,14 @@ type SearchConfig struct {
 }
 
 type UIConfig struct {
-	PreviewChars  int `json:"preview_chars"`
-	ValuePageSize int `json:"value_page_size"`
+	PreviewChars  int      `json:"preview_chars"`
+	ValuePageSize int      `json:"value_page_size"`
+	Columns       []string `json:"columns"` // Visible key table columns, in order; see TableColumns
 }
 
+// TableColumns lists the columns the key table can show.
+var TableColumns = []string{"key", "preview", "size", "expires", "version", "meta", "codec"}
+
 type DBConfig struct {
 	OpenBatchSize     int    `json:"open_batch_size"`
 	AutoBackupOnWrite bool   `json:"auto_backup_on_write"`
@@ -41,6 +55,59 @@ type DBConfig struct {
 	BackupPath        string `json:"backup_path"`
 }
 
+// APIConfig tunes the subprocess RPC mode.
+type APIConfig struct {
+	Workers      int  `json:"workers"`        // Requests executed concurrently
+	QueueSize    int  `json:"queue_size"`     // Requests accepted beyond Workers before reading pauses
+	MaxFrameSize int  `json:"max_frame_size"` // Largest request line or frame in bytes
+	JSONRPC      bool `json:"jsonrpc"`        // Speak JSON-RPC 2.0 instead of the native format
+}
+
+// QueryConfig stores searches for reuse across sessions.
+type QueryConfig struct {
+	History map[string][]string `json:"history"` // DB path -> search terms, most recent first
+	Saved   []SavedQuery        `json:"saved"`
+}
+
+// SavedQuery is a named search shared by every DB.
+type SavedQuery struct {
+	Name       string `json:"name"`
+	Term       string `json:"term"`
+	Mode       string `json:"mode"` // "prefix" | "substring" | "regex"
+	SortDesc   bool   `json:"sort_desc"`
+	IgnoreCase bool   `json:"ignore_case"`
+	Codec      string `json:"codec,omitempty"` // Only keys whose codec rule selects this codec
+}
+
+// HistoryLimit is the number of search terms kept per DB.
+const HistoryLimit = 50
+
+// Bookmarks maps a DB path to its pinned keys, in the order they were pinned.
+type Bookmarks map[string][]string
+
+type CodecConfig struct {
+	DefaultCodec        string      `json:"default_codec"`         // "auto" or a codec name, e.g. "json"
+	DescriptorSets      []string    `json:"descriptor_sets"`       // Protobuf FileDescriptorSet files (.pb)
+	Rules               []CodecRule `json:"rules"`                 // Evaluated in order, first match wins
+	MaxDecompressedSize int64       `json:"max_decompressed_size"` // Largest value gzip, zstd or snappy may expand to, in bytes
+}
+
+// CodecRule maps keys matching Pattern to a codec.
+type CodecRule struct {
+	Pattern string `json:"pattern"`
+	Regex   bool   `json:"regex"` // Pattern is a regular expression instead of a glob
+	Codec   string `json:"codec"`
+	Message string `json:"message,omitempty"` // Protobuf message type when Codec is "protobuf"
+}
+
+// CodecName returns the codec registry name for the rule, e.g. "protobuf:acme.v1.User".
+func (r CodecRule) CodecName() string {
+	if r.Codec == "protobuf" && r.Message != "" {
+		return "protobuf:" + r.Message
+	}
+	return r.Codec
+}
+
 // DefaultConfig returns the default configuration.
 func DefaultConfig() *Config {
 	return &Config{
@@ -56,6 +123,7 @@ func DefaultConfig() *Config {
 		UI: UIConfig{
 			PreviewChars:  100,
 			ValuePageSize: 4096,
+			Columns:       []string{"key", "preview", "size", "expires"},
 		},
 		DB: DBConfig{
 			OpenBatchSize:     200,
@@ -63,6 +131,22 @@ func DefaultConfig() *Config {
 			BackupRetention:   3,
 			BackupPath:        "./backups",
 		},
+		Codec: CodecConfig{
+			DefaultCodec:        "auto",
+			DescriptorSets:      []string{},
+			Rules:               []CodecRule{},
+			MaxDecompressedSize: 256 << 20,
+		},
+		API: APIConfig{
+			Workers:      8,
+			QueueSize:    64,
+			MaxFrameSize: 16 << 20,
+		},
+		Queries: QueryConfig{
+			History: map[string][]string{},
+			Saved:   []SavedQuery{},
+		},
+		Bookmarks:    Bookmarks{},
 		RecentDBs:    []string{},
 		Localization: "en",
 	}
@@ -148,3 +232,213 @@ func (c *Config) GetRecentDBs() []string {
 	copy(result, c.RecentDBs)
 	return result
 }
+
+// GetCodecRules returns a copy of the codec rules.
+func (c *Config) GetCodecRules() []CodecRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]CodecRule, len(c.Codec.Rules))
+	copy(result, c.Codec.Rules)
+	return result
+}
+
+// SetCodecRules replaces the codec rules.
+func (c *Config) SetCodecRules(rules []CodecRule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Codec.Rules = rules
+}
+
+// CodecFor returns the codec name of the first rule matching key, or "" if none matches.
+// Rules with invalid patterns are skipped.
+func (c *Config) CodecFor(key string) string {
+	for _, r := range c.GetCodecRules() {
+		re := c.compileRule(r)
+		if re != nil && re.MatchString(key) {
+			return r.CodecName()
+		}
+	}
+	return ""
+}
+
+func (c *Config) compileRule(r CodecRule) *regexp.Regexp {
+	expr := r.Pattern
+	if !r.Regex {
+		expr = globToRegexp(r.Pattern)
+	}
+
+	c.ruleMu.Lock()
+	defer c.ruleMu.Unlock()
+
+	if c.ruleCache == nil {
+		c.ruleCache = make(map[string]*regexp.Regexp)
+	}
+	if re, ok := c.ruleCache[expr]; ok {
+		return re
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		re = nil // Cached as nil so the error is not retried on every key
+	}
+	c.ruleCache[expr] = re
+	return re
+}
+
+// globToRegexp converts a key glob to an anchored regular expression.
+// Unlike path.Match, "*" also matches separators such as "/" and ":".
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// AddSearchHistory records term as the most recent search of the DB at dbPath.
+func (c *Config) AddSearchHistory(dbPath, term string) {
+	if term == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	history := []string{term}
+	for _, t := range c.Queries.History[dbPath] {
+		if t != term {
+			history = append(history, t)
+		}
+	}
+	if len(history) > HistoryLimit {
+		history = history[:HistoryLimit]
+	}
+	if c.Queries.History == nil {
+		c.Queries.History = make(map[string][]string)
+	}
+	c.Queries.History[dbPath] = history
+}
+
+// GetSearchHistory returns a copy of the search history of a DB, most recent first.
+func (c *Config) GetSearchHistory(dbPath string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]string, len(c.Queries.History[dbPath]))
+	copy(result, c.Queries.History[dbPath])
+	return result
+}
+
+// GetSavedQueries returns a copy of the saved queries.
+func (c *Config) GetSavedQueries() []SavedQuery {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]SavedQuery, len(c.Queries.Saved))
+	copy(result, c.Queries.Saved)
+	return result
+}
+
+// SaveQuery adds q, replacing a saved query with the same name.
+func (c *Config) SaveQuery(q SavedQuery) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, saved := range c.Queries.Saved {
+		if saved.Name == q.Name {
+			c.Queries.Saved[i] = q
+			return
+		}
+	}
+	c.Queries.Saved = append(c.Queries.Saved, q)
+}
+
+// DeleteSavedQuery removes the saved query with the given name and reports whether it existed.
+func (c *Config) DeleteSavedQuery(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, saved := range c.Queries.Saved {
+		if saved.Name == name {
+			c.Queries.Saved = append(c.Queries.Saved[:i], c.Queries.Saved[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// GetBookmarks returns a copy of the pinned keys of the DB at dbPath.
+func (c *Config) GetBookmarks(dbPath string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]string, len(c.Bookmarks[dbPath]))
+	copy(result, c.Bookmarks[dbPath])
+	return result
+}
+
+// IsBookmarked reports whether key is pinned in the DB at dbPath.
+func (c *Config) IsBookmarked(dbPath, key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, k := range c.Bookmarks[dbPath] {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// AddBookmark pins key in the DB at dbPath and reports whether it was added.
+func (c *Config) AddBookmark(dbPath, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, k := range c.Bookmarks[dbPath] {
+		if k == key {
+			return false
+		}
+	}
+	if c.Bookmarks == nil {
+		c.Bookmarks = make(Bookmarks)
+	}
+	c.Bookmarks[dbPath] = append(c.Bookmarks[dbPath], key)
+	return true
+}
+
+// RemoveBookmark unpins key in the DB at dbPath and reports whether it was pinned.
+func (c *Config) RemoveBookmark(dbPath, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.Bookmarks[dbPath]
+	for i, k := range keys {
+		if k == key {
+			keys = append(keys[:i:i], keys[i+1:]...)
+			if len(keys) == 0 {
+				delete(c.Bookmarks, dbPath)
+			} else {
+				c.Bookmarks[dbPath] = keys
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// ToggleBookmark pins or unpins key and reports whether it is now pinned.
+func (c *Config) ToggleBookmark(dbPath, key string) bool {
+	if c.RemoveBookmark(dbPath, key) {
+		return false
+	}
+	return c.AddBookmark(dbPath, key)
+}
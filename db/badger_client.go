@@ -1,12 +1,15 @@
 package db
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 	"sync"
 
+	"badger_explorer_core/codec"
+
 	badger "github.com/dgraph-io/badger/v4"
 )
 
@@ -15,6 +18,10 @@ type DBClient struct {
 	path string
 	db   *badger.DB
 	mu   sync.Mutex
+
+	// CountKeys results, valid while the DB is at countVersion
+	counts       map[countFilter]KeyCount
+	countVersion uint64
 }
 
 // NewDBClient creates a new DBClient instance.
@@ -25,25 +32,39 @@ func NewDBClient() *DBClient {
 // Open opens the BadgerDB at the specified path.
 // Always opens in Read-Write mode for Windows compatibility.
 func (c *DBClient) Open(path string) error {
+	return c.open(path, false)
+}
+
+// OpenReadOnly opens the BadgerDB without write access, so it can be read while
+// another process holds it open read-only. Writes fail with ErrReadOnly.
+// Badger does not support read-only mode on Windows.
+func (c *DBClient) OpenReadOnly(path string) error {
+	return c.open(path, true)
+}
+
+func (c *DBClient) open(path string, readOnly bool) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if c.db != nil {
-		return fmt.Errorf("database is already open")
+		return ErrAlreadyOpen
 	}
 
 	opts := badger.DefaultOptions(path)
-	opts.ReadOnly = false // Always RW
+	opts.ReadOnly = readOnly
 	// Turn off logging for cleaner output
 	opts.Logger = nil
 
 	// Check if directory exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return fmt.Errorf("directory does not exist: %s", path)
+		return &Error{Kind: ErrPathNotFound, Path: path}
 	}
 
 	db, err := badger.Open(opts)
 	if err != nil {
+		if err := wrapOpenErr(err, path, readOnly); errors.Is(err, ErrLocked) {
+			return err
+		}
 		return fmt.Errorf("failed to open badger db: %w", err)
 	}
 
@@ -64,6 +85,7 @@ func (c *DBClient) Close() error {
 	err := c.db.Close()
 	c.db = nil
 	c.path = ""
+	c.counts = nil
 	return err
 }
 
@@ -87,6 +109,9 @@ type KeyItem struct {
 	ValuePreview string
 	Size         int64
 	ExpiresAt    uint64 // Timestamp
+	Codec        string // Codec used for the preview, empty for raw text
+	Version      uint64 // Version of the last write
+	UserMeta     byte
 }
 
 // ListKeysOptions defines options for listing keys.
@@ -98,8 +123,45 @@ type ListKeysOptions struct {
 	Offset       int    // 건너뛸 항목 수 (KV 저장소에서는 비효율적이지만, 간단한 페이지네이션 로직을 위해 필요함)
 	StartKey     string // KV 저장소 페이지네이션에 더 효율적인 방식
 	PreviewChars int
+
+	// PreviewCodec returns the codec to render a key's preview with, or "" for raw text.
+	// Used to apply the key-pattern codec rules from the config.
+	PreviewCodec func(key string) string
+
+	IgnoreCase  bool   // Match Prefix case-insensitively in every mode
+	CodecFilter string // Only keys whose PreviewCodec is this codec
 }
 
+// normalized rewrites a case-insensitive search as a regex search, which
+// scans every key the way a case-insensitive prefix search has to.
+func (opts ListKeysOptions) normalized() ListKeysOptions {
+	if !opts.IgnoreCase {
+		return opts
+	}
+	switch opts.Mode {
+	case "substring":
+		opts.Prefix = "(?i)" + regexp.QuoteMeta(opts.Prefix)
+	case "regex":
+		opts.Prefix = "(?i)" + opts.Prefix
+	default:
+		opts.Prefix = "(?i)^" + regexp.QuoteMeta(opts.Prefix)
+	}
+	opts.Mode = "regex"
+	opts.IgnoreCase = false
+	return opts
+}
+
+// codecMatch reports whether key passes the CodecFilter of opts.
+func (opts ListKeysOptions) codecMatch(key string) bool {
+	if opts.CodecFilter == "" {
+		return true
+	}
+	return opts.PreviewCodec != nil && opts.PreviewCodec(key) == opts.CodecFilter
+}
+
+// maxPrefetch caps the values ListKeys prefetches, so large limits do not load everything at once.
+const maxPrefetch = 1000
+
 // ListKeys lists keys based on the options.
 func (c *DBClient) ListKeys(opts ListKeysOptions) ([]KeyItem, bool, error) {
 	c.mu.Lock()
@@ -107,8 +169,9 @@ func (c *DBClient) ListKeys(opts ListKeysOptions) ([]KeyItem, bool, error) {
 	c.mu.Unlock()
 
 	if db == nil {
-		return nil, false, fmt.Errorf("database not open")
+		return nil, false, ErrNotOpen
 	}
+	opts = opts.normalized()
 
 	var items []KeyItem
 	var hasMore bool
@@ -116,7 +179,7 @@ func (c *DBClient) ListKeys(opts ListKeysOptions) ([]KeyItem, bool, error) {
 	err := db.View(func(txn *badger.Txn) error {
 		itOpts := badger.DefaultIteratorOptions
 		itOpts.PrefetchValues = true // We need values for preview
-		itOpts.PrefetchSize = opts.Limit
+		itOpts.PrefetchSize = min(opts.Limit, maxPrefetch)
 		itOpts.Reverse = opts.SortDesc
 
 		it := txn.NewIterator(itOpts)
@@ -149,7 +212,7 @@ func (c *DBClient) ListKeys(opts ListKeysOptions) ([]KeyItem, bool, error) {
 		if opts.Mode == "regex" && opts.Prefix != "" {
 			re, err = regexp.Compile(opts.Prefix)
 			if err != nil {
-				return fmt.Errorf("invalid regex: %w", err)
+				return regexErr(opts.Prefix, err)
 			}
 		}
 
@@ -204,6 +267,10 @@ func (c *DBClient) ListKeys(opts ListKeysOptions) ([]KeyItem, bool, error) {
 				}
 			}
 
+			if match && !opts.codecMatch(keyStr) {
+				match = false
+			}
+
 			if match {
 				// Offset 처리 (건너뛰기)
 				// 참고: 깊은 페이지에서는 비효율적이지만, 작은 배치의 TUI 사용에는 허용됨.
@@ -217,34 +284,11 @@ func (c *DBClient) ListKeys(opts ListKeysOptions) ([]KeyItem, bool, error) {
 				// 하지만 페이징 중이라면 호출자가 *다음* 키를 전달하거나 우리가 처리해야 함.
 				// StartKey는 포함된다고 가정함.
 
-				valCopy, err := item.ValueCopy(nil)
+				k, err := keyItem(item, opts)
 				if err != nil {
 					continue
 				}
-
-				// Preview
-				previewLen := opts.PreviewChars
-				if previewLen <= 0 {
-					previewLen = 100
-				}
-				preview := ""
-				if len(valCopy) > previewLen {
-					preview = string(valCopy[:previewLen]) + "..."
-				} else {
-					preview = string(valCopy)
-				}
-
-				// Check for binary
-				if isBinary(valCopy) {
-					preview = fmt.Sprintf("[Binary %d bytes]", len(valCopy))
-				}
-
-				items = append(items, KeyItem{
-					Key:          keyStr,
-					ValuePreview: preview,
-					Size:         item.ValueSize(),
-					ExpiresAt:    item.ExpiresAt(),
-				})
+				items = append(items, k)
 
 				count++
 				if count >= opts.Limit {
@@ -270,6 +314,40 @@ func (c *DBClient) ListKeys(opts ListKeysOptions) ([]KeyItem, bool, error) {
 	return items, hasMore, nil
 }
 
+// makePreview renders the list preview of a value and returns the codec it
+// was decoded with, or "" for raw text.
+func makePreview(key string, val []byte, opts ListKeysOptions) (string, string) {
+	previewLen := opts.PreviewChars
+	if previewLen <= 0 {
+		previewLen = 100
+	}
+	preview := ""
+	if len(val) > previewLen {
+		preview = string(val[:previewLen]) + "..."
+	} else {
+		preview = string(val)
+	}
+
+	// Check for binary
+	if isBinary(val) {
+		preview = fmt.Sprintf("[Binary %d bytes]", len(val))
+	}
+
+	// 규칙에 맞는 코덱이 있으면 디코딩된 텍스트로 미리보기
+	codecName := ""
+	if opts.PreviewCodec != nil {
+		codecName = opts.PreviewCodec(key)
+	}
+	if codecName != "" {
+		if d, err := codec.Decode(codecName, val); err == nil {
+			preview = previewText(d.Text, previewLen)
+		} else {
+			codecName = ""
+		}
+	}
+	return preview, codecName
+}
+
 // isBinary checks if the data seems to be binary.
 // Simple heuristic: looks for null bytes or non-printable chars.
 func isBinary(data []byte) bool {
@@ -285,3 +363,61 @@ func isBinary(data []byte) bool {
 	}
 	return false
 }
+
+// previewText collapses decoded text onto one line and truncates it to n runes.
+func previewText(text string, n int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) > n {
+		return string(runes[:n]) + "..."
+	}
+	return string(runes)
+}
+
+// matchFunc returns a predicate that applies the search mode of opts to a key.
+// It mirrors the filter logic in ListKeys so other scans (diff, count) agree with the list view.
+// Callers must pass normalized options.
+func matchFunc(opts ListKeysOptions) (func(key string) bool, error) {
+	var match func(key string) bool
+	switch opts.Mode {
+	case "substring":
+		match = func(key string) bool { return strings.Contains(key, opts.Prefix) }
+	case "regex":
+		if opts.Prefix == "" {
+			match = func(string) bool { return true } // Empty regex matches all
+			break
+		}
+		re, err := regexp.Compile(opts.Prefix)
+		if err != nil {
+			return nil, regexErr(opts.Prefix, err)
+		}
+		match = re.MatchString
+	default:
+		match = func(key string) bool { return strings.HasPrefix(key, opts.Prefix) }
+	}
+	if opts.CodecFilter == "" {
+		return match, nil
+	}
+	return func(key string) bool { return match(key) && opts.codecMatch(key) }, nil
+}
+
+// Matcher returns the predicate ListKeys uses to filter keys for these options.
+func (opts ListKeysOptions) Matcher() (func(key string) bool, error) {
+	return matchFunc(opts.normalized())
+}
+
+// seekKey returns where an ascending scan for opts should start.
+// Only prefix mode can skip ahead; the other modes must scan from the beginning.
+func seekKey(opts ListKeysOptions) []byte {
+	if opts.Mode == "substring" || opts.Mode == "regex" {
+		return []byte{}
+	}
+	return []byte(opts.Prefix)
+}
+
+// pastPrefix reports whether an ascending prefix scan has moved beyond every matching key.
+func pastPrefix(opts ListKeysOptions, key string) bool {
+	if opts.Mode == "substring" || opts.Mode == "regex" || opts.Prefix == "" {
+		return false
+	}
+	return key > opts.Prefix && !strings.HasPrefix(key, opts.Prefix)
+}
@@ -0,0 +1,274 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// BulkResult summarises an operation on a set of keys.
+type BulkResult struct {
+	Done    int      `json:"done"`              // Keys written, deleted or exported
+	Skipped []string `json:"skipped,omitempty"` // Keys left untouched, e.g. missing ones
+}
+
+// ExportEntry is one line of the JSON Lines written by ExportKeys.
+type ExportEntry struct {
+	Key       string `json:"key"`
+	Value     string `json:"value"` // Base64 encoded
+	ExpiresAt uint64 `json:"expires_at,omitempty"`
+	UserMeta  byte   `json:"user_meta,omitempty"`
+}
+
+// MatchingKeys returns up to limit keys matching the search options of opts,
+// in ascending order, and whether more keys matched.
+func (c *DBClient) MatchingKeys(ctx context.Context, opts ListKeysOptions, limit int) ([]string, bool, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return nil, false, ErrNotOpen
+	}
+
+	opts = opts.normalized()
+	match, err := matchFunc(opts)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var keys []string
+	more := false
+	err = db.View(func(txn *badger.Txn) error {
+		itOpts := badger.DefaultIteratorOptions
+		itOpts.PrefetchValues = false
+		it := txn.NewIterator(itOpts)
+		defer it.Close()
+
+		for it.Seek(seekKey(opts)); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			key := string(it.Item().Key())
+			if pastPrefix(opts, key) {
+				break
+			}
+			if !match(key) {
+				continue
+			}
+			if len(keys) == limit {
+				more = true
+				break
+			}
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, wrapErr(err, "")
+	}
+	return keys, more, nil
+}
+
+// DeleteKeys deletes keys in a single batch. Missing keys are skipped.
+func (c *DBClient) DeleteKeys(keys []string) (BulkResult, error) {
+	return c.bulkWrite(keys, func(wb *badger.WriteBatch, item *badger.Item) error {
+		return wb.Delete(item.KeyCopy(nil))
+	})
+}
+
+// SetTTLKeys rewrites keys with a TTL in seconds, keeping their values and
+// user meta, in a single batch. A ttl <= 0 removes the expiry. Missing keys are skipped.
+func (c *DBClient) SetTTLKeys(keys []string, ttl int) (BulkResult, error) {
+	return c.bulkWrite(keys, func(wb *badger.WriteBatch, item *badger.Item) error {
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		e := badger.NewEntry(item.KeyCopy(nil), val).WithMeta(item.UserMeta())
+		if ttl > 0 {
+			e.WithTTL(time.Duration(ttl) * time.Second)
+		}
+		return wb.SetEntry(e)
+	})
+}
+
+// RewritePrefix moves keys starting with from to the same key with the
+// prefix to, keeping value, TTL and user meta, in a single batch. Keys
+// without the prefix, missing keys and keys whose new name already exists
+// are skipped; a new name may only replace a key of keys that is moved
+// itself. The new entries are written before the old keys are deleted, so
+// a batch that fails part-way leaves copies behind rather than losing values.
+func (c *DBClient) RewritePrefix(keys []string, from, to string) (BulkResult, error) {
+	var result BulkResult
+	err := c.batch(func(txn *badger.Txn, wb *badger.WriteBatch) error {
+		// Targets of the keys that can move, and whether the target exists
+		targets := make(map[string]string, len(keys))
+		taken := make(map[string]bool, len(keys))
+		items := make(map[string]*badger.Item, len(keys))
+		for _, key := range keys {
+			rest, ok := strings.CutPrefix(key, from)
+			if !ok || from == to {
+				continue
+			}
+			item, err := txn.Get([]byte(key))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			newKey := to + rest
+			if err := checkSize(newKey, nil, 0); err != nil {
+				return err
+			}
+			exists, err := keyExists(txn, newKey)
+			if err != nil {
+				return err
+			}
+			targets[key], taken[key], items[key] = newKey, exists, item
+		}
+
+		// A taken target is free only if the key there moves away; skipping
+		// one key can block the key moving onto it, so repeat until stable
+		moves := make(map[string]bool, len(targets))
+		for key := range targets {
+			moves[key] = true
+		}
+		for changed := true; changed; {
+			changed = false
+			for key, newKey := range targets {
+				if moves[key] && taken[key] && !moves[newKey] {
+					moves[key] = false
+					changed = true
+				}
+			}
+		}
+
+		var moved []string
+		newKeys := make(map[string]bool, len(targets))
+		for _, key := range keys {
+			if !moves[key] {
+				result.Skipped = append(result.Skipped, key)
+				continue
+			}
+			item := items[key]
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			e := badger.NewEntry([]byte(targets[key]), val).WithMeta(item.UserMeta())
+			e.ExpiresAt = item.ExpiresAt()
+			if err := wb.SetEntry(e); err != nil {
+				return err
+			}
+			moved = append(moved, key)
+			newKeys[targets[key]] = true
+		}
+		// A key that another key moved onto keeps its new value
+		for _, key := range moved {
+			if newKeys[key] {
+				continue
+			}
+			if err := wb.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		result.Done = len(moved)
+		return nil
+	})
+	return result, err
+}
+
+// ExportKeys writes keys as JSON Lines of ExportEntry. Missing keys are skipped.
+func (c *DBClient) ExportKeys(w io.Writer, keys []string) (BulkResult, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return BulkResult{}, ErrNotOpen
+	}
+
+	var result BulkResult
+	enc := json.NewEncoder(w)
+	err := db.View(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			item, err := txn.Get([]byte(key))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				result.Skipped = append(result.Skipped, key)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			err = enc.Encode(ExportEntry{
+				Key:       key,
+				Value:     base64.StdEncoding.EncodeToString(val),
+				ExpiresAt: item.ExpiresAt(),
+				UserMeta:  item.UserMeta(),
+			})
+			if err != nil {
+				return err
+			}
+			result.Done++
+		}
+		return nil
+	})
+	if err != nil {
+		return BulkResult{}, wrapErr(err, "")
+	}
+	return result, nil
+}
+
+// bulkWrite applies write to every existing key of keys in a single batch.
+func (c *DBClient) bulkWrite(keys []string, write func(wb *badger.WriteBatch, item *badger.Item) error) (BulkResult, error) {
+	var result BulkResult
+	err := c.batch(func(txn *badger.Txn, wb *badger.WriteBatch) error {
+		for _, key := range keys {
+			item, err := txn.Get([]byte(key))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				result.Skipped = append(result.Skipped, key)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := write(wb, item); err != nil {
+				return err
+			}
+			result.Done++
+		}
+		return nil
+	})
+	return result, err
+}
+
+// batch runs fn with a read snapshot and a WriteBatch that is flushed when fn succeeds.
+func (c *DBClient) batch(fn func(txn *badger.Txn, wb *badger.WriteBatch) error) error {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return ErrNotOpen
+	}
+
+	wb := db.NewWriteBatch()
+	defer wb.Cancel()
+
+	if err := db.View(func(txn *badger.Txn) error { return fn(txn, wb) }); err != nil {
+		return wrapErr(err, "")
+	}
+	return wrapErr(wb.Flush(), "")
+}
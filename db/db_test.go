@@ -1,9 +1,18 @@
 package db
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v4"
 )
 
 func TestDBClient(t *testing.T) {
@@ -77,3 +86,679 @@ func TestDBClient(t *testing.T) {
 	// 	t.Error("Expected error after deletion, got nil")
 	// }
 }
+
+func TestDiffAndSync(t *testing.T) {
+	dirA, err := os.MkdirTemp("", "badger-diff-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dirA)
+	dirB, err := os.MkdirTemp("", "badger-diff-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dirB)
+
+	a := NewDBClient()
+	if err := a.Open(dirA); err != nil {
+		t.Fatalf("Failed to open DB A: %v", err)
+	}
+	defer a.Close()
+	b := NewDBClient()
+	if err := b.Open(dirB); err != nil {
+		t.Fatalf("Failed to open DB B: %v", err)
+	}
+	defer b.Close()
+
+	a.SetValue("k:same", []byte("v"), 0)
+	b.SetValue("k:same", []byte("v"), 0)
+	a.SetValue("k:changed", []byte("one"), 0)
+	b.SetValue("k:changed", []byte("two"), 0)
+	a.SetValue("k:only-a", []byte("a"), 0)
+	b.SetValue("k:only-b", []byte("b"), 0)
+	b.SetValue("other", []byte("ignored by prefix"), 0)
+
+	var got []DiffEntry
+	opts := ListKeysOptions{Prefix: "k:", Mode: "prefix"}
+	err = Diff(a, b, opts, func(e DiffEntry) error {
+		got = append(got, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	want := map[string]string{
+		"k:changed": DiffChanged,
+		"k:only-a":  DiffRemoved,
+		"k:only-b":  DiffAdded,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d entries, got %v", len(want), got)
+	}
+	for _, e := range got {
+		if want[e.Key] != e.Op {
+			t.Errorf("Key %s: expected op %s, got %s", e.Key, want[e.Key], e.Op)
+		}
+	}
+
+	// Make B match A
+	keys := make([]string, len(got))
+	for i, e := range got {
+		keys[i] = e.Key
+	}
+	if _, err := SyncKeys(a, b, keys); err != nil {
+		t.Fatalf("SyncKeys failed: %v", err)
+	}
+
+	got = nil
+	Diff(a, b, opts, func(e DiffEntry) error {
+		got = append(got, e)
+		return nil
+	})
+	if len(got) != 0 {
+		t.Errorf("Expected no differences after sync, got %v", got)
+	}
+}
+
+func TestGetValueRange(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-range-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatalf("Failed to open DB: %v", err)
+	}
+	defer client.Close()
+
+	client.SetValue("big", []byte("0123456789"), 0)
+
+	page, total, err := client.GetValueRange("big", 4, 3)
+	if err != nil {
+		t.Fatalf("GetValueRange failed: %v", err)
+	}
+	if string(page) != "456" || total != 10 {
+		t.Errorf("Expected 456 of 10, got %q of %d", page, total)
+	}
+
+	// Past the end and open-ended ranges
+	page, _, _ = client.GetValueRange("big", 8, 5)
+	if string(page) != "89" {
+		t.Errorf("Expected 89, got %q", page)
+	}
+	page, _, _ = client.GetValueRange("big", 20, 5)
+	if len(page) != 0 {
+		t.Errorf("Expected empty page, got %q", page)
+	}
+
+	var chunks []string
+	total, err = client.ReadValueChunks("big", 4, func(offset int64, chunk []byte) error {
+		chunks = append(chunks, string(chunk))
+		return nil
+	})
+	if err != nil || total != 10 {
+		t.Fatalf("ReadValueChunks failed: %v (total %d)", err, total)
+	}
+	if strings.Join(chunks, "|") != "0123|4567|89" {
+		t.Errorf("Unexpected chunks: %v", chunks)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-errors-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := NewDBClient()
+	if _, err := client.GetValue("k"); !errors.Is(err, ErrNotOpen) {
+		t.Errorf("Expected ErrNotOpen, got %v", err)
+	}
+	if err := client.Open(tmpDir + "/missing"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("Expected ErrPathNotFound, got %v", err)
+	}
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatalf("Failed to open DB: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Open(tmpDir); !errors.Is(err, ErrAlreadyOpen) {
+		t.Errorf("Expected ErrAlreadyOpen, got %v", err)
+	}
+
+	// A second client on the same directory hits the directory lock
+	other := NewDBClient()
+	if err := other.Open(tmpDir); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked, got %v", err)
+		other.Close()
+	}
+	if err := other.OpenReadOnly(tmpDir); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected ErrLocked opening read-only, got %v", err)
+		other.Close()
+	}
+
+	// Badger rejects oversized keys without a sentinel; they are caught first
+	if err := client.SetValue(strings.Repeat("k", maxKeySize+1), []byte("v"), 0); !errors.Is(err, ErrTooBig) {
+		t.Errorf("Expected ErrTooBig for a long key, got %v", err)
+	}
+	if err := checkSize("k", make([]byte, 11), 10); !errors.Is(err, ErrTooBig) {
+		t.Errorf("Expected ErrTooBig for a large value, got %v", err)
+	}
+	if err := checkSize(strings.Repeat("k", maxKeySize), make([]byte, 10), 10); err != nil {
+		t.Errorf("Expected sizes at the limit to pass, got %v", err)
+	}
+
+	_, err = client.GetValue("missing")
+	var dbErr *Error
+	if !errors.Is(err, ErrKeyNotFound) || !errors.As(err, &dbErr) || dbErr.Key != "missing" {
+		t.Errorf("Expected ErrKeyNotFound for key missing, got %v", err)
+	}
+
+	_, _, err = client.ListKeys(ListKeysOptions{Prefix: "(", Mode: "regex", Limit: 10})
+	if !errors.Is(err, ErrInvalidRegex) {
+		t.Errorf("Expected ErrInvalidRegex, got %v", err)
+	}
+}
+
+func TestWrapErr(t *testing.T) {
+	other := errors.New("other")
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{badger.ErrKeyNotFound, ErrKeyNotFound},
+		{badger.ErrDBClosed, ErrNotOpen},
+		{badger.ErrConflict, ErrConflict},
+		{badger.ErrReadOnlyTxn, ErrReadOnly},
+		{badger.ErrBlockedWrites, ErrReadOnly},
+		{badger.ErrTxnTooBig, ErrTooBig},
+		{fmt.Errorf("batch: %w", badger.ErrTxnTooBig), ErrTooBig},
+		{other, other},
+	}
+	for _, tt := range tests {
+		err := wrapErr(tt.err, "k")
+		if !errors.Is(err, tt.want) {
+			t.Errorf("wrapErr(%v) = %v, want %v", tt.err, err, tt.want)
+		}
+	}
+	if wrapErr(nil, "k") != nil {
+		t.Error("wrapErr(nil) should be nil")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-subscribe-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatalf("Failed to open DB: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan KeyEvent, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Subscribe(ctx, []string{"w:"}, func(evs []KeyEvent) error {
+			for _, e := range evs {
+				events <- e
+			}
+			return nil
+		})
+	}()
+
+	// The subscription starts asynchronously; write until it sees something
+	ready := false
+	for i := 0; i < 100 && !ready; i++ {
+		client.SetValue("w:ready", []byte("x"), 0)
+		select {
+		case <-events:
+			ready = true
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	if !ready {
+		t.Fatal("Subscription never delivered an event")
+	}
+	for len(events) > 0 {
+		<-events
+	}
+
+	client.SetValue("other", []byte("ignored"), 0)
+	client.SetValue("w:k", []byte("one"), 0)
+	client.SetValue("w:k", []byte("three"), 0)
+	client.DeleteKey("w:k")
+
+	want := []KeyEvent{
+		{Key: "w:k", Op: EventAdded, Size: 3},
+		{Key: "w:k", Op: EventChanged, Size: 5},
+		{Key: "w:k", Op: EventDeleted, Size: 0},
+	}
+	for _, w := range want {
+		select {
+		case e := <-events:
+			if e.Key != w.Key || e.Op != w.Op || e.Size != w.Size {
+				t.Errorf("Expected %+v, got %+v", w, e)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %+v", w)
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Subscribe returned %v after cancel", err)
+	}
+}
+
+func TestKeyInfoCountAndStats(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-stats-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		client.SetValue(fmt.Sprintf("user:%d", i), []byte("12345"), 0)
+	}
+	client.SetValueWithMeta("order:1", []byte("x"), 3600, 7)
+
+	info, err := client.GetKeyInfo("order:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 1 || info.UserMeta != 7 || info.ExpiresAt == 0 || info.Version == 0 {
+		t.Errorf("Unexpected key info: %+v", info)
+	}
+	if _, err := client.GetKeyInfo("missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	ctx := context.Background()
+	counts := []struct {
+		opts ListKeysOptions
+		want int
+	}{
+		{ListKeysOptions{Prefix: "user:", Mode: "prefix"}, 10},
+		{ListKeysOptions{Prefix: ":1", Mode: "substring"}, 2},
+		{ListKeysOptions{Prefix: "^order", Mode: "regex"}, 1},
+		{ListKeysOptions{Prefix: "", Mode: "prefix"}, 11},
+		{ListKeysOptions{Prefix: "nothing", Mode: "prefix"}, 0},
+	}
+	for _, c := range counts {
+		n, err := client.CountKeys(ctx, c.opts)
+		if err != nil || n.Keys != int64(c.want) {
+			t.Errorf("CountKeys(%q, %s) = %d, %v; want %d", c.opts.Prefix, c.opts.Mode, n.Keys, err, c.want)
+		}
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := client.CountKeys(cancelled, ListKeysOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	st, err := client.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Keys != 11 || st.ValueBytes != 51 || st.ReadOnly || st.MaxVersion == 0 {
+		t.Errorf("Unexpected stats: %+v", st)
+	}
+	client.Close()
+
+	// Read-only mode rejects writes
+	if err := client.OpenReadOnly(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.SetValue("k", []byte("v"), 0); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+	if v, err := client.GetValue("user:1"); err != nil || string(v) != "12345" {
+		t.Errorf("Read-only get returned %q, %v", v, err)
+	}
+}
+
+func TestBulkOperations(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-bulk-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	for i := 0; i < 5; i++ {
+		client.SetValueWithMeta(fmt.Sprintf("v1/%d", i), []byte(fmt.Sprintf("val-%d", i)), 3600, 2)
+	}
+	client.SetValue("v2/4", []byte("existing"), 0)
+
+	ctx := context.Background()
+	keys, more, err := client.MatchingKeys(ctx, ListKeysOptions{Prefix: "v1/", Mode: "prefix"}, 3)
+	if err != nil || len(keys) != 3 || !more || keys[0] != "v1/0" {
+		t.Errorf("MatchingKeys = %v, %v, %v", keys, more, err)
+	}
+
+	var buf bytes.Buffer
+	res, err := client.ExportKeys(&buf, []string{"v1/0", "missing"})
+	if err != nil || res.Done != 1 || len(res.Skipped) != 1 {
+		t.Errorf("ExportKeys = %+v, %v", res, err)
+	}
+	var entry ExportEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil || entry.Key != "v1/0" || entry.Value != "dmFsLTA=" || entry.UserMeta != 2 {
+		t.Errorf("Unexpected export %s", buf.String())
+	}
+
+	// v1/4 would overwrite v2/4, which is not part of the move
+	all := []string{"v1/0", "v1/1", "v1/2", "v1/3", "v1/4"}
+	res, err = client.RewritePrefix(all, "v1/", "v2/")
+	if err != nil || res.Done != 4 || len(res.Skipped) != 1 || res.Skipped[0] != "v1/4" {
+		t.Fatalf("RewritePrefix = %+v, %v", res, err)
+	}
+	info, err := client.GetKeyInfo("v2/1")
+	if err != nil || info.UserMeta != 2 || info.ExpiresAt == 0 {
+		t.Errorf("Moved key lost its metadata: %+v, %v", info, err)
+	}
+	if _, err := client.GetKeyInfo("v1/1"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Old key still present: %v", err)
+	}
+	if v, _ := client.GetValue("v2/4"); string(v) != "existing" {
+		t.Errorf("Existing key overwritten with %q", v)
+	}
+
+	// Moving onto a key that moves as well keeps both values
+	client.SetValue("x/1", []byte("one"), 0)
+	client.SetValue("x/x/1", []byte("two"), 0)
+	res, err = client.RewritePrefix([]string{"x/1", "x/x/1"}, "x/", "x/x/")
+	if err != nil || res.Done != 2 {
+		t.Fatalf("RewritePrefix = %+v, %v", res, err)
+	}
+	if v, _ := client.GetValue("x/x/1"); string(v) != "one" {
+		t.Errorf("x/x/1 = %q", v)
+	}
+	if v, _ := client.GetValue("x/x/x/1"); string(v) != "two" {
+		t.Errorf("x/x/x/1 = %q", v)
+	}
+
+	// A skipped key is not overwritten by the key moving onto it
+	client.SetValue("a/1", []byte("one"), 0)
+	client.SetValue("a/a/1", []byte("two"), 0)
+	client.SetValue("a/a/a/1", []byte("three"), 0)
+	res, err = client.RewritePrefix([]string{"a/1", "a/a/1"}, "a/", "a/a/")
+	if err != nil || res.Done != 0 || len(res.Skipped) != 2 {
+		t.Fatalf("RewritePrefix onto a skipped key = %+v, %v", res, err)
+	}
+	for key, want := range map[string]string{"a/1": "one", "a/a/1": "two", "a/a/a/1": "three"} {
+		if v, _ := client.GetValue(key); string(v) != want {
+			t.Errorf("%s = %q, want %q", key, v, want)
+		}
+	}
+	client.DeleteKeys([]string{"a/1", "a/a/1", "a/a/a/1"})
+
+	res, err = client.SetTTLKeys([]string{"v2/0", "v2/1"}, 0)
+	if err != nil || res.Done != 2 {
+		t.Errorf("SetTTLKeys = %+v, %v", res, err)
+	}
+	if info, _ := client.GetKeyInfo("v2/0"); info.ExpiresAt != 0 || info.UserMeta != 2 {
+		t.Errorf("TTL not cleared: %+v", info)
+	}
+
+	res, err = client.DeleteKeys([]string{"v2/0", "v2/1", "missing"})
+	if err != nil || res.Done != 2 || len(res.Skipped) != 1 {
+		t.Errorf("DeleteKeys = %+v, %v", res, err)
+	}
+	if n, _ := client.CountKeys(ctx, ListKeysOptions{Prefix: "v2/", Mode: "prefix"}); n.Keys != 3 {
+		t.Errorf("Expected 3 keys left under v2/, got %d", n.Keys)
+	}
+}
+
+func TestRenameAndCopy(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-rename-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	client.SetValueWithMeta("a", []byte("value"), 3600, 5)
+	client.SetValue("taken", []byte("other"), 0)
+
+	if err := client.CopyKey("a", "b", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.RenameKey("a", "taken", false); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("Expected ErrKeyExists, got %v", err)
+	}
+	if err := client.RenameKey("missing", "c", false); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if err := client.RenameKey("a", "c", false); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"b", "c"} {
+		info, err := client.GetKeyInfo(key)
+		if err != nil || info.UserMeta != 5 || info.ExpiresAt == 0 || info.Size != 5 {
+			t.Errorf("%s: unexpected info %+v, %v", key, info, err)
+		}
+	}
+	if _, err := client.GetKeyInfo("a"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Renamed key still present: %v", err)
+	}
+	if err := client.RenameKey("c", "taken", true); err != nil {
+		t.Errorf("Overwrite failed: %v", err)
+	}
+
+	// Prefix-wide operations span several chunks
+	n := prefixChunkKeys + prefixChunkKeys/2
+	for i := 0; i < n; i++ {
+		client.SetValue(fmt.Sprintf("v1/%05d", i), []byte("x"), 0)
+	}
+	client.SetValue("v2/00000", []byte("kept"), 0)
+
+	ctx := context.Background()
+	if _, err := client.RenamePrefix(ctx, "v1/", "v1/old/", false); err == nil {
+		t.Error("Expected error for a target inside the source prefix")
+	}
+	client.SetValue("v1/a/a/x", []byte("x"), 0)
+	if _, err := client.RenamePrefix(ctx, "v1/a/", "v1/", false); err == nil {
+		t.Error("Expected error for a source inside the target prefix")
+	}
+	if v, err := client.GetValue("v1/a/a/x"); err != nil || string(v) != "x" {
+		t.Errorf("Key moved by a rejected rename: %q, %v", v, err)
+	}
+	client.DeleteKey("v1/a/a/x")
+	res, err := client.CopyPrefix(ctx, "v1/", "backup/", false)
+	if err != nil || res.Done != n {
+		t.Fatalf("CopyPrefix = %d, %v", res.Done, err)
+	}
+	res, err = client.RenamePrefix(ctx, "v1/", "v2/", false)
+	if err != nil || res.Done != n-1 || len(res.Skipped) != 1 || res.Skipped[0] != "v1/00000" {
+		t.Fatalf("RenamePrefix = %d, %v, %v", res.Done, res.Skipped, err)
+	}
+	counts := map[string]int{"v1/": 1, "v2/": n, "backup/": n}
+	for prefix, want := range counts {
+		if got, _ := client.CountKeys(ctx, ListKeysOptions{Prefix: prefix, Mode: "prefix"}); got.Keys != int64(want) {
+			t.Errorf("%s: %d keys, want %d", prefix, got.Keys, want)
+		}
+	}
+	if v, _ := client.GetValue("v2/00000"); string(v) != "kept" {
+		t.Errorf("Existing key overwritten with %q", v)
+	}
+}
+
+func TestCountCacheAndEstimate(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-count-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		client.SetValue(fmt.Sprintf("user:%03d", i), []byte("12345"), 0)
+	}
+
+	ctx := context.Background()
+	opts := ListKeysOptions{Prefix: "user:", Mode: "prefix"}
+	n, err := client.CountKeys(ctx, opts)
+	if err != nil || n.Keys != 100 || n.Bytes != 500 || n.Approx {
+		t.Fatalf("CountKeys = %+v, %v", n, err)
+	}
+	if _, ok := client.counts[countFilter{prefix: "user:", mode: "prefix"}]; !ok {
+		t.Error("Count not cached")
+	}
+	// A write invalidates the cache
+	client.SetValue("user:new", []byte("1"), 0)
+	if n, _ := client.CountKeys(ctx, opts); n.Keys != 101 || n.Bytes != 501 {
+		t.Errorf("CountKeys after write = %+v", n)
+	}
+	// Keys with a TTL expire without a write, so their counts are not cached
+	client.SetValue("ttl:1", []byte("1"), 3600)
+	if n, _ := client.CountKeys(ctx, ListKeysOptions{Prefix: "ttl:", Mode: "prefix"}); n.Keys != 1 {
+		t.Errorf("CountKeys with TTL = %+v", n)
+	}
+	if _, ok := client.counts[countFilter{prefix: "ttl:", mode: "prefix"}]; ok {
+		t.Error("Count over keys with a TTL was cached")
+	}
+
+	// Closing flushes the keys to a table the estimate can read
+	client.Close()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if est, ok := client.EstimateKeys(opts); !ok || !est.Approx || est.Keys < 50 {
+		t.Errorf("EstimateKeys = %+v, %v", est, ok)
+	}
+	if est, ok := client.EstimateKeys(ListKeysOptions{Prefix: "zzz", Mode: "prefix"}); !ok || est.Keys != 0 {
+		t.Errorf("EstimateKeys past the last key = %+v, %v", est, ok)
+	}
+	if _, ok := client.EstimateKeys(ListKeysOptions{Prefix: "user", Mode: "substring"}); ok {
+		t.Error("Expected no estimate for a substring search")
+	}
+}
+
+func TestKeysAroundAndSegments(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "badger-seek-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	client := NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	for i := 0; i < 20; i++ {
+		client.SetValue(fmt.Sprintf("user:%02d:name", i), []byte("x"), 0)
+		client.SetValue(fmt.Sprintf("user:%02d:mail", i), []byte("x"), 0)
+	}
+	client.SetValue("order:1", []byte("x"), 0)
+	client.SetValue("user:zz", []byte("x"), 0)
+
+	keys := func(w KeyWindow) []string {
+		var out []string
+		for _, k := range w.Items {
+			out = append(out, k.Key)
+		}
+		return out
+	}
+
+	// Seeking between keys lands on the next one
+	opts := ListKeysOptions{Prefix: "user:", Mode: "prefix", Limit: 4}
+	w, err := client.KeysAround(opts, "user:05:m", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"user:04:mail", "user:04:name", "user:05:mail", "user:05:name"}
+	if !slices.Equal(keys(w), want) || w.Index != 2 || !w.HasLess || !w.HasMore {
+		t.Errorf("KeysAround = %v %+v", keys(w), w)
+	}
+
+	// A window filled with keys ahead of the target still sees the keys after it
+	w, _ = client.KeysAround(opts, "user:05:m", 4)
+	if len(w.Items) != 4 || w.Index != 4 || !w.HasMore {
+		t.Errorf("KeysAround with a full window ahead = %v %+v", keys(w), w)
+	}
+
+	// An exact hit is the first key after the window start, also in descending order
+	opts.SortDesc = true
+	w, _ = client.KeysAround(opts, "user:05:mail", 1)
+	want = []string{"user:05:name", "user:05:mail", "user:04:name", "user:04:mail"}
+	if !slices.Equal(keys(w), want) || w.Index != 1 {
+		t.Errorf("KeysAround desc = %v %+v", keys(w), w)
+	}
+
+	// The next page starts after the last key of the window
+	opts.SortDesc = false
+	w, _ = client.KeysAround(opts, "user:05:m", 2)
+	w, _ = client.KeysAfter(opts, w.Items[3].Key)
+	want = []string{"user:06:mail", "user:06:name", "user:07:mail", "user:07:name"}
+	if !slices.Equal(keys(w), want) || !w.HasLess || !w.HasMore {
+		t.Errorf("KeysAfter = %v %+v", keys(w), w)
+	}
+
+	// Past the last key of the prefix only the keys before it are shown
+	w, _ = client.KeysAround(opts, "user:zzz", 2)
+	want = []string{"user:19:name", "user:zz"}
+	if !slices.Equal(keys(w), want) || w.Index != 2 || w.HasMore {
+		t.Errorf("KeysAround past the end = %v %+v", keys(w), w)
+	}
+
+	segments, more, err := client.KeySegments("user:", ":/", 100)
+	if err != nil || more || len(segments) != 21 || segments[0] != "user:00:" || segments[20] != "user:zz" {
+		t.Errorf("KeySegments = %v, %v, %v", segments, more, err)
+	}
+	segments, more, _ = client.KeySegments("user:1", ":/", 3)
+	if !more || !slices.Equal(segments, []string{"user:10:", "user:11:", "user:12:"}) {
+		t.Errorf("KeySegments with limit = %v, %v", segments, more)
+	}
+	if segments, _, _ = client.KeySegments("", ":/", 100); !slices.Equal(segments, []string{"order:", "user:"}) {
+		t.Errorf("KeySegments from the root = %v", segments)
+	}
+
+	// Separators at the top of the byte range and of more than one byte
+	for _, k := range []string{"x\xff1", "x\xff2", "x\xff\xff", "x\xff\xff\xff", "x→1", "x→2", "x→"} {
+		client.SetValue(k, []byte("x"), 0)
+	}
+	tests := []struct {
+		prefix, separators string
+		want               []string
+	}{
+		{"x", "\xff", []string{"x→", "x→1", "x→2", "x\xff"}},
+		{"x\xff", "\xff", []string{"x\xff1", "x\xff2", "x\xff\xff"}},
+		{"x\xff\xff", "\xff", []string{"x\xff\xff", "x\xff\xff\xff"}},
+		{"x", "→", []string{"x→", "x\xff1", "x\xff2", "x\xff\xff", "x\xff\xff\xff"}},
+		{"x→", "→", []string{"x→", "x→1", "x→2"}},
+	}
+	for _, tt := range tests {
+		segments, _, err := client.KeySegments(tt.prefix, tt.separators, 100)
+		if err != nil || !slices.Equal(segments, tt.want) {
+			t.Errorf("KeySegments(%q, %q) = %q, %v; want %q", tt.prefix, tt.separators, segments, err, tt.want)
+		}
+	}
+}
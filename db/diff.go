@@ -0,0 +1,245 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"hash/fnv"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// Diff operations reported by Diff.
+const (
+	DiffAdded   = "added"   // Key exists only in b
+	DiffRemoved = "removed" // Key exists only in a
+	DiffChanged = "changed" // Key exists in both but value, TTL or user meta differ
+)
+
+// DiffEntry describes a single key that differs between two stores.
+type DiffEntry struct {
+	Key      string `json:"key"`
+	Op       string `json:"op"`
+	SizeA    int64  `json:"size_a"`
+	SizeB    int64  `json:"size_b"`
+	ExpiresA uint64 `json:"expires_a"`
+	ExpiresB uint64 `json:"expires_b"`
+}
+
+// errDiffLimit stops the merged iteration once opts.Limit entries were reported.
+var errDiffLimit = errors.New("diff limit reached")
+
+// Diff walks the keys of a and b in ascending order and calls fn for every key that differs.
+// Prefix and Mode in opts filter the keys on both sides, Limit caps the number of reported
+// entries (0 means no limit). SortDesc, Offset and StartKey are ignored.
+// Returning an error from fn stops the walk and that error is returned.
+func Diff(a, b *DBClient, opts ListKeysOptions, fn func(DiffEntry) error) error {
+	a.mu.Lock()
+	dbA := a.db
+	a.mu.Unlock()
+
+	b.mu.Lock()
+	dbB := b.db
+	b.mu.Unlock()
+
+	if dbA == nil || dbB == nil {
+		return ErrNotOpen
+	}
+
+	opts = opts.normalized()
+	match, err := matchFunc(opts)
+	if err != nil {
+		return err
+	}
+
+	reported := 0
+	emit := func(e DiffEntry) error {
+		if err := fn(e); err != nil {
+			return err
+		}
+		reported++
+		if opts.Limit > 0 && reported >= opts.Limit {
+			return errDiffLimit
+		}
+		return nil
+	}
+
+	err = dbA.View(func(txnA *badger.Txn) error {
+		return dbB.View(func(txnB *badger.Txn) error {
+			// 값은 해시 비교가 필요한 경우에만 읽으므로 프리페치하지 않음
+			itOpts := badger.DefaultIteratorOptions
+			itOpts.PrefetchValues = false
+
+			itA := txnA.NewIterator(itOpts)
+			defer itA.Close()
+			itB := txnB.NewIterator(itOpts)
+			defer itB.Close()
+
+			start := seekKey(opts)
+			itA.Seek(start)
+			itB.Seek(start)
+
+			for {
+				ia := nextMatch(itA, opts, match)
+				ib := nextMatch(itB, opts, match)
+				if ia == nil && ib == nil {
+					return nil
+				}
+
+				cmp := 0
+				if ia == nil {
+					cmp = 1
+				} else if ib == nil {
+					cmp = -1
+				} else {
+					cmp = bytes.Compare(ia.Key(), ib.Key())
+				}
+
+				switch {
+				case cmp < 0:
+					if err := emit(DiffEntry{
+						Key:      string(ia.Key()),
+						Op:       DiffRemoved,
+						SizeA:    ia.ValueSize(),
+						ExpiresA: ia.ExpiresAt(),
+					}); err != nil {
+						return err
+					}
+					itA.Next()
+				case cmp > 0:
+					if err := emit(DiffEntry{
+						Key:      string(ib.Key()),
+						Op:       DiffAdded,
+						SizeB:    ib.ValueSize(),
+						ExpiresB: ib.ExpiresAt(),
+					}); err != nil {
+						return err
+					}
+					itB.Next()
+				default:
+					changed, err := itemsDiffer(ia, ib)
+					if err != nil {
+						return err
+					}
+					if changed {
+						if err := emit(DiffEntry{
+							Key:      string(ia.Key()),
+							Op:       DiffChanged,
+							SizeA:    ia.ValueSize(),
+							SizeB:    ib.ValueSize(),
+							ExpiresA: ia.ExpiresAt(),
+							ExpiresB: ib.ExpiresAt(),
+						}); err != nil {
+							return err
+						}
+					}
+					itA.Next()
+					itB.Next()
+				}
+			}
+		})
+	})
+
+	if errors.Is(err, errDiffLimit) {
+		return nil
+	}
+	return err
+}
+
+// nextMatch advances it to the next key accepted by match and returns its item,
+// or nil when the iterator is exhausted or has moved past the prefix.
+func nextMatch(it *badger.Iterator, opts ListKeysOptions, match func(string) bool) *badger.Item {
+	for ; it.Valid(); it.Next() {
+		key := string(it.Item().Key())
+		if pastPrefix(opts, key) {
+			return nil
+		}
+		if match(key) {
+			return it.Item()
+		}
+	}
+	return nil
+}
+
+// itemsDiffer compares metadata first and falls back to hashing the values
+// only when size, TTL and user meta are identical.
+func itemsDiffer(a, b *badger.Item) (bool, error) {
+	if a.ValueSize() != b.ValueSize() || a.ExpiresAt() != b.ExpiresAt() || a.UserMeta() != b.UserMeta() {
+		return true, nil
+	}
+
+	hashA, err := valueHash(a)
+	if err != nil {
+		return false, err
+	}
+	hashB, err := valueHash(b)
+	if err != nil {
+		return false, err
+	}
+	return hashA != hashB, nil
+}
+
+func valueHash(item *badger.Item) (uint64, error) {
+	h := fnv.New64a()
+	err := item.Value(func(val []byte) error {
+		_, err := h.Write(val)
+		return err
+	})
+	return h.Sum64(), err
+}
+
+// SyncKeys makes dst match src for the given keys.
+// Keys present in src are copied with their value, TTL and user meta; keys missing from src are deleted from dst.
+// All writes go through a single WriteBatch. It returns the number of keys written or deleted.
+func SyncKeys(src, dst *DBClient, keys []string) (int, error) {
+	src.mu.Lock()
+	srcDB := src.db
+	src.mu.Unlock()
+
+	dst.mu.Lock()
+	dstDB := dst.db
+	dst.mu.Unlock()
+
+	if srcDB == nil || dstDB == nil {
+		return 0, ErrNotOpen
+	}
+
+	wb := dstDB.NewWriteBatch()
+	defer wb.Cancel()
+
+	synced := 0
+	err := srcDB.View(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			item, err := txn.Get([]byte(key))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				if err := wb.Delete([]byte(key)); err != nil {
+					return err
+				}
+				synced++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			e := badger.NewEntry([]byte(key), val).WithMeta(item.UserMeta())
+			e.ExpiresAt = item.ExpiresAt()
+			if err := wb.SetEntry(e); err != nil {
+				return err
+			}
+			synced++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, wrapErr(err, "")
+	}
+
+	if err := wb.Flush(); err != nil {
+		return 0, wrapErr(err, "")
+	}
+	return synced, nil
+}
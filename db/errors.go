@@ -0,0 +1,110 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// Errors returned by DBClient. Use errors.Is to test for them; details such as
+// the key are available through *Error.
+var (
+	ErrNotOpen      = errors.New("database not open")
+	ErrAlreadyOpen  = errors.New("database is already open")
+	ErrPathNotFound = errors.New("directory does not exist")
+	ErrLocked       = errors.New("database is locked by another process")
+	ErrKeyNotFound  = errors.New("key not found")
+	ErrReadOnly     = errors.New("database is read-only")
+	ErrConflict     = errors.New("transaction conflict")
+	ErrInvalidRegex = errors.New("invalid regex")
+	ErrTooBig       = errors.New("key, value or transaction too big")
+	ErrKeyExists    = errors.New("key already exists")
+)
+
+// Error attaches details to one of the sentinel errors above.
+type Error struct {
+	Kind    error  // One of the Err* variables
+	Key     string // Key the operation was about, if any
+	Path    string // Database path, for open errors
+	Pattern string // Search pattern, for ErrInvalidRegex
+	Err     error  // Underlying error, if any
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	sb.WriteString(e.Kind.Error())
+	for _, detail := range []string{e.Key, e.Path, e.Pattern} {
+		if detail != "" {
+			sb.WriteString(": ")
+			sb.WriteString(detail)
+		}
+	}
+	if e.Err != nil {
+		sb.WriteString(" (")
+		sb.WriteString(e.Err.Error())
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+// Unwrap lets errors.Is match both the kind and the underlying error.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// wrapErr converts Badger errors about key into the errors of this package.
+// Other errors are returned unchanged.
+func wrapErr(err error, key string) error {
+	var kind error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, badger.ErrKeyNotFound):
+		return &Error{Kind: ErrKeyNotFound, Key: key}
+	case errors.Is(err, badger.ErrDBClosed):
+		return ErrNotOpen
+	case errors.Is(err, badger.ErrConflict):
+		kind = ErrConflict
+	case errors.Is(err, badger.ErrReadOnlyTxn), errors.Is(err, badger.ErrBlockedWrites):
+		kind = ErrReadOnly
+	case errors.Is(err, badger.ErrTxnTooBig):
+		kind = ErrTooBig
+	default:
+		return err
+	}
+	return &Error{Kind: kind, Key: key, Err: err}
+}
+
+// wrapOpenErr classifies an error from badger.Open. Badger flattens the
+// error of its directory lock into text, so the lock is probed instead.
+func wrapOpenErr(err error, path string, readOnly bool) error {
+	if dirLocked(path, readOnly) {
+		return &Error{Kind: ErrLocked, Path: path, Err: err}
+	}
+	return err
+}
+
+// maxKeySize is the longest key Badger accepts; Badger does not export it.
+const maxKeySize = 65000
+
+// checkSize catches keys and values Badger would refuse as too big. Badger
+// reports those with a formatted message rather than a sentinel error, so
+// they are checked before writing. maxValue <= 0 skips the value check.
+func checkSize(key string, value []byte, maxValue int64) error {
+	switch {
+	case len(key) > maxKeySize:
+		return &Error{Kind: ErrTooBig, Err: fmt.Errorf("key of %d bytes exceeds %d", len(key), maxKeySize)}
+	case maxValue > 0 && int64(len(value)) > maxValue:
+		return &Error{Kind: ErrTooBig, Key: key, Err: fmt.Errorf("value of %d bytes exceeds %d", len(value), maxValue)}
+	}
+	return nil
+}
+
+func regexErr(pattern string, err error) error {
+	return &Error{Kind: ErrInvalidRegex, Pattern: pattern, Err: err}
+}
@@ -0,0 +1,20 @@
+//go:build windows || plan9 || js || wasip1
+
+package db
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// dirLocked reports whether another process holds Badger's LOCK file. It is
+// opened exclusively and removed once released, so a LOCK file that exists
+// but cannot be opened is held.
+func dirLocked(path string, readOnly bool) bool {
+	f, err := os.Open(filepath.Join(path, "LOCK"))
+	if err != nil {
+		return !os.IsNotExist(err)
+	}
+	f.Close()
+	return false
+}
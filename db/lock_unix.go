@@ -0,0 +1,26 @@
+//go:build !windows && !plan9 && !js && !wasip1
+
+package db
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// dirLocked reports whether another handle holds the flock Badger takes on
+// the directory, so that opening it the same way would fail.
+func dirLocked(path string, readOnly bool) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close() // Also releases the probe's own lock
+
+	how := unix.LOCK_EX | unix.LOCK_NB
+	if readOnly {
+		how = unix.LOCK_SH | unix.LOCK_NB
+	}
+	return errors.Is(unix.Flock(int(f.Fd()), how), unix.EWOULDBLOCK)
+}
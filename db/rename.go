@@ -0,0 +1,192 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// Prefix-wide renames and copies commit in chunks of at most this many keys
+// or value bytes, so large prefixes stay below Badger's transaction limits.
+const (
+	prefixChunkKeys  = 1000
+	prefixChunkBytes = 4 << 20
+)
+
+// RenameKey moves the value, TTL and user meta of from to to in one
+// transaction. It fails with ErrKeyExists if to exists, unless overwrite is set.
+func (c *DBClient) RenameKey(from, to string, overwrite bool) error {
+	return c.moveKey(from, to, overwrite, true)
+}
+
+// CopyKey copies the value, TTL and user meta of from to to in one
+// transaction. It fails with ErrKeyExists if to exists, unless overwrite is set.
+func (c *DBClient) CopyKey(from, to string, overwrite bool) error {
+	return c.moveKey(from, to, overwrite, false)
+}
+
+func (c *DBClient) moveKey(from, to string, overwrite, deleteOld bool) error {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return ErrNotOpen
+	}
+	if from == to {
+		return fmt.Errorf("source and target are the same key: %s", from)
+	}
+
+	err := db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(from))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return &Error{Kind: ErrKeyNotFound, Key: from}
+		}
+		if err != nil {
+			return err
+		}
+		exists, err := keyExists(txn, to)
+		if err != nil {
+			return err
+		}
+		if exists && !overwrite {
+			return &Error{Kind: ErrKeyExists, Key: to}
+		}
+		return copyItem(txn, item, to, deleteOld)
+	})
+	return wrapErr(err, to)
+}
+
+// RenamePrefix moves every key under from to the same key under to,
+// keeping value, TTL and user meta. Each chunk of keys is committed
+// atomically. Keys whose target exists are skipped unless overwrite is set.
+func (c *DBClient) RenamePrefix(ctx context.Context, from, to string, overwrite bool) (BulkResult, error) {
+	return c.movePrefix(ctx, from, to, overwrite, true)
+}
+
+// CopyPrefix copies every key under from to the same key under to, like RenamePrefix.
+func (c *DBClient) CopyPrefix(ctx context.Context, from, to string, overwrite bool) (BulkResult, error) {
+	return c.movePrefix(ctx, from, to, overwrite, false)
+}
+
+func (c *DBClient) movePrefix(ctx context.Context, from, to string, overwrite, deleteOld bool) (BulkResult, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return BulkResult{}, ErrNotOpen
+	}
+	// New keys would land in the range being scanned and move again
+	if strings.HasPrefix(to, from) || strings.HasPrefix(from, to) {
+		return BulkResult{}, fmt.Errorf("source prefix %q and target prefix %q overlap", from, to)
+	}
+
+	var result BulkResult
+	start := []byte(from)
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		keys, err := prefixChunk(db, from, start)
+		if err != nil {
+			return result, wrapErr(err, "")
+		}
+		if len(keys) == 0 {
+			return result, nil
+		}
+
+		var chunk BulkResult
+		err = db.Update(func(txn *badger.Txn) error {
+			chunk = BulkResult{}
+			for _, key := range keys {
+				item, err := txn.Get([]byte(key))
+				if errors.Is(err, badger.ErrKeyNotFound) {
+					continue // Deleted since the scan
+				}
+				if err != nil {
+					return err
+				}
+				newKey := to + strings.TrimPrefix(key, from)
+				exists, err := keyExists(txn, newKey)
+				if err != nil {
+					return err
+				}
+				if exists && !overwrite {
+					chunk.Skipped = append(chunk.Skipped, key)
+					continue
+				}
+				if err := copyItem(txn, item, newKey, deleteOld); err != nil {
+					return err
+				}
+				chunk.Done++
+			}
+			return nil
+		})
+		if err != nil {
+			return result, wrapErr(err, "")
+		}
+		result.Done += chunk.Done
+		result.Skipped = append(result.Skipped, chunk.Skipped...)
+
+		// Continue after the last key of the chunk
+		start = append([]byte(keys[len(keys)-1]), 0)
+	}
+}
+
+// prefixChunk returns the next keys under prefix from start on, bounded by
+// prefixChunkKeys and prefixChunkBytes.
+func prefixChunk(db *badger.DB, prefix string, start []byte) ([]string, error) {
+	var keys []string
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		var size int64
+		for it.Seek(start); it.Valid(); it.Next() {
+			item := it.Item()
+			keys = append(keys, string(item.Key()))
+			size += item.ValueSize()
+			if len(keys) >= prefixChunkKeys || size >= prefixChunkBytes {
+				break
+			}
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// copyItem writes the value, TTL and user meta of item under key, then
+// deletes the item's key if deleteOld is set.
+func copyItem(txn *badger.Txn, item *badger.Item, key string, deleteOld bool) error {
+	if err := checkSize(key, nil, 0); err != nil {
+		return err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return err
+	}
+	e := badger.NewEntry([]byte(key), val).WithMeta(item.UserMeta())
+	e.ExpiresAt = item.ExpiresAt()
+	if err := txn.SetEntry(e); err != nil {
+		return err
+	}
+	if deleteOld {
+		return txn.Delete(item.KeyCopy(nil))
+	}
+	return nil
+}
+
+func keyExists(txn *badger.Txn, key string) (bool, error) {
+	_, err := txn.Get([]byte(key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
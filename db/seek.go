@@ -0,0 +1,229 @@
+package db
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// KeyWindow is a page of keys positioned around a target key.
+type KeyWindow struct {
+	Items   []KeyItem
+	Index   int  // Position of the first key at or after the target, len(Items) if none
+	HasLess bool // More keys may precede the window
+	HasMore bool // More keys may follow the window
+}
+
+// KeysAround seeks to target and returns the keys matching the search
+// options of opts around it, in the order of opts.SortDesc: up to before
+// keys ahead of target, then keys at or after it up to opts.Limit in total.
+// In descending order "after" means smaller keys. Offset and StartKey are
+// ignored.
+func (c *DBClient) KeysAround(opts ListKeysOptions, target string, before int) (KeyWindow, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return KeyWindow{}, ErrNotOpen
+	}
+
+	opts = opts.normalized()
+	match, err := matchFunc(opts)
+	if err != nil {
+		return KeyWindow{}, err
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	before = min(max(before, 0), opts.Limit)
+
+	var w KeyWindow
+	err = db.View(func(txn *badger.Txn) error {
+		// Keys ahead of the target, walking away from it
+		ahead, more, err := scanFrom(txn, opts, match, target, !opts.SortDesc, before, true)
+		if err != nil {
+			return err
+		}
+		slices.Reverse(ahead)
+		w.HasLess = more
+
+		items, more, err := scanFrom(txn, opts, match, target, opts.SortDesc, opts.Limit-len(ahead), false)
+		if err != nil {
+			return err
+		}
+		w.Items = append(ahead, items...)
+		w.Index = len(ahead)
+		w.HasMore = more
+		return nil
+	})
+	if err != nil {
+		return KeyWindow{}, wrapErr(err, "")
+	}
+	return w, nil
+}
+
+// KeysAfter returns the page of keys matching the search options of opts
+// that follows key in the order of opts.SortDesc, without key itself. It
+// continues a window past its last key.
+func (c *DBClient) KeysAfter(opts ListKeysOptions, key string) (KeyWindow, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return KeyWindow{}, ErrNotOpen
+	}
+
+	opts = opts.normalized()
+	match, err := matchFunc(opts)
+	if err != nil {
+		return KeyWindow{}, err
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+
+	w := KeyWindow{HasLess: true}
+	err = db.View(func(txn *badger.Txn) error {
+		var err error
+		w.Items, w.HasMore, err = scanFrom(txn, opts, match, key, opts.SortDesc, opts.Limit, true)
+		return err
+	})
+	if err != nil {
+		return KeyWindow{}, wrapErr(err, "")
+	}
+	return w, nil
+}
+
+// scanFrom collects up to limit matching keys from target in one direction
+// and reports whether the limit cut the scan short. With a limit of 0 it
+// only looks for one matching key. skipTarget leaves out the target key itself.
+func scanFrom(txn *badger.Txn, opts ListKeysOptions, match func(string) bool, target string, reverse bool, limit int, skipTarget bool) ([]KeyItem, bool, error) {
+	itOpts := badger.DefaultIteratorOptions
+	itOpts.PrefetchSize = min(max(limit, 1), maxPrefetch)
+	itOpts.Reverse = reverse
+	it := txn.NewIterator(itOpts)
+	defer it.Close()
+
+	var items []KeyItem
+	for it.Seek([]byte(target)); it.Valid(); it.Next() {
+		item := it.Item()
+		key := string(item.Key())
+		if outsidePrefix(opts, key, reverse) {
+			break
+		}
+		if (skipTarget && key == target) || !match(key) {
+			continue
+		}
+		if len(items) == limit {
+			return items, true, nil
+		}
+		k, err := keyItem(item, opts)
+		if err != nil {
+			return nil, false, err
+		}
+		items = append(items, k)
+	}
+	return items, false, nil
+}
+
+// outsidePrefix reports whether a prefix scan in the given direction has
+// moved beyond every matching key.
+func outsidePrefix(opts ListKeysOptions, key string, reverse bool) bool {
+	if !reverse {
+		return pastPrefix(opts, key)
+	}
+	if opts.Mode == "substring" || opts.Mode == "regex" || opts.Prefix == "" {
+		return false
+	}
+	return key < opts.Prefix
+}
+
+// prefixEnd returns the first key after every key starting with prefix, or
+// nil if there is none because prefix is all 0xFF bytes.
+func prefixEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// keyItem reads the list entry of item, with its value preview.
+func keyItem(item *badger.Item, opts ListKeysOptions) (KeyItem, error) {
+	key := string(item.Key())
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return KeyItem{}, err
+	}
+	preview, codecName := makePreview(key, val, opts)
+	return KeyItem{
+		Key:          key,
+		ValuePreview: preview,
+		Size:         item.ValueSize(),
+		ExpiresAt:    item.ExpiresAt(),
+		Codec:        codecName,
+		Version:      item.Version(),
+		UserMeta:     item.UserMeta(),
+	}, nil
+}
+
+// KeySegments completes prefix by one key segment: it returns the distinct
+// keys under prefix cut after the first of separators that follows the
+// prefix, like directories, in ascending order. Separators may be any
+// runes. Keys without a further separator are returned whole. The scan skips over each segment it finds,
+// so it reads at most limit+1 keys; the bool reports whether limit was reached.
+func (c *DBClient) KeySegments(prefix, separators string, limit int) ([]string, bool, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return nil, false, ErrNotOpen
+	}
+
+	var segments []string
+	var more bool
+	err := db.View(func(txn *badger.Txn) error {
+		itOpts := badger.DefaultIteratorOptions
+		itOpts.PrefetchValues = false
+		itOpts.Prefix = []byte(prefix)
+		it := txn.NewIterator(itOpts)
+		defer it.Close()
+
+		for it.Seek([]byte(prefix)); it.Valid(); {
+			key := string(it.Item().Key())
+			segment := key
+			i := strings.IndexAny(key[len(prefix):], separators)
+			if i >= 0 {
+				_, size := utf8.DecodeRuneInString(key[len(prefix)+i:])
+				segment = key[:len(prefix)+i+size]
+			}
+			if len(segments) == limit {
+				more = true
+				return nil
+			}
+			segments = append(segments, segment)
+
+			if i < 0 {
+				it.Next()
+			} else if next := prefixEnd(segment); next != nil {
+				// Every key under the segment shares it; skip to the next one
+				it.Seek(next)
+			} else {
+				break // Nothing sorts after a segment of 0xFF bytes
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, wrapErr(err, "")
+	}
+	return segments, more, nil
+}
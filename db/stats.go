@@ -0,0 +1,198 @@
+package db
+
+import (
+	"bytes"
+	"context"
+
+	badger "github.com/dgraph-io/badger/v4"
+)
+
+// Stats summarises the open database.
+type Stats struct {
+	Path       string `json:"path"`
+	ReadOnly   bool   `json:"readonly"`
+	Keys       int64  `json:"keys"`        // Live keys, without deleted or expired ones
+	ValueBytes int64  `json:"value_bytes"` // Sum of the value sizes of the live keys
+	LSMSize    int64  `json:"lsm_size"`
+	VLogSize   int64  `json:"vlog_size"`
+	Tables     int    `json:"tables"`
+	MaxVersion uint64 `json:"max_version"`
+}
+
+// Stats scans every key to count them, so it takes time on large databases.
+func (c *DBClient) Stats(ctx context.Context) (Stats, error) {
+	c.mu.Lock()
+	db, path := c.db, c.path
+	c.mu.Unlock()
+
+	if db == nil {
+		return Stats{}, ErrNotOpen
+	}
+
+	lsm, vlog := db.Size()
+	st := Stats{
+		Path:       path,
+		ReadOnly:   db.Opts().ReadOnly,
+		LSMSize:    lsm,
+		VLogSize:   vlog,
+		Tables:     len(db.Tables()),
+		MaxVersion: db.MaxVersion(),
+	}
+
+	err := db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			st.Keys++
+			st.ValueBytes += it.Item().ValueSize()
+		}
+		return nil
+	})
+	if err != nil {
+		return Stats{}, wrapErr(err, "")
+	}
+	return st, nil
+}
+
+// KeyCount is the number of keys matching a filter and the sum of their value sizes.
+type KeyCount struct {
+	Keys   int64 `json:"keys"`
+	Bytes  int64 `json:"bytes"`
+	Approx bool  `json:"approx,omitempty"` // Estimated from the table indexes; Bytes is unknown
+}
+
+// countFilter identifies the search options a count was made for.
+type countFilter struct {
+	prefix, mode string
+}
+
+// CountKeys counts the keys matching the search options of opts with a
+// key-only scan. Limit, Offset and the preview options are ignored.
+// Results are cached per filter until the next write to the DB. Counts
+// that include a key with a TTL are not cached, since expiry changes them
+// without a write.
+func (c *DBClient) CountKeys(ctx context.Context, opts ListKeysOptions) (KeyCount, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return KeyCount{}, ErrNotOpen
+	}
+
+	opts = opts.normalized()
+	match, err := matchFunc(opts)
+	if err != nil {
+		return KeyCount{}, err
+	}
+
+	// Codec filters depend on the config rules, which can change without a write
+	filter := countFilter{prefix: opts.Prefix, mode: opts.Mode}
+	cacheable := opts.CodecFilter == ""
+	version := db.MaxVersion()
+	if cacheable {
+		if count, ok := c.cachedCount(version, filter); ok {
+			return count, nil
+		}
+	}
+
+	var count KeyCount
+	var expiring bool
+	err = db.View(func(txn *badger.Txn) error {
+		itOpts := badger.DefaultIteratorOptions
+		itOpts.PrefetchValues = false
+		it := txn.NewIterator(itOpts)
+		defer it.Close()
+
+		for it.Seek(seekKey(opts)); it.Valid(); it.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			item := it.Item()
+			key := string(item.Key())
+			if pastPrefix(opts, key) {
+				break
+			}
+			if match(key) {
+				count.Keys++
+				count.Bytes += item.ValueSize()
+				expiring = expiring || item.ExpiresAt() != 0
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return KeyCount{}, wrapErr(err, "")
+	}
+
+	if cacheable && !expiring {
+		c.storeCount(version, filter, count)
+	}
+	return count, nil
+}
+
+func (c *DBClient) cachedCount(version uint64, filter countFilter) (KeyCount, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.countVersion != version {
+		return KeyCount{}, false
+	}
+	count, ok := c.counts[filter]
+	return count, ok
+}
+
+func (c *DBClient) storeCount(version uint64, filter countFilter, count KeyCount) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil || c.countVersion != version {
+		c.counts = make(map[countFilter]KeyCount)
+		c.countVersion = version
+	}
+	c.counts[filter] = count
+}
+
+// EstimateKeys approximates the number of keys under the prefix of opts
+// from the key ranges and counts of the on-disk tables, without reading
+// any key. It reports false for filters it cannot estimate: other search
+// modes, case-insensitive and codec filters. Tables count every version and
+// deleted keys, and keys still in memory are missed, so use CountKeys for
+// an exact number.
+func (c *DBClient) EstimateKeys(opts ListKeysOptions) (KeyCount, bool) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil || opts.IgnoreCase || opts.CodecFilter != "" || ((opts.Mode == "substring" || opts.Mode == "regex") && opts.Prefix != "") {
+		return KeyCount{}, false
+	}
+
+	prefix := []byte(opts.Prefix)
+	var keys float64
+	for _, t := range db.Tables() {
+		left, right := userKey(t.Left), userKey(t.Right)
+		switch {
+		case bytes.HasPrefix(left, prefix) && bytes.HasPrefix(right, prefix):
+			keys += float64(t.KeyCount)
+		case bytes.Compare(right, prefix) < 0 || (bytes.Compare(left, prefix) > 0 && !bytes.HasPrefix(left, prefix)):
+			// Table entirely before or after the prefix
+		default:
+			// The prefix covers part of the table; assume half of it
+			keys += float64(t.KeyCount) / 2
+		}
+	}
+	return KeyCount{Keys: int64(keys), Approx: true}, true
+}
+
+// userKey strips the version Badger appends to the keys of its tables.
+func userKey(key []byte) []byte {
+	if len(key) <= 8 {
+		return key
+	}
+	return key[:len(key)-8]
+}
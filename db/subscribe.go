@@ -0,0 +1,111 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	badger "github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/pb"
+)
+
+// Key change operations reported by Subscribe.
+const (
+	EventAdded   = "added"
+	EventChanged = "changed"
+	EventDeleted = "deleted"
+)
+
+// KeyEvent describes one write seen by Subscribe.
+type KeyEvent struct {
+	Key       string `json:"key"`
+	Op        string `json:"op"`
+	Version   uint64 `json:"version"`
+	Size      int64  `json:"size"`
+	ExpiresAt uint64 `json:"expires_at"`
+}
+
+// Subscribe calls fn with the writes to keys under any of prefixes until ctx is
+// done, the database is closed or fn returns an error. No prefixes watches every key.
+// It blocks and returns nil when ctx is cancelled or the database closes.
+func (c *DBClient) Subscribe(ctx context.Context, prefixes []string, fn func([]KeyEvent) error) error {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return ErrNotOpen
+	}
+
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+	matches := make([]pb.Match, len(prefixes))
+	for i, p := range prefixes {
+		matches[i] = pb.Match{Prefix: []byte(p)}
+	}
+
+	err := db.Subscribe(ctx, func(kvs *badger.KVList) error {
+		events := make([]KeyEvent, 0, len(kvs.Kv))
+		err := db.View(func(txn *badger.Txn) error {
+			for _, kv := range kvs.Kv {
+				events = append(events, KeyEvent{
+					Key:       string(kv.Key),
+					Op:        eventOp(txn, kv),
+					Version:   kv.Version,
+					Size:      int64(len(kv.Value)),
+					ExpiresAt: kv.ExpiresAt,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return fn(events)
+	}, matches)
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	return wrapErr(err, "")
+}
+
+// eventOp tells a new key from an overwrite or a delete by looking at the
+// versions of the key. Badger only reports the written entry, so a key whose
+// older versions were already compacted away is reported as added.
+func eventOp(txn *badger.Txn, kv *pb.KV) string {
+	opts := badger.DefaultIteratorOptions
+	opts.AllVersions = true
+	opts.PrefetchValues = false
+	it := txn.NewKeyIterator(kv.Key, opts)
+	defer it.Close()
+
+	seen := false
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		if !bytes.Equal(item.Key(), kv.Key) {
+			break
+		}
+		switch {
+		case item.Version() > kv.Version:
+			continue
+		case item.Version() == kv.Version:
+			if item.IsDeletedOrExpired() {
+				return EventDeleted
+			}
+			seen = true
+		default:
+			// Newest version before this write
+			if item.IsDeletedOrExpired() {
+				return EventAdded
+			}
+			return EventChanged
+		}
+	}
+
+	if !seen && len(kv.Value) == 0 {
+		return EventDeleted
+	}
+	return EventAdded
+}
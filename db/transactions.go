@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -14,7 +15,7 @@ func (c *DBClient) GetValue(key string) ([]byte, error) {
 	c.mu.Unlock()
 
 	if db == nil {
-		return nil, fmt.Errorf("database not open")
+		return nil, ErrNotOpen
 	}
 
 	var val []byte
@@ -28,11 +29,185 @@ func (c *DBClient) GetValue(key string) ([]byte, error) {
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, wrapErr(err, key)
 	}
 	return val, nil
 }
 
+// KeyInfo holds the metadata of a key without its value.
+type KeyInfo struct {
+	Key       string `json:"key"`
+	Size      int64  `json:"size"`
+	ExpiresAt uint64 `json:"expires_at"`
+	UserMeta  byte   `json:"user_meta"`
+	Version   uint64 `json:"version"`
+}
+
+// GetKeyInfo returns the metadata of a key, or ErrKeyNotFound.
+func (c *DBClient) GetKeyInfo(key string) (KeyInfo, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return KeyInfo{}, ErrNotOpen
+	}
+
+	var info KeyInfo
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		info = KeyInfo{
+			Key:       key,
+			Size:      item.ValueSize(),
+			ExpiresAt: item.ExpiresAt(),
+			UserMeta:  item.UserMeta(),
+			Version:   item.Version(),
+		}
+		return nil
+	})
+	return info, wrapErr(err, key)
+}
+
+// KeyStatus is the current state of a key looked up by name.
+type KeyStatus struct {
+	KeyInfo
+	Exists  bool   `json:"exists"`
+	Preview string `json:"preview"`
+	Codec   string `json:"codec,omitempty"` // Codec used for the preview, empty for raw text
+}
+
+// LookupKeys reads the metadata and a value preview of each key from one
+// snapshot. Missing keys are returned with Exists false. Only the preview
+// options of opts are used.
+func (c *DBClient) LookupKeys(keys []string, opts ListKeysOptions) ([]KeyStatus, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return nil, ErrNotOpen
+	}
+
+	result := make([]KeyStatus, len(keys))
+	err := db.View(func(txn *badger.Txn) error {
+		for i, key := range keys {
+			result[i].Key = key
+			item, err := txn.Get([]byte(key))
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			preview, codecName := makePreview(key, val, opts)
+			result[i] = KeyStatus{
+				KeyInfo: KeyInfo{
+					Key:       key,
+					Size:      item.ValueSize(),
+					ExpiresAt: item.ExpiresAt(),
+					UserMeta:  item.UserMeta(),
+					Version:   item.Version(),
+				},
+				Exists:  true,
+				Preview: preview,
+				Codec:   codecName,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, wrapErr(err, "")
+	}
+	return result, nil
+}
+
+// GetValueRange retrieves up to length bytes of a key's value starting at offset,
+// together with the total value size. A length <= 0 reads to the end of the value.
+func (c *DBClient) GetValueRange(key string, offset, length int64) ([]byte, int64, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return nil, 0, ErrNotOpen
+	}
+	if offset < 0 {
+		return nil, 0, fmt.Errorf("invalid offset: %d", offset)
+	}
+
+	var page []byte
+	var total int64
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		// Only the requested range is copied out of the value
+		return item.Value(func(val []byte) error {
+			total = int64(len(val))
+			if offset >= total {
+				page = []byte{}
+				return nil
+			}
+			end := total
+			if length > 0 && offset+length < total {
+				end = offset + length
+			}
+			page = append([]byte{}, val[offset:end]...)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, 0, wrapErr(err, key)
+	}
+	return page, total, nil
+}
+
+// ReadValueChunks calls fn for consecutive chunks of a key's value within a single read.
+// The chunk slice is only valid during the call. It returns the total value size.
+func (c *DBClient) ReadValueChunks(key string, chunkSize int, fn func(offset int64, chunk []byte) error) (int64, error) {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return 0, ErrNotOpen
+	}
+	if chunkSize <= 0 {
+		return 0, fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+
+	var total int64
+	err := db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			total = int64(len(val))
+			for off := 0; off < len(val); off += chunkSize {
+				end := off + chunkSize
+				if end > len(val) {
+					end = len(val)
+				}
+				if err := fn(int64(off), val[off:end]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+	return total, wrapErr(err, key)
+}
+
 // SetValue sets a value for a key.
 // If ttl is > 0, it sets the TTL in seconds.
 func (c *DBClient) SetValue(key string, value []byte, ttl int) error {
@@ -41,7 +216,7 @@ func (c *DBClient) SetValue(key string, value []byte, ttl int) error {
 	c.mu.Unlock()
 
 	if db == nil {
-		return fmt.Errorf("database not open")
+		return ErrNotOpen
 	}
 
 	// 필요한 경우 R/W 모드로 다시 열기?
@@ -56,8 +231,27 @@ func (c *DBClient) SetValue(key string, value []byte, ttl int) error {
 	// 아니면 편집하려는 경우 그냥 R/W로 열기?
 	// 쓰기를 시도해봄. ReadOnly로 인해 실패하면 다시 열기를 시도할 수 있음.
 
+	return wrapErr(setEntry(db, key, value, ttl, 0), key)
+}
+
+// SetValueWithMeta sets a value together with Badger's user meta byte.
+func (c *DBClient) SetValueWithMeta(key string, value []byte, ttl int, userMeta byte) error {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return ErrNotOpen
+	}
+	return wrapErr(setEntry(db, key, value, ttl, userMeta), key)
+}
+
+func setEntry(db *badger.DB, key string, value []byte, ttl int, userMeta byte) error {
+	if err := checkSize(key, value, db.Opts().ValueLogFileSize); err != nil {
+		return err
+	}
 	return db.Update(func(txn *badger.Txn) error {
-		e := badger.NewEntry([]byte(key), value)
+		e := badger.NewEntry([]byte(key), value).WithMeta(userMeta)
 		if ttl > 0 {
 			e.WithTTL(time.Duration(ttl) * time.Second)
 		}
@@ -65,6 +259,35 @@ func (c *DBClient) SetValue(key string, value []byte, ttl int) error {
 	})
 }
 
+// SetTTL rewrites a key with a new TTL in seconds, keeping its value and user
+// meta. A ttl <= 0 removes the expiry.
+func (c *DBClient) SetTTL(key string, ttl int) error {
+	c.mu.Lock()
+	db := c.db
+	c.mu.Unlock()
+
+	if db == nil {
+		return ErrNotOpen
+	}
+
+	err := db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		e := badger.NewEntry([]byte(key), val).WithMeta(item.UserMeta())
+		if ttl > 0 {
+			e.WithTTL(time.Duration(ttl) * time.Second)
+		}
+		return txn.SetEntry(e)
+	})
+	return wrapErr(err, key)
+}
+
 // DeleteKey deletes a key.
 func (c *DBClient) DeleteKey(key string) error {
 	c.mu.Lock()
@@ -72,10 +295,11 @@ func (c *DBClient) DeleteKey(key string) error {
 	c.mu.Unlock()
 
 	if db == nil {
-		return fmt.Errorf("database not open")
+		return ErrNotOpen
 	}
 
-	return db.Update(func(txn *badger.Txn) error {
+	err := db.Update(func(txn *badger.Txn) error {
 		return txn.Delete([]byte(key))
 	})
+	return wrapErr(err, key)
 }
@@ -4,20 +4,22 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"badger_explorer_core/api"
+	"badger_explorer_core/cli"
+	"badger_explorer_core/codec"
 	"badger_explorer_core/config"
 	"badger_explorer_core/db"
 	"badger_explorer_core/locale"
+	"badger_explorer_core/server"
 	"badger_explorer_core/ui"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
-	standalone := flag.Bool("standalone", true, "Run in standalone TUI mode")
-	flag.Parse()
-
 	// Load Config
 	cfg, err := config.LoadConfig("config.json")
 	if err != nil {
@@ -25,6 +27,30 @@ func main() {
 		// For now, just proceed with defaults (which LoadConfig returns on error if not exist)
 	}
 
+	codec.SetMaxUnwrapSize(cfg.Codec.MaxDecompressedSize)
+
+	// Load protobuf descriptor sets for the value decoders
+	for _, path := range cfg.Codec.DescriptorSets {
+		if err := codec.LoadDescriptorSet(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load descriptor set: %v\n", err)
+		}
+	}
+
+	// Subcommands (e.g. "diff") run without the TUI or the subprocess protocol
+	if len(os.Args) > 1 {
+		if run, ok := cli.Lookup(os.Args[1]); ok {
+			cli.SetConfig(cfg)
+			os.Exit(run(os.Args[2:], os.Stdout, os.Stderr))
+		}
+	}
+
+	standalone := flag.Bool("standalone", true, "Run in standalone TUI mode")
+	jsonrpc := flag.Bool("jsonrpc", false, "Use JSON-RPC 2.0 messages in subprocess mode")
+	serve := flag.String("serve", "", "Serve the HTTP/WebSocket API on host:port or unix:/path/to.sock")
+	token := flag.String("token", os.Getenv("BADGER_EXPLORER_TOKEN"), "Token required by the HTTP server (default $BADGER_EXPLORER_TOKEN)")
+	dbPath := flag.String("db", "", "Open this DB at startup in server mode")
+	flag.Parse()
+
 	// Init Locale
 	if err := locale.Init(cfg.Localization); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to init locale: %v\n", err)
@@ -34,7 +60,38 @@ func main() {
 	dbClient := db.NewDBClient()
 	defer dbClient.Close()
 
-	if *standalone {
+	if *serve != "" {
+		// Server Mode; clients share the DB and cannot open another one
+		if *dbPath == "" {
+			fmt.Fprintln(os.Stderr, "-serve requires -db")
+			os.Exit(2)
+		}
+		if err := dbClient.Open(*dbPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open DB: %v\n", err)
+			os.Exit(1)
+		}
+		l, err := server.Listen(*serve)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to listen: %v\n", err)
+			os.Exit(1)
+		}
+		if *token == "" {
+			fmt.Fprintln(os.Stderr, "Warning: serving without a token, any local process can access the DB")
+		}
+		// Stop accepting on Ctrl+C so the DB is closed cleanly
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-stop
+			l.Close()
+		}()
+
+		fmt.Fprintf(os.Stderr, "Serving on %s\n", l.Addr())
+		if err := server.New(dbClient, cfg, *token).Serve(l); err != nil {
+			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if *standalone {
 		// TUI Mode
 		p := tea.NewProgram(ui.NewAppModel(cfg, dbClient), tea.WithAltScreen())
 		if _, err := p.Run(); err != nil {
@@ -44,6 +101,10 @@ func main() {
 	} else {
 		// Subprocess Mode
 		handler := api.NewHandler(dbClient, os.Stdout)
+		handler.SetConfig(cfg)
+		if *jsonrpc {
+			handler.SetJSONRPC(true)
+		}
 		handler.Run(os.Stdin)
 	}
 }
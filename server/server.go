@@ -0,0 +1,285 @@
+// Package server exposes the core over HTTP: a REST API for keys and a
+// WebSocket endpoint speaking the subprocess protocol of package api.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"badger_explorer_core/api"
+	"badger_explorer_core/config"
+	"badger_explorer_core/db"
+)
+
+// UnixPrefix marks a listen address as a Unix domain socket path, e.g. "unix:/tmp/bx.sock".
+const UnixPrefix = "unix:"
+
+// DefaultListLimit is the number of keys GET /keys returns without a limit parameter.
+const DefaultListLimit = 100
+
+// downloadChunkSize is the size of the reads streamed by GET /keys/{key}.
+const downloadChunkSize = 256 << 10
+
+// Server serves one DBClient over HTTP.
+type Server struct {
+	dbClient *db.DBClient
+	cfg      *config.Config // Optional, passed to the WebSocket handlers
+	token    string         // Empty disables authentication
+
+	// Largest value accepted by PUT /keys/{key}
+	maxUploadSize int64
+
+	mux *http.ServeMux
+}
+
+// New creates a server for client. A non-empty token must be sent with every
+// request, as "Authorization: Bearer <token>" or as the "token" query parameter.
+func New(client *db.DBClient, cfg *config.Config, token string) *Server {
+	s := &Server{
+		dbClient:      client,
+		cfg:           cfg,
+		token:         token,
+		maxUploadSize: api.DefaultUploadLimits.MaxSessionSize,
+		mux:           http.NewServeMux(),
+	}
+	s.mux.HandleFunc("GET /keys", s.handleList)
+	s.mux.HandleFunc("GET /keys/{key...}", s.handleGet)
+	s.mux.HandleFunc("PUT /keys/{key...}", s.handlePut)
+	s.mux.HandleFunc("DELETE /keys/{key...}", s.handleDelete)
+	s.mux.Handle("GET /ws", s.wsHandler())
+	return s
+}
+
+// SetMaxUploadSize sets the largest value PUT /keys/{key} accepts. Values <= 0 are ignored.
+func (s *Server) SetMaxUploadSize(size int64) {
+	if size > 0 {
+		s.maxUploadSize = size
+	}
+}
+
+// ServeHTTP checks the token and routes the request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, &api.Error{Code: api.CodeUnauthorized, Message: "invalid or missing token"})
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token := r.URL.Query().Get("token") // Browsers cannot set headers on WebSocket requests
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// Listen opens a TCP listener, or a Unix socket for addresses starting with UnixPrefix.
+// A stale socket file left by an earlier run is removed first; any other
+// file at the path is an error.
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := strings.CutPrefix(addr, UnixPrefix); ok {
+		info, err := os.Lstat(path)
+		switch {
+		case err == nil && info.Mode()&os.ModeSocket == 0:
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		case err == nil:
+			if err := os.Remove(path); err != nil {
+				return nil, err
+			}
+		case !os.IsNotExist(err):
+			return nil, err
+		}
+		return net.Listen("unix", path)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// Serve accepts connections on l until it is closed.
+func (s *Server) Serve(l net.Listener) error {
+	srv := &http.Server{Handler: s}
+	err := srv.Serve(l)
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// --- REST ---
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := db.ListKeysOptions{
+		Prefix:   q.Get("prefix"),
+		Mode:     q.Get("mode"),
+		SortDesc: q.Get("sort") == "desc",
+		Limit:    DefaultListLimit,
+
+		IgnoreCase:  q.Get("ignore_case") == "true",
+		CodecFilter: q.Get("codec"),
+	}
+	if opts.Mode == "" {
+		opts.Mode = "prefix"
+	}
+	if s.cfg != nil {
+		opts.PreviewCodec = s.cfg.CodecFor
+	}
+	for name, dst := range map[string]*int{"limit": &opts.Limit, "offset": &opts.Offset} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeError(w, http.StatusBadRequest, invalidParam(name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+
+	keys, hasMore, err := s.dbClient.ListKeys(opts)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, api.ListKeysResult{Keys: keys, HasMore: hasMore})
+}
+
+// handleGet streams the value. The status is only sent with the first chunk,
+// so a missing key is still reported as an error.
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	started := false
+	start := func() {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	_, err := s.dbClient.ReadValueChunks(key, downloadChunkSize, func(offset int64, chunk []byte) error {
+		if !started {
+			start()
+		}
+		_, err := w.Write(chunk)
+		return err
+	})
+	switch {
+	case err != nil && !started:
+		writeDBError(w, err)
+	case err == nil && !started:
+		start() // Empty value
+	}
+}
+
+// handlePut reads the request body as the new value. Query parameters:
+// "ttl" in seconds and "user_meta" (0-255).
+func (s *Server) handlePut(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	q := r.URL.Query()
+
+	ttl := 0
+	if v := q.Get("ttl"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, invalidParam("ttl", v))
+			return
+		}
+		ttl = n
+	}
+	userMeta := -1
+	if v := q.Get("user_meta"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 255 {
+			writeError(w, http.StatusBadRequest, invalidParam("user_meta", v))
+			return
+		}
+		userMeta = n
+	}
+
+	value, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxUploadSize))
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			writeError(w, http.StatusRequestEntityTooLarge, &api.Error{
+				Code:    api.CodeTooBig,
+				Message: fmt.Sprintf("value exceeds the maximum upload size of %d bytes", s.maxUploadSize),
+			})
+			return
+		}
+		writeError(w, http.StatusBadRequest, &api.Error{Code: api.CodeReadError, Message: "read error: " + err.Error()})
+		return
+	}
+
+	if userMeta >= 0 {
+		err = s.dbClient.SetValueWithMeta(key, value, ttl, byte(userMeta))
+	} else {
+		err = s.dbClient.SetValue(key, value, ttl)
+	}
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if err := s.dbClient.DeleteKey(r.PathValue("key")); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// --- Responses ---
+
+// errorBody is the JSON body of every error response. The codes are the
+// ones of the subprocess protocol.
+type errorBody struct {
+	Error *api.Error `json:"error"`
+}
+
+func invalidParam(name, value string) *api.Error {
+	return &api.Error{Code: api.CodeInvalidParams, Message: fmt.Sprintf("invalid %s: %q", name, value)}
+}
+
+// httpStatus maps protocol error codes to HTTP status codes.
+var httpStatus = map[int]int{
+	api.CodeInvalidParams: http.StatusBadRequest,
+	api.CodeForbidden:     http.StatusForbidden,
+	api.CodeNotOpen:       http.StatusServiceUnavailable,
+	api.CodeKeyNotFound:   http.StatusNotFound,
+	api.CodeReadOnly:      http.StatusForbidden,
+	api.CodeConflict:      http.StatusConflict,
+	api.CodeInvalidRegex:  http.StatusBadRequest,
+	api.CodeTooBig:        http.StatusRequestEntityTooLarge,
+	api.CodeKeyExists:     http.StatusConflict,
+}
+
+func writeDBError(w http.ResponseWriter, err error) {
+	e := api.NewError(err)
+	status, ok := httpStatus[e.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	writeError(w, status, e)
+}
+
+func writeError(w http.ResponseWriter, status int, e *api.Error) {
+	writeJSON(w, status, errorBody{Error: e})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"badger_explorer_core/api"
+	"badger_explorer_core/db"
+
+	"golang.org/x/net/websocket"
+)
+
+func openTestDB(t *testing.T) *db.DBClient {
+	tmpDir, err := os.MkdirTemp("", "badger-server-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+	client := db.NewDBClient()
+	if err := client.Open(tmpDir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func do(t *testing.T, method, url, token string, body io.Reader) (*http.Response, []byte) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, data
+}
+
+func TestREST(t *testing.T) {
+	client := openTestDB(t)
+	srv := httptest.NewServer(New(client, nil, "secret"))
+	defer srv.Close()
+
+	// Auth
+	if resp, _ := do(t, "GET", srv.URL+"/keys", "", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without token, got %d", resp.StatusCode)
+	}
+	if resp, _ := do(t, "GET", srv.URL+"/keys?token=secret", "", nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 with token parameter, got %d", resp.StatusCode)
+	}
+
+	// Put, including keys with slashes
+	value := bytes.Repeat([]byte("0123456789"), 100000) // More than one download chunk
+	if resp, body := do(t, "PUT", srv.URL+"/keys/user:1?ttl=3600&user_meta=7", "secret", bytes.NewReader(value)); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Put failed: %d %s", resp.StatusCode, body)
+	}
+	if resp, body := do(t, "PUT", srv.URL+"/keys/path/to/key", "secret", strings.NewReader("x")); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Put failed: %d %s", resp.StatusCode, body)
+	}
+
+	// Get
+	resp, body := do(t, "GET", srv.URL+"/keys/user:1", "secret", nil)
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(body, value) {
+		t.Errorf("Get returned %d with %d bytes", resp.StatusCode, len(body))
+	}
+	if _, body := do(t, "GET", srv.URL+"/keys/path/to/key", "secret", nil); string(body) != "x" {
+		t.Errorf("Expected x, got %q", body)
+	}
+
+	// List
+	resp, body = do(t, "GET", srv.URL+"/keys?prefix=user:", "secret", nil)
+	var list api.ListKeysResult
+	if err := json.Unmarshal(body, &list); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("List failed: %d %s", resp.StatusCode, body)
+	}
+	if len(list.Keys) != 1 || list.Keys[0].Key != "user:1" || list.Keys[0].ExpiresAt == 0 {
+		t.Errorf("Unexpected list: %+v", list.Keys)
+	}
+	if resp, _ := do(t, "GET", srv.URL+"/keys?limit=x", "secret", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for bad limit, got %d", resp.StatusCode)
+	}
+
+	// Delete, then not found with the protocol error code
+	if resp, _ := do(t, "DELETE", srv.URL+"/keys/user:1", "secret", nil); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Delete failed: %d", resp.StatusCode)
+	}
+	resp, body = do(t, "GET", srv.URL+"/keys/user:1", "secret", nil)
+	var e errorBody
+	json.Unmarshal(body, &e)
+	if resp.StatusCode != http.StatusNotFound || e.Error == nil || e.Error.Code != api.CodeKeyNotFound {
+		t.Errorf("Expected 404 with code %d, got %d %s", api.CodeKeyNotFound, resp.StatusCode, body)
+	}
+
+	// Upload limit
+	s := New(client, nil, "")
+	s.SetMaxUploadSize(10)
+	small := httptest.NewServer(s)
+	defer small.Close()
+	if resp, _ := do(t, "PUT", small.URL+"/keys/big", "", bytes.NewReader(value)); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d", resp.StatusCode)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	client := openTestDB(t)
+	client.SetValue("user:1", []byte("hello"), 0)
+
+	srv := httptest.NewServer(New(client, nil, "secret"))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?token=secret"
+	ws, err := websocket.Dial(wsURL, "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	send := func(line string) api.Response {
+		if err := websocket.Message.Send(ws, line); err != nil {
+			t.Fatal(err)
+		}
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			t.Fatal(err)
+		}
+		var resp api.Response
+		if err := json.Unmarshal([]byte(msg), &resp); err != nil {
+			t.Fatalf("Bad message %q: %v", msg, err)
+		}
+		return resp
+	}
+
+	resp := send(`{"id":"1","type":"get_value","params":{"key":"user:1"}}`)
+	if resp.Type != "get_value_resp" || resp.Error != nil {
+		t.Fatalf("Unexpected response: %+v", resp)
+	}
+	resp = send(`{"id":"2","type":"get_value","params":{"key":"missing"}}`)
+	if resp.Error == nil || resp.Error.Code != api.CodeKeyNotFound {
+		t.Errorf("Expected key not found, got %+v", resp)
+	}
+	// Connections share the DB, so none may close it for the others
+	resp = send(`{"id":"3","type":"close_db"}`)
+	if resp.Error == nil || resp.Error.Code != api.CodeForbidden || !client.IsOpen() {
+		t.Errorf("Expected close_db to be refused, got %+v", resp)
+	}
+	// nor open other DBs or read files on the host
+	other := filepath.Join(t.TempDir(), "other")
+	descriptors := filepath.Join(t.TempDir(), "set.pb")
+	os.WriteFile(descriptors, nil, 0o600)
+	for i, line := range []string{
+		fmt.Sprintf(`{"type":"open_db","params":{"path":%q}}`, other),
+		fmt.Sprintf(`{"type":"diff","params":{"path":%q}}`, other),
+		fmt.Sprintf(`{"type":"diff_apply","params":{"path":%q,"direction":"a_to_b"}}`, other),
+		fmt.Sprintf(`{"type":"load_descriptor_set","params":{"path":%q}}`, descriptors),
+	} {
+		resp := send(fmt.Sprintf(`{"id":"s%d",`, i) + line[1:])
+		if resp.Error == nil || resp.Error.Code != api.CodeForbidden {
+			t.Errorf("Expected %s to be refused, got %+v", line, resp)
+		}
+	}
+	if _, err := os.Stat(other); !os.IsNotExist(err) {
+		t.Errorf("Expected %s not to be created, got %v", other, err)
+	}
+
+	// Without the token the upgrade is refused
+	if _, err := websocket.Dial(strings.TrimSuffix(wsURL, "?token=secret"), "", srv.URL); err == nil {
+		t.Error("Expected WebSocket without token to fail")
+	}
+}
+
+func TestUnixSocket(t *testing.T) {
+	client := openTestDB(t)
+	client.SetValue("k", []byte("v"), 0)
+
+	// Other files are never removed
+	file := filepath.Join(t.TempDir(), "data")
+	os.WriteFile(file, []byte("keep"), 0o600)
+	if _, err := Listen(UnixPrefix + file); err == nil {
+		t.Error("Expected error for a path that is not a socket")
+	}
+	if data, _ := os.ReadFile(file); string(data) != "keep" {
+		t.Errorf("File at the listen path was changed: %q", data)
+	}
+
+	// Stale socket from an earlier run
+	sock := filepath.Join(t.TempDir(), "bx.sock")
+	stale, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	l, err := Listen(UnixPrefix + sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- New(client, nil, "").Serve(l) }()
+
+	hc := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", sock)
+		},
+	}}
+	resp, err := hc.Get("http://unix/keys/k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "v" {
+		t.Errorf("Expected v, got %q", body)
+	}
+
+	l.Close()
+	if err := <-done; err != nil {
+		t.Errorf("Serve returned %v after close", err)
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"badger_explorer_core/api"
+
+	"golang.org/x/net/websocket"
+)
+
+// wsHandler runs an api.Handler per WebSocket connection. Each text message
+// carries one JSON line of the subprocess protocol in either direction.
+// After a "framing" request for length_prefixed, frames travel as binary messages.
+func (s *Server) wsHandler() http.Handler {
+	return websocket.Server{
+		Handshake: s.checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			// Stop scans once the client is gone
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			h := api.NewHandler(s.dbClient, &wsWriter{ws: ws})
+			h.SetSharedDB(true)
+			h.SetContext(ctx)
+			if s.cfg != nil {
+				h.SetConfig(s.cfg)
+				ws.MaxPayloadBytes = s.cfg.API.MaxFrameSize
+			}
+
+			pr, pw := io.Pipe()
+			go func() {
+				pw.CloseWithError(readMessages(ws, pw))
+				cancel()
+			}()
+			h.Run(pr)
+			pr.Close()
+		},
+	}
+}
+
+// checkOrigin rejects cross-site pages when no token protects the server.
+// With a token the page must already know it, so any origin is accepted.
+func (s *Server) checkOrigin(cfg *websocket.Config, r *http.Request) error {
+	if s.token != "" || r.Header.Get("Origin") == "" {
+		return nil
+	}
+	origin, err := websocket.Origin(cfg, r)
+	if err != nil {
+		return err
+	}
+	host := origin.Hostname()
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+	return fmt.Errorf("origin not allowed: %s", origin)
+}
+
+// message is one received WebSocket message.
+type message struct {
+	data   []byte
+	binary bool
+}
+
+var messageCodec = websocket.Codec{
+	Marshal: func(v interface{}) ([]byte, byte, error) {
+		m := v.(message)
+		if m.binary {
+			return m.data, websocket.BinaryFrame, nil
+		}
+		return m.data, websocket.TextFrame, nil
+	},
+	Unmarshal: func(data []byte, payloadType byte, v interface{}) error {
+		*v.(*message) = message{data: data, binary: payloadType == websocket.BinaryFrame}
+		return nil
+	},
+}
+
+// readMessages turns messages into the byte stream api.Handler.Run reads:
+// text messages become lines, binary messages are passed through as frames.
+func readMessages(ws *websocket.Conn, w io.Writer) error {
+	for {
+		var m message
+		if err := messageCodec.Receive(ws, &m); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if !m.binary && !bytes.HasSuffix(m.data, []byte("\n")) {
+			m.data = append(m.data, '\n')
+		}
+		if _, err := w.Write(m.data); err != nil {
+			return err
+		}
+	}
+}
+
+// wsWriter sends every write of the handler as one message. The handler
+// writes a whole JSON line or frame at a time; frames start with a length,
+// never with the '{' or '[' of a JSON line.
+type wsWriter struct {
+	ws *websocket.Conn
+}
+
+func (w *wsWriter) Write(p []byte) (int, error) {
+	m := message{data: p, binary: true}
+	if line, ok := bytes.CutSuffix(p, []byte("\n")); ok && len(line) > 0 && (line[0] == '{' || line[0] == '[') {
+		m = message{data: line}
+	}
+	if err := messageCodec.Send(w.ws, m); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -16,6 +16,10 @@ const (
 	stateDetail
 	stateInsert
 	stateConfig
+	stateDiff
+	stateCodecRules
+	stateSavedQueries
+	stateBookmarks
 )
 
 type AppModel struct {
@@ -23,12 +27,16 @@ type AppModel struct {
 	cfg      *config.Config
 	dbClient *db.DBClient
 
-	welcome  WelcomeModel
-	dbPicker DBPickerModel
-	dbMain   DBMainModel
-	detail   DetailModel
-	insert   InsertModel
-	config   ConfigModel
+	welcome   WelcomeModel
+	dbPicker  DBPickerModel
+	dbMain    DBMainModel
+	detail    DetailModel
+	insert    InsertModel
+	config    ConfigModel
+	diff      DiffModel
+	rules     CodecRulesModel
+	queries   SavedQueriesModel
+	bookmarks BookmarksModel
 
 	width  int
 	height int
@@ -45,6 +53,8 @@ func NewAppModel(cfg *config.Config, dbClient *db.DBClient) AppModel {
 		detail:   NewDetailModel(dbClient, cfg, ""), // Empty key initially
 		insert:   NewInsertModel(dbClient, cfg),
 		config:   NewConfigModel(cfg),
+		diff:     NewDiffModel(dbClient, cfg, "", cfg.Search.DefaultMode),
+		rules:    NewCodecRulesModel(cfg),
 	}
 }
 
@@ -82,6 +92,9 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		updatedConfig, _ := updateModel(m.config, msg)
 		m.config = updatedConfig.(ConfigModel)
 
+		updatedDiff, _ := updateModel(m.diff, msg)
+		m.diff = updatedDiff.(DiffModel)
+
 	// Navigation Messages
 	case OpenPickerMsg:
 		m.state = stateDBPicker
@@ -95,6 +108,17 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.config = NewConfigModel(m.cfg)
 		return m, m.config.Init()
 
+	case OpenCodecRulesMsg:
+		m.state = stateCodecRules
+		m.rules = NewCodecRulesModel(m.cfg)
+		return m, m.rules.Init()
+
+	case LiveEventsMsg, KeyCountMsg:
+		// Live updates and counts keep flowing while other screens are shown
+		newModel, newCmd := m.dbMain.Update(msg)
+		m.dbMain = newModel.(DBMainModel)
+		return m, newCmd
+
 	case OpenDBMsg:
 		// Try to open DB
 		err := m.dbClient.Open(msg.Path) // Always RW
@@ -123,6 +147,8 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, m.dbMain.Init()
 
 	case BackToWelcomeMsg:
+		m.dbMain.stopLive()
+		m.dbMain.stopCount()
 		if m.dbClient.IsOpen() {
 			m.dbClient.Close()
 		}
@@ -142,8 +168,10 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case BackToMainMsg:
 		m.state = stateDBMain
-		// Maybe refresh list?
-		return m, nil // Main model keeps state
+		// Main model keeps its search and page; keys may have been renamed,
+		// deleted or pinned in the detail view
+		m.dbMain.updateTable()
+		return m, m.dbMain.fetchKeysCmd()
 
 	case OpenInsertMsg:
 		m.state = stateInsert
@@ -151,6 +179,28 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		updatedModel, _ := updateModel(m.insert, tea.WindowSizeMsg{Width: m.width, Height: m.height})
 		m.insert = updatedModel.(InsertModel)
 		return m, m.insert.Init()
+
+	case OpenSavedQueriesMsg:
+		m.state = stateSavedQueries
+		m.queries = NewSavedQueriesModel(m.cfg, msg.Current)
+		return m, m.queries.Init()
+
+	case OpenBookmarksMsg:
+		m.state = stateBookmarks
+		m.bookmarks = NewBookmarksModel(m.dbClient, m.cfg)
+		return m, m.bookmarks.Init()
+
+	case ApplySavedQueryMsg:
+		m.state = stateDBMain
+		cmd := m.dbMain.applyQuery(msg.Query)
+		return m, cmd
+
+	case OpenDiffMsg:
+		m.state = stateDiff
+		m.diff = NewDiffModel(m.dbClient, m.cfg, msg.Prefix, msg.Mode)
+		updatedDiff, _ := updateModel(m.diff, tea.WindowSizeMsg{Width: m.width, Height: m.height})
+		m.diff = updatedDiff.(DiffModel)
+		return m, m.diff.Init()
 	}
 
 	// Delegate Update
@@ -179,6 +229,22 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		newModel, newCmd := m.config.Update(msg)
 		m.config = newModel.(ConfigModel)
 		cmd = newCmd
+	case stateDiff:
+		newModel, newCmd := m.diff.Update(msg)
+		m.diff = newModel.(DiffModel)
+		cmd = newCmd
+	case stateCodecRules:
+		newModel, newCmd := m.rules.Update(msg)
+		m.rules = newModel.(CodecRulesModel)
+		cmd = newCmd
+	case stateSavedQueries:
+		newModel, newCmd := m.queries.Update(msg)
+		m.queries = newModel.(SavedQueriesModel)
+		cmd = newCmd
+	case stateBookmarks:
+		newModel, newCmd := m.bookmarks.Update(msg)
+		m.bookmarks = newModel.(BookmarksModel)
+		cmd = newCmd
 	}
 
 	cmds = append(cmds, cmd)
@@ -199,6 +265,14 @@ func (m AppModel) View() string {
 		return m.insert.View()
 	case stateConfig:
 		return m.config.View()
+	case stateDiff:
+		return m.diff.View()
+	case stateCodecRules:
+		return m.rules.View()
+	case stateSavedQueries:
+		return m.queries.View()
+	case stateBookmarks:
+		return m.bookmarks.View()
 	}
 	return "Unknown state"
 }
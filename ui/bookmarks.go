@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"badger_explorer_core/config"
+	"badger_explorer_core/db"
+	"badger_explorer_core/pkg"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// BookmarksModel shows the pinned keys of the open DB with their current values.
+type BookmarksModel struct {
+	dbClient *db.DBClient
+	cfg      *config.Config
+	styles   pkg.Styles
+
+	items  []db.KeyStatus
+	cursor int
+
+	// Versions seen by the previous refresh, to flag keys changed since
+	versions map[string]uint64
+	changed  map[string]bool
+
+	err error
+}
+
+func NewBookmarksModel(client *db.DBClient, cfg *config.Config) BookmarksModel {
+	return BookmarksModel{
+		dbClient: client,
+		cfg:      cfg,
+		styles:   pkg.DefaultStyles(),
+	}
+}
+
+func (m BookmarksModel) Init() tea.Cmd {
+	return m.fetchCmd()
+}
+
+func (m BookmarksModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case BookmarksFetchedMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+			return m, nil
+		}
+		m.err = nil
+		m.changed = make(map[string]bool)
+		for _, item := range msg.Items {
+			if v, ok := m.versions[item.Key]; ok && v != item.Version {
+				m.changed[item.Key] = true
+			}
+		}
+		m.versions = make(map[string]uint64)
+		for _, item := range msg.Items {
+			m.versions[item.Key] = item.Version
+		}
+		m.items = msg.Items
+		if m.cursor >= len(m.items) {
+			m.cursor = max(len(m.items)-1, 0)
+		}
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return m, func() tea.Msg { return BackToMainMsg{} }
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.items)-1 {
+				m.cursor++
+			}
+		case "enter":
+			if len(m.items) > 0 && m.items[m.cursor].Exists {
+				key := m.items[m.cursor].Key
+				return m, func() tea.Msg { return OpenDetailMsg{Key: key} }
+			}
+		case "r":
+			return m, m.fetchCmd()
+		case "d", "b":
+			if len(m.items) > 0 {
+				m.cfg.RemoveBookmark(m.dbClient.GetPath(), m.items[m.cursor].Key)
+				m.err = m.cfg.Save()
+				return m, m.fetchCmd()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m BookmarksModel) View() string {
+	s := strings.Builder{}
+
+	s.WriteString(m.styles.Title.Render("Bookmarks") + "\n\n")
+
+	if m.err != nil {
+		s.WriteString(m.styles.Error.Render(m.err.Error()) + "\n")
+	}
+
+	if len(m.items) == 0 {
+		s.WriteString(m.styles.Dimmed.Render("No bookmarks. Press b on a key to pin it.") + "\n")
+	}
+	for i, item := range m.items {
+		var line string
+		if item.Exists {
+			line = fmt.Sprintf("%s = %s", item.Key, item.Preview)
+		} else {
+			line = item.Key
+		}
+		if i == m.cursor {
+			line = m.styles.Highlight.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+
+		info := "(missing)"
+		if item.Exists {
+			info = fmt.Sprintf("v%d, %d bytes", item.Version, item.Size)
+			if item.ExpiresAt > 0 {
+				info += ", expires " + time.Unix(int64(item.ExpiresAt), 0).Format(time.DateTime)
+			}
+		}
+		if m.changed[item.Key] {
+			info += " changed"
+		}
+		s.WriteString(line + " " + m.styles.Dimmed.Render(info) + "\n")
+	}
+
+	s.WriteString("\n" + m.styles.Help.Render("Enter: Detail | d: Unpin | r: Refresh | Esc: Back"))
+
+	return s.String()
+}
+
+// BookmarksFetchedMsg carries the current state of the pinned keys.
+type BookmarksFetchedMsg struct {
+	Items []db.KeyStatus
+	Err   error
+}
+
+func (m BookmarksModel) fetchCmd() tea.Cmd {
+	client, cfg := m.dbClient, m.cfg
+	return func() tea.Msg {
+		keys := cfg.GetBookmarks(client.GetPath())
+		items, err := client.LookupKeys(keys, db.ListKeysOptions{
+			PreviewChars: cfg.UI.PreviewChars,
+			PreviewCodec: cfg.CodecFor,
+		})
+		return BookmarksFetchedMsg{Items: items, Err: err}
+	}
+}
+
+// OpenBookmarksMsg opens the bookmarks of the open DB.
+type OpenBookmarksMsg struct{}
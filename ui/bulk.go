@@ -0,0 +1,347 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"badger_explorer_core/db"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxSelection caps "select all matching" so a broad search cannot load every key.
+const maxSelection = 100000
+
+// Bulk actions on the selected keys
+const (
+	bulkDelete   = "delete"
+	bulkExport   = "export"
+	bulkTTL      = "ttl"
+	bulkMoveFrom = "move_from"
+	bulkMoveTo   = "move_to"
+	bulkRename   = "rename"
+	bulkCopy     = "copy"
+)
+
+// bulkPrompt asks for the argument of a bulk action before running it.
+type bulkPrompt struct {
+	action string
+	keys   []string
+	input  textinput.Model
+	from   string // Old key or prefix, once known
+	prefix bool   // Rename or copy every key under from
+}
+
+// SelectionMsg carries the keys of "select all matching".
+type SelectionMsg struct {
+	Keys []string
+	More bool // More keys matched than maxSelection
+	Err  error
+}
+
+// BulkDoneMsg reports the outcome of a bulk action.
+type BulkDoneMsg struct {
+	Summary string
+	Err     error
+}
+
+// selectedKeys returns the selection in key order, or the key under the
+// cursor when nothing is selected.
+func (m DBMainModel) selectedKeys() []string {
+	if len(m.selected) == 0 {
+		if key, ok := m.cursorKey(); ok {
+			return []string{key}
+		}
+		return nil
+	}
+	keys := make([]string, 0, len(m.selected))
+	for key := range m.selected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toggleSelect flips the selection of the row under the cursor and starts a range there.
+func (m *DBMainModel) toggleSelect() {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.keys) {
+		return
+	}
+	if m.selected == nil {
+		m.selected = make(map[string]bool)
+	}
+	key := m.keys[i].Key
+	if m.selected[key] {
+		delete(m.selected, key)
+	} else {
+		m.selected[key] = true
+	}
+	m.anchor = i
+	m.updateTable()
+}
+
+// selectRange selects the rows between the last toggled row and the cursor.
+func (m *DBMainModel) selectRange() {
+	from, to := m.anchor, m.table.Cursor()
+	if from > to {
+		from, to = to, from
+	}
+	if m.selected == nil {
+		m.selected = make(map[string]bool)
+	}
+	for i := max(from, 0); i <= to && i < len(m.keys); i++ {
+		m.selected[m.keys[i].Key] = true
+	}
+	m.updateTable()
+}
+
+// selectAllCmd selects every key matching the search, not only the loaded page.
+func (m DBMainModel) selectAllCmd() tea.Cmd {
+	client, opts := m.dbClient, m.listOptions()
+	return func() tea.Msg {
+		keys, more, err := client.MatchingKeys(context.Background(), opts, maxSelection)
+		return SelectionMsg{Keys: keys, More: more, Err: err}
+	}
+}
+
+// startBulk opens the prompt of a bulk action on the selected keys.
+func (m *DBMainModel) startBulk(action string) tea.Cmd {
+	keys := m.selectedKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+
+	in := textinput.New()
+	in.CharLimit = 1024
+	switch action {
+	case bulkDelete:
+		in.Prompt = fmt.Sprintf("Delete %d keys? (y/n) ", len(keys))
+	case bulkExport:
+		in.Prompt = "Export to: "
+		in.SetValue(fmt.Sprintf("export-%s.jsonl", time.Now().Format("20060102-150405")))
+	case bulkTTL:
+		in.Prompt = "TTL in seconds (0 clears): "
+	case bulkMoveFrom:
+		in.Prompt = "Move keys from prefix: "
+		in.SetValue(sharedPrefix(keys))
+	}
+	in.CursorEnd()
+	m.bulk = &bulkPrompt{action: action, keys: keys, input: in}
+	m.status = ""
+	m.err = nil
+	return m.bulk.input.Focus()
+}
+
+// startRename asks for the new name of the key under the cursor. Tab
+// switches to renaming (or copying) a whole prefix.
+func (m *DBMainModel) startRename(action string) tea.Cmd {
+	key, ok := m.cursorKey()
+	if !ok {
+		return nil
+	}
+	in := textinput.New()
+	in.CharLimit = 1024
+	m.bulk = &bulkPrompt{action: action, keys: []string{key}, input: in}
+	m.bulk.setRenameStep(key)
+	m.status = ""
+	m.err = nil
+	return m.bulk.input.Focus()
+}
+
+// setRenameStep asks for the target of from, or for the source prefix when from is empty.
+func (p *bulkPrompt) setRenameStep(from string) {
+	verb := "Rename"
+	if p.action == bulkCopy {
+		verb = "Copy"
+	}
+	p.from = from
+	switch {
+	case from == "":
+		p.input.Prompt = verb + " prefix: "
+		p.input.SetValue(keyPrefix(p.keys[0]))
+	case p.prefix:
+		p.input.Prompt = fmt.Sprintf("%s prefix %q to: ", verb, from)
+	default:
+		p.input.Prompt = fmt.Sprintf("%s %q to: ", verb, from)
+	}
+	p.input.CursorEnd()
+}
+
+func (m DBMainModel) updateBulk(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.bulk
+	if msg.String() == "esc" {
+		m.bulk = nil
+		return m, nil
+	}
+	if p.action == bulkRename || p.action == bulkCopy {
+		switch msg.String() {
+		case "tab":
+			p.prefix = !p.prefix
+			if p.prefix {
+				p.setRenameStep("")
+			} else {
+				p.input.SetValue(p.keys[0])
+				p.setRenameStep(p.keys[0])
+			}
+			return m, nil
+		case "enter":
+			if p.from == "" {
+				p.setRenameStep(p.input.Value())
+				return m, nil
+			}
+			m.bulk = nil
+			return m, m.renameCmd(p.action, p.prefix, p.from, p.input.Value())
+		}
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return m, cmd
+	}
+	if p.action == bulkDelete {
+		switch msg.String() {
+		case "y", "Y":
+			m.bulk = nil
+			return m, m.bulkCmd(bulkDelete, p.keys, "", "")
+		case "n", "N", "enter":
+			m.bulk = nil
+		}
+		return m, nil
+	}
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return m, cmd
+	}
+
+	value := p.input.Value()
+	switch p.action {
+	case bulkTTL:
+		if _, err := strconv.Atoi(strings.TrimSpace(value)); err != nil {
+			m.err = fmt.Errorf("invalid TTL: %s", value)
+			return m, nil
+		}
+	case bulkMoveFrom:
+		// Ask for the new prefix next
+		p.from = value
+		p.action = bulkMoveTo
+		p.input.Prompt = fmt.Sprintf("Move %q to prefix: ", value)
+		return m, nil
+	}
+	m.bulk = nil
+	m.err = nil
+	return m, m.bulkCmd(p.action, p.keys, p.from, value)
+}
+
+// bulkCmd runs a bulk action; arg is the argument entered in the prompt.
+func (m DBMainModel) bulkCmd(action string, keys []string, from, arg string) tea.Cmd {
+	client := m.dbClient
+	return func() tea.Msg {
+		var res db.BulkResult
+		var err error
+		var done string // Summary with a %d for the number of keys
+		switch action {
+		case bulkDelete:
+			done = "Deleted %d keys"
+			res, err = client.DeleteKeys(keys)
+		case bulkExport:
+			done = "Exported %d keys to " + arg
+			var f *os.File
+			if f, err = os.Create(arg); err != nil {
+				return BulkDoneMsg{Err: err}
+			}
+			res, err = client.ExportKeys(f, keys)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		case bulkTTL:
+			ttl, _ := strconv.Atoi(strings.TrimSpace(arg))
+			done = "Updated the TTL of %d keys"
+			res, err = client.SetTTLKeys(keys, ttl)
+		case bulkMoveTo:
+			done = fmt.Sprintf("Moved %%d keys from %q to %q", from, arg)
+			res, err = client.RewritePrefix(keys, from, arg)
+		}
+		if err != nil {
+			return BulkDoneMsg{Err: err}
+		}
+		summary := fmt.Sprintf(done, res.Done)
+		if len(res.Skipped) > 0 {
+			summary += fmt.Sprintf(", skipped %d", len(res.Skipped))
+		}
+		return BulkDoneMsg{Summary: summary}
+	}
+}
+
+// renameCmd renames or copies a key, or every key under a prefix.
+func (m DBMainModel) renameCmd(action string, prefix bool, from, to string) tea.Cmd {
+	client := m.dbClient
+	return func() tea.Msg {
+		verb := "Renamed"
+		if action == bulkCopy {
+			verb = "Copied"
+		}
+		if !prefix {
+			var err error
+			if action == bulkCopy {
+				err = client.CopyKey(from, to, false)
+			} else {
+				err = client.RenameKey(from, to, false)
+			}
+			if err != nil {
+				return BulkDoneMsg{Err: err}
+			}
+			return BulkDoneMsg{Summary: fmt.Sprintf("%s %q to %q", verb, from, to)}
+		}
+
+		var res db.BulkResult
+		var err error
+		if action == bulkCopy {
+			res, err = client.CopyPrefix(context.Background(), from, to, false)
+		} else {
+			res, err = client.RenamePrefix(context.Background(), from, to, false)
+		}
+		summary := fmt.Sprintf("%s %d keys from %q to %q", verb, res.Done, from, to)
+		if len(res.Skipped) > 0 {
+			summary += fmt.Sprintf(", skipped %d existing targets", len(res.Skipped))
+		}
+		// Chunks committed before an error stay applied
+		if err != nil {
+			return BulkDoneMsg{Err: fmt.Errorf("%s: %w", summary, err)}
+		}
+		return BulkDoneMsg{Summary: summary}
+	}
+}
+
+// copyKeys puts the selected keys on the clipboard, one per line.
+func (m *DBMainModel) copyKeys() tea.Cmd {
+	keys := m.selectedKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+	return copyTextCmd(strings.Join(keys, "\n"), fmt.Sprintf("%d keys", len(keys)))
+}
+
+// keyPrefix drops the last segment of key: "user:1:name" becomes "user:1:".
+func keyPrefix(key string) string {
+	return key[:strings.LastIndexAny(key, ":/")+1]
+}
+
+// sharedPrefix returns the longest prefix shared by all of keys.
+func sharedPrefix(keys []string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	prefix := keys[0]
+	for _, k := range keys[1:] {
+		for !strings.HasPrefix(k, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
@@ -0,0 +1,209 @@
+package ui
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"badger_explorer_core/codec"
+	"badger_explorer_core/db"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Where copied text ends up
+const (
+	toSystem   = "clipboard"
+	toTerminal = "terminal clipboard"
+	toMemory   = "internal clipboard"
+)
+
+// clipboardBuffer keeps the last copied text, so paste works without a system clipboard.
+var clipboardBuffer struct {
+	sync.Mutex
+	text string
+}
+
+// osc52Output receives the OSC52 sequences; the terminal shares it with the UI.
+var osc52Output io.Writer = os.Stderr
+
+// copyToClipboard puts text on the system clipboard and returns where it
+// went. Over SSH, or when there is no system clipboard, it asks the terminal
+// to copy it with an OSC52 sequence instead. The text is also kept in
+// memory for pasting within the app.
+func copyToClipboard(text string) string {
+	clipboardBuffer.Lock()
+	clipboardBuffer.text = text
+	clipboardBuffer.Unlock()
+
+	if !overSSH() && clipboard.WriteAll(text) == nil {
+		return toSystem
+	}
+	if !isTerminal(osc52Output) {
+		return toMemory
+	}
+	seq := osc52.New(text)
+	switch {
+	case os.Getenv("TMUX") != "":
+		seq = seq.Tmux()
+	case strings.HasPrefix(os.Getenv("TERM"), "screen"):
+		seq = seq.Screen()
+	}
+	if _, err := seq.WriteTo(osc52Output); err != nil {
+		return toMemory
+	}
+	return toTerminal
+}
+
+// pasteFromClipboard reads the system clipboard, or the text copied last in
+// this session when there is none. Over SSH the terminal's own paste (which
+// arrives as typed text) reaches the remote clipboard instead.
+func pasteFromClipboard() (string, error) {
+	if !overSSH() {
+		if text, err := clipboard.ReadAll(); err == nil && text != "" {
+			return text, nil
+		}
+	}
+	clipboardBuffer.Lock()
+	defer clipboardBuffer.Unlock()
+	if clipboardBuffer.text == "" {
+		return "", fmt.Errorf("clipboard is empty")
+	}
+	return clipboardBuffer.text, nil
+}
+
+// pasteMsg types the clipboard into the focused input.
+func pasteMsg() (tea.Msg, error) {
+	text, err := pasteFromClipboard()
+	if err != nil {
+		return nil, err
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text), Paste: true}, nil
+}
+
+func overSSH() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Formats of copied values
+const (
+	copyRaw     = "raw"
+	copyDecoded = "decoded"
+	copyHex     = "hex"
+	copyBase64  = "base64"
+	copyCommand = "command"
+)
+
+// copyFormatKeys maps the keys of the copy prompt to formats.
+var copyFormatKeys = map[string]string{
+	"r": copyRaw,
+	"d": copyDecoded,
+	"h": copyHex,
+	"b": copyBase64,
+	"c": copyCommand,
+}
+
+// copyFormatHelp is the footer of the copy prompt.
+const copyFormatHelp = "Copy value as: r: Raw | d: Decoded | h: Hex | b: Base64 | c: Command | Esc: Cancel"
+
+// CopiedMsg reports the outcome of a copy done in the background.
+type CopiedMsg struct {
+	What   string
+	Target string
+	Err    error
+}
+
+func (msg CopiedMsg) status() string {
+	return fmt.Sprintf("Copied %s to the %s", msg.What, msg.Target)
+}
+
+// copyTextCmd copies text in the background; what names it in the status.
+func copyTextCmd(text, what string) tea.Cmd {
+	return func() tea.Msg {
+		return CopiedMsg{What: what, Target: copyToClipboard(text)}
+	}
+}
+
+// copyValueCmd reads the whole value of key and copies it in format.
+// codecName decodes the value for copyDecoded.
+func copyValueCmd(client *db.DBClient, key, format, codecName string) tea.Cmd {
+	return func() tea.Msg {
+		val, err := client.GetValue(key)
+		if err != nil {
+			return CopiedMsg{Err: err}
+		}
+		var text string
+		switch format {
+		case copyRaw:
+			text = string(val)
+		case copyDecoded:
+			d, err := codec.Decode(codecName, val)
+			if err != nil {
+				return CopiedMsg{Err: err}
+			}
+			text = d.Text
+		case copyHex:
+			text = hex.EncodeToString(val)
+		case copyBase64:
+			text = base64.StdEncoding.EncodeToString(val)
+		case copyCommand:
+			info, err := client.GetKeyInfo(key)
+			if err != nil {
+				return CopiedMsg{Err: err}
+			}
+			text = putCommand(client.GetPath(), info, val)
+		}
+		what := "value as " + format
+		if format == copyCommand {
+			what = "put command"
+		}
+		return CopiedMsg{What: what, Target: copyToClipboard(text)}
+	}
+}
+
+// putCommand returns a shell command that writes the key back with the CLI,
+// keeping its remaining TTL and user meta. Binary values go through base64.
+// Key and value follow "--", so they may start with "-".
+func putCommand(path string, info db.KeyInfo, val []byte) string {
+	var flags string
+	if info.ExpiresAt > 0 {
+		if left := int(time.Until(time.Unix(int64(info.ExpiresAt), 0)).Seconds()); left > 0 {
+			flags += fmt.Sprintf(" --ttl %d", left)
+		}
+	}
+	if info.UserMeta != 0 {
+		flags += fmt.Sprintf(" --meta %d", info.UserMeta)
+	}
+
+	put := fmt.Sprintf("%s put --db %s%s -- %s", filepath.Base(os.Args[0]), shellQuote(path), flags, shellQuote(info.Key))
+	if utf8.Valid(val) && !strings.ContainsRune(string(val), 0) {
+		return fmt.Sprintf("%s %s", put, shellQuote(string(val)))
+	}
+	return fmt.Sprintf("echo %s | base64 -d | %s", base64.StdEncoding.EncodeToString(val), put)
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.:/@%+=,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
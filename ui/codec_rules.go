@@ -0,0 +1,268 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"badger_explorer_core/codec"
+	"badger_explorer_core/config"
+	"badger_explorer_core/pkg"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CodecRulesModel manages the ordered key-pattern → codec rules.
+type CodecRulesModel struct {
+	cfg    *config.Config
+	styles pkg.Styles
+
+	rules  []config.CodecRule
+	cursor int
+
+	// Rule editor
+	isEditing bool
+	editIndex int // -1 for a new rule
+	inputs    []textinput.Model
+	focus     int
+
+	err error
+	msg string
+}
+
+func NewCodecRulesModel(cfg *config.Config) CodecRulesModel {
+	inputs := make([]textinput.Model, 4)
+
+	inputs[0] = textinput.New()
+	inputs[0].Placeholder = "user:*"
+	inputs[0].Prompt = "Pattern: "
+
+	inputs[1] = textinput.New()
+	inputs[1].Placeholder = "false"
+	inputs[1].Prompt = "Regex (true/false): "
+
+	inputs[2] = textinput.New()
+	inputs[2].Placeholder = strings.Join(codec.Names()[1:], ", ")
+	inputs[2].Prompt = "Codec: "
+
+	inputs[3] = textinput.New()
+	inputs[3].Placeholder = "acme.v1.User (protobuf only)"
+	inputs[3].Prompt = "Message: "
+
+	return CodecRulesModel{
+		cfg:       cfg,
+		styles:    pkg.DefaultStyles(),
+		rules:     cfg.GetCodecRules(),
+		inputs:    inputs,
+		editIndex: -1,
+	}
+}
+
+func (m CodecRulesModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m CodecRulesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.isEditing {
+			return m.updateEditor(msg)
+		}
+
+		switch msg.String() {
+		case "esc":
+			return m, func() tea.Msg { return OpenConfigMsg{} }
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.rules)-1 {
+				m.cursor++
+			}
+		case "K":
+			// Move up (earlier rules win)
+			if m.cursor > 0 {
+				m.rules[m.cursor-1], m.rules[m.cursor] = m.rules[m.cursor], m.rules[m.cursor-1]
+				m.cursor--
+			}
+		case "J":
+			if m.cursor < len(m.rules)-1 {
+				m.rules[m.cursor+1], m.rules[m.cursor] = m.rules[m.cursor], m.rules[m.cursor+1]
+				m.cursor++
+			}
+		case "a":
+			return m, m.openEditor(-1)
+		case "e", "enter":
+			if len(m.rules) > 0 {
+				return m, m.openEditor(m.cursor)
+			}
+		case "d":
+			if len(m.rules) > 0 {
+				m.rules = append(m.rules[:m.cursor], m.rules[m.cursor+1:]...)
+				if m.cursor >= len(m.rules) && m.cursor > 0 {
+					m.cursor--
+				}
+			}
+		case "ctrl+s":
+			return m, m.saveCmd()
+		}
+
+	case OperationResultMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+		} else {
+			m.err = nil
+			m.msg = msg.Message
+		}
+	}
+
+	return m, nil
+}
+
+func (m *CodecRulesModel) openEditor(index int) tea.Cmd {
+	m.isEditing = true
+	m.editIndex = index
+	m.err = nil
+
+	rule := config.CodecRule{}
+	if index >= 0 {
+		rule = m.rules[index]
+	}
+	m.inputs[0].SetValue(rule.Pattern)
+	m.inputs[1].SetValue(strconv.FormatBool(rule.Regex))
+	m.inputs[2].SetValue(rule.Codec)
+	m.inputs[3].SetValue(rule.Message)
+
+	m.focus = 0
+	for i := range m.inputs {
+		m.inputs[i].Blur()
+	}
+	return m.inputs[0].Focus()
+}
+
+func (m CodecRulesModel) updateEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.isEditing = false
+		return m, nil
+	case "tab", "down":
+		m.inputs[m.focus].Blur()
+		m.focus = (m.focus + 1) % len(m.inputs)
+		return m, m.inputs[m.focus].Focus()
+	case "shift+tab", "up":
+		m.inputs[m.focus].Blur()
+		m.focus = (m.focus - 1 + len(m.inputs)) % len(m.inputs)
+		return m, m.inputs[m.focus].Focus()
+	case "enter":
+		rule, err := m.ruleFromInputs()
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		if m.editIndex < 0 {
+			m.rules = append(m.rules, rule)
+			m.cursor = len(m.rules) - 1
+		} else {
+			m.rules[m.editIndex] = rule
+		}
+		m.isEditing = false
+		m.err = nil
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+// ruleFromInputs validates the editor fields.
+func (m CodecRulesModel) ruleFromInputs() (config.CodecRule, error) {
+	rule := config.CodecRule{
+		Pattern: strings.TrimSpace(m.inputs[0].Value()),
+		Codec:   strings.TrimSpace(m.inputs[2].Value()),
+		Message: strings.TrimSpace(m.inputs[3].Value()),
+	}
+	if rule.Pattern == "" {
+		return rule, fmt.Errorf("pattern cannot be empty")
+	}
+
+	if v := strings.TrimSpace(m.inputs[1].Value()); v != "" {
+		isRegex, err := strconv.ParseBool(v)
+		if err != nil {
+			return rule, fmt.Errorf("regex must be true or false")
+		}
+		rule.Regex = isRegex
+	}
+	if rule.Regex {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return rule, fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+
+	// Message types may come from descriptor sets that are not loaded yet
+	if rule.Codec != "protobuf" {
+		if _, ok := codec.Get(rule.Codec); !ok {
+			return rule, fmt.Errorf("unknown codec: %s", rule.Codec)
+		}
+		rule.Message = ""
+	}
+	return rule, nil
+}
+
+func (m CodecRulesModel) View() string {
+	s := strings.Builder{}
+
+	s.WriteString(m.styles.Title.Render("Codec Rules") + "\n\n")
+
+	if m.err != nil {
+		s.WriteString(m.styles.Error.Render(m.err.Error()) + "\n")
+	}
+	if m.msg != "" {
+		s.WriteString(m.styles.Success.Render(m.msg) + "\n")
+	}
+
+	if m.isEditing {
+		for i := range m.inputs {
+			s.WriteString(m.inputs[i].View() + "\n")
+		}
+		s.WriteString("\n" + m.styles.Help.Render("Enter: Apply | Tab/Arrows: Navigate | Esc: Cancel"))
+		return s.String()
+	}
+
+	if len(m.rules) == 0 {
+		s.WriteString(m.styles.Dimmed.Render("No rules. Press a to add one.") + "\n")
+	}
+	for i, r := range m.rules {
+		kind := "glob"
+		if r.Regex {
+			kind = "regex"
+		}
+		line := fmt.Sprintf("%d. %s (%s) → %s", i+1, r.Pattern, kind, r.CodecName())
+		if i == m.cursor {
+			s.WriteString(m.styles.Highlight.Render("> "+line) + "\n")
+		} else {
+			s.WriteString("  " + line + "\n")
+		}
+	}
+
+	s.WriteString("\n" + m.styles.Help.Render("a: Add | e/Enter: Edit | d: Delete | K/J: Move Up/Down | Ctrl+S: Save | Esc: Back"))
+
+	return s.String()
+}
+
+func (m CodecRulesModel) saveCmd() tea.Cmd {
+	rules := make([]config.CodecRule, len(m.rules))
+	copy(rules, m.rules)
+	return func() tea.Msg {
+		m.cfg.SetCodecRules(rules)
+		if err := m.cfg.Save(); err != nil {
+			return OperationResultMsg{Op: "config", Err: err}
+		}
+		return OperationResultMsg{Op: "config", Message: "Codec rules saved"}
+	}
+}
+
+type OpenCodecRulesMsg struct{}
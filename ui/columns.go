@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"badger_explorer_core/config"
+	"badger_explorer_core/db"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Widths of the columns that do not share the free space. Key and preview
+// split what is left.
+var fixedColumnWidths = map[string]int{
+	"size":    10,
+	"expires": 20,
+	"version": 10,
+	"meta":    5,
+	"codec":   12,
+}
+
+var columnTitles = map[string]string{
+	"key":     "Key",
+	"preview": "Preview",
+	"size":    "Size",
+	"expires": "Expires",
+	"version": "Version",
+	"meta":    "Meta",
+	"codec":   "Codec",
+}
+
+// Narrowest key column when it shares the space with the preview
+const minKeyWidth = 12
+
+// visibleColumns returns the configured columns, skipping unknown names.
+func visibleColumns(cfg *config.Config) []string {
+	var cols []string
+	for _, name := range cfg.UI.Columns {
+		if slices.Contains(config.TableColumns, name) && !slices.Contains(cols, name) {
+			cols = append(cols, name)
+		}
+	}
+	if len(cols) == 0 {
+		return []string{"key", "preview", "size", "expires"}
+	}
+	return cols
+}
+
+// parseColumns validates a comma separated list of column names.
+func parseColumns(s string) ([]string, error) {
+	var cols []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(config.TableColumns, name) {
+			return nil, fmt.Errorf("unknown column %q (use %s)", name, strings.Join(config.TableColumns, ", "))
+		}
+		cols = append(cols, name)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("at least one column must be visible")
+	}
+	return cols, nil
+}
+
+// columnWidths fits the columns to width, the inner width of the table.
+// The key column grows with its widest cell up to half of the free space.
+func columnWidths(names []string, width int, rows []table.Row) []int {
+	widths := make([]int, len(names))
+	free := width
+	flexible := 0
+	for i, name := range names {
+		free -= 2 // Cell padding
+		if w, ok := fixedColumnWidths[name]; ok {
+			widths[i] = w
+			free -= w
+		} else {
+			flexible++
+		}
+	}
+	if flexible == 0 {
+		return widths
+	}
+	free = max(free, flexible*minKeyWidth)
+
+	longest := minKeyWidth
+	if i := slices.Index(names, "key"); i >= 0 {
+		for _, row := range rows {
+			longest = max(longest, lipgloss.Width(row[i])+1)
+		}
+	}
+	for i, name := range names {
+		switch {
+		case flexible == 1 && (name == "key" || name == "preview"):
+			widths[i] = free
+		case name == "key":
+			widths[i] = max(min(longest, free/2), minKeyWidth)
+		case name == "preview":
+			widths[i] = free - max(min(longest, free/2), minKeyWidth)
+		}
+	}
+	return widths
+}
+
+// cell renders one column of a key item.
+func cell(name string, k db.KeyItem) string {
+	switch name {
+	case "key":
+		return k.Key
+	case "preview":
+		return k.ValuePreview
+	case "size":
+		return fmt.Sprintf("%d", k.Size)
+	case "expires":
+		return fmt.Sprintf("%d", k.ExpiresAt)
+	case "version":
+		return fmt.Sprintf("%d", k.Version)
+	case "meta":
+		return fmt.Sprintf("%d", k.UserMeta)
+	case "codec":
+		return k.Codec
+	}
+	return ""
+}
+
+// pageSort orders the loaded page by a column other than the key.
+type pageSort struct {
+	column string // "" keeps the key order of the fetch
+	desc   bool
+}
+
+// pageSorts is the cycle of the page sort key.
+var pageSorts = []pageSort{
+	{},
+	{column: "size", desc: true},
+	{column: "size"},
+	{column: "expires"},
+	{column: "expires", desc: true},
+}
+
+func (s pageSort) next() pageSort {
+	for i, ps := range pageSorts {
+		if ps == s {
+			return pageSorts[(i+1)%len(pageSorts)]
+		}
+	}
+	return pageSorts[0]
+}
+
+// apply sorts keys in place, keeping the key order among equal values.
+func (s pageSort) apply(keys []db.KeyItem) {
+	if s.column == "" {
+		return
+	}
+	value := func(k db.KeyItem) int64 {
+		if s.column == "size" {
+			return k.Size
+		}
+		if k.ExpiresAt == 0 {
+			return 1<<63 - 1 // Keys without expiry expire last
+		}
+		return int64(k.ExpiresAt)
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if s.desc {
+			return value(keys[i]) > value(keys[j])
+		}
+		return value(keys[i]) < value(keys[j])
+	})
+}
+
+// tableColumns builds the header, marking the column the page is sorted by.
+func tableColumns(names []string, widths []int, s pageSort) []table.Column {
+	cols := make([]table.Column, len(names))
+	for i, name := range names {
+		title := columnTitles[name]
+		if name == s.column {
+			if s.desc {
+				title += " ↓"
+			} else {
+				title += " ↑"
+			}
+		}
+		cols[i] = table.Column{Title: title, Width: widths[i]}
+	}
+	return cols
+}
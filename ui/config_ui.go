@@ -24,7 +24,7 @@ type ConfigModel struct {
 }
 
 func NewConfigModel(cfg *config.Config) ConfigModel {
-	inputs := make([]textinput.Model, 5)
+	inputs := make([]textinput.Model, 6)
 
 	inputs[0] = textinput.New()
 	inputs[0].Placeholder = "Theme (dark/light)"
@@ -52,6 +52,11 @@ func NewConfigModel(cfg *config.Config) ConfigModel {
 	inputs[4].SetValue(cfg.DB.BackupPath)
 	inputs[4].Prompt = "Backup Path: "
 
+	inputs[5] = textinput.New()
+	inputs[5].Placeholder = strings.Join(config.TableColumns, ",")
+	inputs[5].SetValue(strings.Join(visibleColumns(cfg), ","))
+	inputs[5].Prompt = "Columns: "
+
 	return ConfigModel{
 		cfg:    cfg,
 		styles: pkg.DefaultStyles(),
@@ -87,7 +92,13 @@ func (m ConfigModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			// Save
 			return m, m.saveCmd()
+		case "ctrl+r":
+			return m, func() tea.Msg { return OpenCodecRulesMsg{} }
 		}
+
+	case OperationResultMsg:
+		m.err, m.msg = msg.Err, msg.Message
+		return m, nil
 	}
 
 	m.inputs[m.cursor], cmd = m.inputs[m.cursor].Update(msg)
@@ -112,7 +123,7 @@ func (m ConfigModel) View() string {
 		s.WriteString(m.inputs[i].View() + "\n")
 	}
 
-	s.WriteString("\n" + m.styles.Help.Render("Enter: Save | Tab/Arrows: Navigate | Esc: Back"))
+	s.WriteString("\n" + m.styles.Help.Render("Enter: Save | Tab/Arrows: Navigate | Ctrl+R: Codec Rules | Esc: Back"))
 
 	return s.String()
 }
@@ -120,6 +131,12 @@ func (m ConfigModel) View() string {
 func (m *ConfigModel) saveCmd() tea.Cmd {
 	return func() tea.Msg {
 		// Parse inputs
+		columns, err := parseColumns(m.inputs[5].Value())
+		if err != nil {
+			return OperationResultMsg{Op: "config", Err: err}
+		}
+		m.cfg.UI.Columns = columns
+
 		m.cfg.Theme = m.inputs[0].Value()
 
 		pc, err := strconv.Atoi(m.inputs[1].Value())
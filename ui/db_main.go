@@ -1,7 +1,10 @@
 package ui
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"sort"
 	"time"
 
 	"badger_explorer_core/config"
@@ -25,11 +28,22 @@ type DBMainModel struct {
 
 	keys      []db.KeyItem
 	offset    int
+	at        *keyAnchor // Page positioned by a jump, nil for offset paging
 	hasMore   bool
+	hasLess   bool
 	isLoading bool
 
-	searchMode string // "prefix", "substring", "regex"
-	sortDesc   bool
+	searchMode  string // "prefix", "substring", "regex"
+	sortDesc    bool
+	pageSort    pageSort // Client-side order of the loaded page
+	columns     []string // Visible columns, from UIConfig
+	ignoreCase  bool
+	codecFilter string // Set by saved queries
+
+	// Search history of this DB, most recent first
+	history    []string
+	historyPos int // -1 when not browsing
+	draft      string
 
 	width  int
 	height int
@@ -38,18 +52,31 @@ type DBMainModel struct {
 
 	// Debounce state
 	searchID int
+
+	// Live mode: rows changed since the last fetch, by key
+	live  *liveFeed
+	marks map[string]string
+
+	// Multi-select: selected keys and the row a range starts from
+	selected map[string]bool
+	anchor   int
+	bulk     *bulkPrompt // Prompt of a bulk action, nil when none is open
+	jump     *jumpPrompt // "Go to key" prompt, nil when closed
+	copying  string      // Key whose value is being copied, while asking for the format
+	status   string      // Outcome of the last bulk action
+
+	// Background count of the keys matching the search
+	total       *db.KeyCount // nil until the first result arrives
+	countID     int
+	countCancel context.CancelFunc
 }
 
 func NewDBMainModel(client *db.DBClient, cfg *config.Config) DBMainModel {
 	styles := pkg.DefaultStyles()
 
-	// Table init
-	columns := []table.Column{
-		{Title: "Key", Width: 30},
-		{Title: "Preview", Width: 50},
-		{Title: "Size", Width: 10},
-		{Title: "Expires", Width: 20},
-	}
+	// Table init; widths follow the terminal once its size is known
+	names := visibleColumns(cfg)
+	columns := tableColumns(names, columnWidths(names, defaultTableWidth, nil), pageSort{})
 
 	t := table.New(
 		table.WithColumns(columns),
@@ -86,7 +113,11 @@ func NewDBMainModel(client *db.DBClient, cfg *config.Config) DBMainModel {
 		table:      t,
 		searchIn:   ti,
 		searchMode: cfg.Search.DefaultMode,
+		columns:    names,
 		sortDesc:   false,
+		ignoreCase: !cfg.Search.CaseSensitive,
+		history:    cfg.GetSearchHistory(client.GetPath()),
+		historyPos: -1,
 	}
 }
 
@@ -108,6 +139,20 @@ func (m DBMainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.bulk != nil {
+			return m.updateBulk(msg)
+		}
+		if m.jump != nil {
+			return m.updateJump(msg)
+		}
+		if m.copying != "" {
+			key := m.copying
+			m.copying = ""
+			if format, ok := copyFormatKeys[msg.String()]; ok {
+				return m, copyValueCmd(m.dbClient, key, format, keyCodec(m.cfg, key))
+			}
+			return m, nil
+		}
 		// Global keys
 		switch msg.String() {
 		case "ctrl+c":
@@ -116,9 +161,14 @@ func (m DBMainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.searchIn.Focused() {
 				m.searchIn.Blur()
 				m.table.Focus()
+			} else if len(m.selected) > 0 {
+				m.selected = nil
+				m.updateTable()
 			} else {
 				// Back to Welcome?
 				// Or close DB?
+				m.stopLive()
+				m.stopCount()
 				return m, func() tea.Msg { return BackToWelcomeMsg{} }
 			}
 		case "/":
@@ -130,25 +180,108 @@ func (m DBMainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			if m.table.Focused() {
 				// Open detail
-				selected := m.table.SelectedRow()
-				if len(selected) > 0 {
-					key := selected[0]
+				if key, ok := m.cursorKey(); ok {
 					return m, func() tea.Msg { return OpenDetailMsg{Key: key} }
 				}
 			} else if m.searchIn.Focused() {
+				m.recordSearch()
 				// Trigger search immediately (force)
-				m.offset = 0
+				m.resetPage()
 				m.searchID++ // Invalidate pending ticks
 				cmds = append(cmds, m.fetchKeysCmd())
 				m.searchIn.Blur()
 				m.table.Focus()
 			}
+		case "up", "down":
+			if m.searchIn.Focused() {
+				if m.recallHistory(msg.String() == "up") {
+					return m, m.debounceCmd()
+				}
+				return m, nil
+			}
+		case "b":
+			if !m.searchIn.Focused() {
+				if key, ok := m.cursorKey(); ok {
+					m.cfg.ToggleBookmark(m.dbClient.GetPath(), key)
+					m.err = m.cfg.Save()
+					m.updateTable()
+				}
+				return m, nil // Also pages up in the table
+			}
+		case " ":
+			if !m.searchIn.Focused() {
+				m.toggleSelect()
+				return m, nil // Also pages down in the table
+			}
+		case "v":
+			if !m.searchIn.Focused() {
+				m.selectRange()
+			}
+		case "ctrl+a":
+			if !m.searchIn.Focused() {
+				return m, m.selectAllCmd()
+			}
+		case "x":
+			if !m.searchIn.Focused() {
+				return m, m.startBulk(bulkDelete)
+			}
+		case "E":
+			if !m.searchIn.Focused() {
+				return m, m.startBulk(bulkExport)
+			}
+		case "T":
+			if !m.searchIn.Focused() {
+				return m, m.startBulk(bulkTTL)
+			}
+		case "M":
+			if !m.searchIn.Focused() {
+				return m, m.startBulk(bulkMoveFrom)
+			}
+		case "y":
+			if !m.searchIn.Focused() {
+				return m, m.copyKeys()
+			}
+		case "Y":
+			if !m.searchIn.Focused() {
+				if key, ok := m.cursorKey(); ok {
+					m.copying = key
+					m.status = ""
+					m.err = nil
+				}
+			}
+		case "R":
+			if !m.searchIn.Focused() {
+				return m, m.startRename(bulkRename)
+			}
+		case "C":
+			if !m.searchIn.Focused() {
+				return m, m.startRename(bulkCopy)
+			}
+		case "J":
+			if !m.searchIn.Focused() {
+				return m, m.startJump()
+			}
+		case "B":
+			if !m.searchIn.Focused() {
+				return m, func() tea.Msg { return OpenBookmarksMsg{} }
+			}
+		case "Q":
+			if !m.searchIn.Focused() {
+				current := m.currentQuery()
+				return m, func() tea.Msg { return OpenSavedQueriesMsg{Current: current} }
+			}
 		case "s":
 			if !m.searchIn.Focused() {
 				m.sortDesc = !m.sortDesc
-				m.offset = 0
+				m.resetPage()
 				cmds = append(cmds, m.fetchKeysCmd())
 			}
+		case "o":
+			if !m.searchIn.Focused() {
+				m.pageSort = m.pageSort.next()
+				m.sortPage()
+				m.updateTable()
+			}
 		case "ctrl+f":
 			// Toggle search mode
 			modes := []string{"prefix", "substring", "regex"}
@@ -159,22 +292,49 @@ func (m DBMainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 			// Re-fetch?
-			m.offset = 0
+			m.resetPage()
 			cmds = append(cmds, m.fetchKeysCmd())
+		case "w":
+			if !m.searchIn.Focused() {
+				if m.live != nil {
+					m.stopLive()
+					m.marks = nil
+					m.updateTable()
+				} else {
+					cmds = append(cmds, m.startLive())
+				}
+			}
 		case "i":
 			if !m.searchIn.Focused() {
 				return m, func() tea.Msg { return OpenInsertMsg{} }
 			}
+		case "D":
+			if !m.searchIn.Focused() {
+				prefix, mode := m.searchIn.Value(), m.searchMode
+				return m, func() tea.Msg { return OpenDiffMsg{Prefix: prefix, Mode: mode} }
+			}
 		case "right", "l":
 			if !m.searchIn.Focused() && m.hasMore {
-				m.offset += m.cfg.DB.OpenBatchSize
+				if m.at != nil {
+					_, last := m.pageEnds()
+					m.at = &keyAnchor{key: last, after: true}
+				} else {
+					m.offset += m.cfg.DB.OpenBatchSize
+				}
 				cmds = append(cmds, m.fetchKeysCmd())
 			}
 		case "left", "h":
-			if !m.searchIn.Focused() && m.offset > 0 {
-				m.offset -= m.cfg.DB.OpenBatchSize
-				if m.offset < 0 {
-					m.offset = 0
+			if !m.searchIn.Focused() && m.hasLess {
+				if m.at != nil && len(m.keys) > 0 {
+					first, _ := m.pageEnds()
+					m.at = &keyAnchor{key: first, before: m.cfg.DB.OpenBatchSize}
+				} else if m.at != nil {
+					m.resetPage()
+				} else {
+					m.offset -= m.cfg.DB.OpenBatchSize
+					if m.offset < 0 {
+						m.offset = 0
+					}
 				}
 				cmds = append(cmds, m.fetchKeysCmd())
 			}
@@ -194,6 +354,7 @@ func (m DBMainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		m.table.SetWidth(msg.Width - 4) // Container padding
 		m.table.SetHeight(availableHeight)
+		m.updateTable()
 
 	case KeysFetchedMsg:
 		m.isLoading = false
@@ -202,12 +363,89 @@ func (m DBMainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.keys = msg.Keys
 			m.hasMore = msg.HasMore
+			m.hasLess = msg.HasLess
+			m.marks = nil
+			m.pageSort.apply(m.keys)
 			m.updateTable()
+			if msg.Focus != "" {
+				for i, k := range m.keys {
+					if k.Key == msg.Focus {
+						m.table.SetCursor(i)
+					}
+				}
+			}
+			// Paging keeps the count of the search
+			if (m.offset == 0 && m.at == nil) || m.total == nil {
+				cmds = append(cmds, m.startCount())
+			}
+		}
+
+	case KeyCountMsg:
+		if msg.ID != m.countID || msg.Err != nil {
+			break // Stale, cancelled or failed; the list shows search errors
+		}
+		// The estimate may arrive after the exact count
+		if msg.Count.Approx && m.total != nil && !m.total.Approx {
+			break
+		}
+		count := msg.Count
+		m.total = &count
+
+	case CopiedMsg:
+		if msg.Err != nil {
+			m.err = fmt.Errorf("copy failed: %w", msg.Err)
+		} else {
+			m.err = nil
+			m.status = msg.status()
+		}
+
+	case CompletionsMsg:
+		if m.jump != nil && msg.ID == m.jump.id && msg.Err == nil {
+			m.jump.completions = msg.Segments
+			m.jump.more = msg.More
+			m.jump.choice = -1
+		}
+
+	case SelectionMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+			break
+		}
+		m.selected = make(map[string]bool, len(msg.Keys))
+		for _, key := range msg.Keys {
+			m.selected[key] = true
+		}
+		m.status = fmt.Sprintf("Selected %d matching keys", len(msg.Keys))
+		if msg.More {
+			m.status += fmt.Sprintf(" (limit %d reached)", maxSelection)
+		}
+		m.updateTable()
+
+	case BulkDoneMsg:
+		// Refetch either way: prefix-wide actions may fail after some chunks
+		if msg.Err != nil {
+			m.err = msg.Err
+		} else {
+			m.status = msg.Summary
+			m.selected = nil
+		}
+		cmds = append(cmds, m.fetchKeysCmd(), m.startCount())
+
+	case LiveEventsMsg:
+		if msg.feed != m.live {
+			break // From a stopped feed
 		}
+		if msg.Closed {
+			m.live = nil
+			m.err = msg.Err
+			break
+		}
+		m.applyEvents(msg.Events)
+		cmds = append(cmds, waitLiveCmd(m.live))
 
 	case SearchTickMsg:
 		if msg.ID == m.searchID {
-			m.offset = 0
+			m.resetPage()
 			cmds = append(cmds, m.fetchKeysCmd())
 		}
 	}
@@ -220,12 +458,8 @@ func (m DBMainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		newValue := m.searchIn.Value()
 		if oldValue != newValue {
-			m.searchID++
-			// Debounce 400ms
-			id := m.searchID
-			cmds = append(cmds, tea.Tick(400*time.Millisecond, func(t time.Time) tea.Msg {
-				return SearchTickMsg{ID: id}
-			}))
+			m.historyPos = -1
+			cmds = append(cmds, m.debounceCmd())
 		}
 	} else {
 		m.table, cmd = m.table.Update(msg)
@@ -235,25 +469,178 @@ func (m DBMainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// debounceCmd schedules a search after the input settles.
+func (m *DBMainModel) debounceCmd() tea.Cmd {
+	m.searchID++
+	// Debounce 400ms
+	id := m.searchID
+	return tea.Tick(400*time.Millisecond, func(t time.Time) tea.Msg {
+		return SearchTickMsg{ID: id}
+	})
+}
+
+// recordSearch adds the search term to the history of this DB.
+func (m *DBMainModel) recordSearch() {
+	m.historyPos = -1
+	term := m.searchIn.Value()
+	if term == "" {
+		return
+	}
+	path := m.dbClient.GetPath()
+	m.cfg.AddSearchHistory(path, term)
+	m.cfg.Save()
+	m.history = m.cfg.GetSearchHistory(path)
+}
+
+// recallHistory replaces the search term with an older (up) or newer entry
+// of the history and reports whether the term changed.
+func (m *DBMainModel) recallHistory(older bool) bool {
+	pos := m.historyPos
+	if older && pos < len(m.history)-1 {
+		pos++
+	} else if !older && pos >= 0 {
+		pos--
+	}
+	if pos == m.historyPos {
+		return false
+	}
+
+	if m.historyPos == -1 {
+		m.draft = m.searchIn.Value()
+	}
+	m.historyPos = pos
+	if pos == -1 {
+		m.searchIn.SetValue(m.draft)
+	} else {
+		m.searchIn.SetValue(m.history[pos])
+	}
+	m.searchIn.CursorEnd()
+	return true
+}
+
+// currentQuery describes the search shown in the list.
+func (m DBMainModel) currentQuery() config.SavedQuery {
+	return config.SavedQuery{
+		Term:       m.searchIn.Value(),
+		Mode:       m.searchMode,
+		SortDesc:   m.sortDesc,
+		IgnoreCase: m.ignoreCase,
+		Codec:      m.codecFilter,
+	}
+}
+
+// applyQuery replaces the search with a saved query and fetches the keys.
+func (m *DBMainModel) applyQuery(q config.SavedQuery) tea.Cmd {
+	m.searchIn.SetValue(q.Term)
+	m.searchMode = q.Mode
+	m.sortDesc = q.SortDesc
+	m.ignoreCase = q.IgnoreCase
+	m.codecFilter = q.Codec
+	m.resetPage()
+	m.searchID++ // Invalidate pending ticks
+	m.recordSearch()
+	return m.fetchKeysCmd()
+}
+
+// defaultTableWidth lays out the columns until the terminal size is known.
+const defaultTableWidth = 120
+
+// cursorKey returns the key of the row under the cursor.
+func (m DBMainModel) cursorKey() (string, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.keys) {
+		return "", false
+	}
+	return m.keys[i].Key, true
+}
+
+// resetPage goes back to the first page of the search.
+func (m *DBMainModel) resetPage() {
+	m.offset = 0
+	m.at = nil
+}
+
+// pageEnds returns the first and last keys of the page in fetch order,
+// whatever the page sort. The page must not be empty.
+func (m DBMainModel) pageEnds() (string, string) {
+	first, last := m.keys[0].Key, m.keys[0].Key
+	for _, k := range m.keys[1:] {
+		first, last = min(first, k.Key), max(last, k.Key)
+	}
+	if m.sortDesc {
+		return last, first
+	}
+	return first, last
+}
+
+// sortPage restores the key order of the fetch or applies the page sort.
+func (m *DBMainModel) sortPage() {
+	if m.pageSort.column == "" {
+		sort.SliceStable(m.keys, func(i, j int) bool {
+			if m.sortDesc {
+				return m.keys[i].Key > m.keys[j].Key
+			}
+			return m.keys[i].Key < m.keys[j].Key
+		})
+		return
+	}
+	m.pageSort.apply(m.keys)
+}
+
 func (m *DBMainModel) updateTable() {
+	pinned := make(map[string]bool)
+	for _, key := range m.cfg.GetBookmarks(m.dbClient.GetPath()) {
+		pinned[key] = true
+	}
+
+	// Rows carry marks in their first visible cell
 	rows := make([]table.Row, len(m.keys))
 	for i, k := range m.keys {
-		rows[i] = table.Row{
-			k.Key,
-			k.ValuePreview,
-			fmt.Sprintf("%d", k.Size),
-			fmt.Sprintf("%d", k.ExpiresAt),
+		row := make(table.Row, len(m.columns))
+		for j, name := range m.columns {
+			row[j] = cell(name, k)
+		}
+		if pinned[k.Key] {
+			row[0] = "★ " + row[0]
+		}
+		if m.selected[k.Key] {
+			row[0] = "✓ " + row[0]
 		}
+		switch m.marks[k.Key] {
+		case db.EventAdded:
+			row[0] = "[+] " + row[0]
+		case db.EventChanged:
+			row[0] = "[~] " + row[0]
+		case db.EventDeleted:
+			row[0] = "[-] " + row[0]
+		}
+		rows[i] = row
+	}
+
+	width := defaultTableWidth
+	if m.width > 0 {
+		width = m.width - 4
 	}
+	widths := columnWidths(m.columns, width, rows)
+	m.table.SetColumns(tableColumns(m.columns, widths, m.pageSort))
 	m.table.SetRows(rows)
 }
 
 func (m DBMainModel) View() string {
 	// Header
 	header := m.styles.Title.Render(fmt.Sprintf("DB: %s", m.dbClient.GetPath()))
+	if totals := m.totals(); totals != "" {
+		header = lipgloss.JoinHorizontal(lipgloss.Left, header, " ", m.styles.Dimmed.Render(totals))
+	}
 
 	// Search Bar
 	modeStr := fmt.Sprintf("[%s]", m.searchMode)
+	if m.ignoreCase {
+		modeStr += " [ignore case]"
+	}
+	if m.codecFilter != "" {
+		modeStr += fmt.Sprintf(" [codec: %s]", m.codecFilter)
+	}
 	searchBar := lipgloss.JoinHorizontal(lipgloss.Left,
 		m.searchIn.View(),
 		" ",
@@ -265,11 +652,35 @@ func (m DBMainModel) View() string {
 	tableView := m.styles.Border.Render(m.table.View())
 
 	// Footer
-	helpText := "Enter: Detail | /: Search | s: Sort | i: Insert | ←/→: Page | Ctrl+F: Mode | Esc: Back"
+	helpText := "Enter: Detail | /: Search | s: Sort | o: Order Page | i: Insert | ←/→: Page | Ctrl+F: Mode | D: Diff | J: Jump to Key | w: Live | b: Pin | B: Bookmarks | Q: Queries | R/C: Rename/Copy | y/Y: Copy Key/Value | Space: Select | ↑/↓ in search: History | Esc: Back"
+	if len(m.selected) > 0 {
+		helpText = fmt.Sprintf("%d selected | Space: Toggle | v: Range | Ctrl+A: All Matching | x: Delete | E: Export | y: Copy Keys | T: TTL | M: Move Prefix | Esc: Clear", len(m.selected))
+	}
+	if m.live != nil {
+		helpText += " | Live"
+	}
 	if m.isLoading {
 		helpText += " | Loading..."
 	}
 	footer := m.styles.Help.Render(helpText)
+	if m.jump != nil {
+		footer = m.jumpView()
+	}
+	if m.copying != "" {
+		footer = m.styles.Help.Render(copyFormatHelp)
+	}
+	if m.bulk != nil {
+		promptHelp := "Enter: Confirm | Esc: Cancel"
+		if m.bulk.action == bulkRename || m.bulk.action == bulkCopy {
+			promptHelp = "Enter: Confirm | Tab: Key/Prefix | Esc: Cancel"
+		}
+		footer = lipgloss.JoinVertical(lipgloss.Left, m.bulk.input.View(), m.styles.Help.Render(promptHelp))
+	}
+	if m.err != nil {
+		footer = lipgloss.JoinVertical(lipgloss.Left, m.styles.Error.Render(m.err.Error()), footer)
+	} else if m.status != "" {
+		footer = lipgloss.JoinVertical(lipgloss.Left, m.styles.Success.Render(m.status), footer)
+	}
 
 	content := lipgloss.JoinVertical(lipgloss.Left,
 		header,
@@ -286,24 +697,101 @@ func (m DBMainModel) View() string {
 type KeysFetchedMsg struct {
 	Keys    []db.KeyItem
 	HasMore bool
+	HasLess bool   // Keys precede the page
+	Focus   string // Key to put the cursor on, if any
 	Err     error
 }
 
+// listOptions describes the search and page shown in the list.
+func (m DBMainModel) listOptions() db.ListKeysOptions {
+	return db.ListKeysOptions{
+		Prefix:       m.searchIn.Value(),
+		Mode:         m.searchMode,
+		SortDesc:     m.sortDesc,
+		Limit:        m.cfg.DB.OpenBatchSize,
+		Offset:       m.offset,
+		PreviewChars: m.cfg.UI.PreviewChars,
+		PreviewCodec: m.cfg.CodecFor,
+		IgnoreCase:   m.ignoreCase,
+		CodecFilter:  m.codecFilter,
+	}
+}
+
 func (m DBMainModel) fetchKeysCmd() tea.Cmd {
+	opts := m.listOptions()
+	if m.at != nil {
+		at := *m.at
+		return func() tea.Msg { return m.fetchWindow(at, opts) }
+	}
 	return func() tea.Msg {
-		opts := db.ListKeysOptions{
-			Prefix:       m.searchIn.Value(),
-			Mode:         m.searchMode,
-			SortDesc:     m.sortDesc,
-			Limit:        m.cfg.DB.OpenBatchSize,
-			Offset:       m.offset,
-			PreviewChars: m.cfg.UI.PreviewChars,
-		}
-
 		// Simulate delay for spinner? No need.
 		keys, hasMore, err := m.dbClient.ListKeys(opts)
-		return KeysFetchedMsg{Keys: keys, HasMore: hasMore, Err: err}
+		return KeysFetchedMsg{Keys: keys, HasMore: hasMore, HasLess: opts.Offset > 0, Err: err}
+	}
+}
+
+// Match count
+
+// KeyCountMsg carries the count of the keys matching the search.
+type KeyCountMsg struct {
+	ID    int
+	Count db.KeyCount
+	Err   error
+}
+
+// startCount cancels the running count and counts the current search in
+// the background: a quick estimate from the table indexes first, when the
+// filter allows it, then the exact number.
+func (m *DBMainModel) startCount() tea.Cmd {
+	m.stopCount()
+	m.countID++
+	m.total = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.countCancel = cancel
+	id, opts, client := m.countID, m.listOptions(), m.dbClient
+
+	exact := func() tea.Msg {
+		count, err := client.CountKeys(ctx, opts)
+		return KeyCountMsg{ID: id, Count: count, Err: err}
 	}
+	estimate, ok := client.EstimateKeys(opts)
+	if !ok {
+		return exact
+	}
+	return tea.Batch(func() tea.Msg { return KeyCountMsg{ID: id, Count: estimate} }, exact)
+}
+
+// stopCount cancels the running count, if any.
+func (m *DBMainModel) stopCount() {
+	if m.countCancel != nil {
+		m.countCancel()
+		m.countCancel = nil
+	}
+}
+
+// totals describes the page position and the size of the search.
+func (m DBMainModel) totals() string {
+	if m.at != nil {
+		// Jumps do not know their page
+		switch {
+		case m.total == nil:
+			return ""
+		case m.total.Approx:
+			return fmt.Sprintf("~%d keys", m.total.Keys)
+		}
+		return fmt.Sprintf("%d keys, %d bytes", m.total.Keys, m.total.Bytes)
+	}
+	batch := max(1, m.cfg.DB.OpenBatchSize)
+	page := m.offset/batch + 1
+	if m.total == nil {
+		return fmt.Sprintf("page %d", page)
+	}
+	pages := max(1, int((m.total.Keys+int64(batch)-1)/int64(batch)))
+	if m.total.Approx {
+		return fmt.Sprintf("page %d of ~%d | ~%d keys", page, max(page, pages), m.total.Keys)
+	}
+	return fmt.Sprintf("page %d of %d | %d keys, %d bytes", page, pages, m.total.Keys, m.total.Bytes)
 }
 
 type OpenDetailMsg struct {
@@ -311,3 +799,130 @@ type OpenDetailMsg struct {
 }
 
 type OpenInsertMsg struct{}
+
+// Live mode
+
+// liveFeed is a running subscription feeding key changes to the model.
+type liveFeed struct {
+	cancel context.CancelFunc
+	events chan []db.KeyEvent
+	err    error // Set before events is closed
+}
+
+// LiveEventsMsg carries key changes from the live subscription.
+type LiveEventsMsg struct {
+	Events []db.KeyEvent
+	Closed bool // The subscription ended; Err tells why
+	Err    error
+	feed   *liveFeed
+}
+
+// startLive subscribes to changes under the current prefix. Other search
+// modes watch every key and filter the events.
+func (m *DBMainModel) startLive() tea.Cmd {
+	var prefixes []string
+	if m.searchMode == "prefix" && m.searchIn.Value() != "" {
+		prefixes = []string{m.searchIn.Value()}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	feed := &liveFeed{cancel: cancel, events: make(chan []db.KeyEvent)}
+	m.live = feed
+	m.marks = make(map[string]string)
+
+	client := m.dbClient
+	go func() {
+		feed.err = client.Subscribe(ctx, prefixes, func(events []db.KeyEvent) error {
+			select {
+			case feed.events <- events:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(feed.events)
+	}()
+	return waitLiveCmd(feed)
+}
+
+// stopLive ends live mode. It must run before the database is closed.
+func (m *DBMainModel) stopLive() {
+	if m.live != nil {
+		m.live.cancel()
+		m.live = nil
+	}
+}
+
+func waitLiveCmd(feed *liveFeed) tea.Cmd {
+	return func() tea.Msg {
+		events, ok := <-feed.events
+		if !ok {
+			return LiveEventsMsg{Closed: true, Err: feed.err, feed: feed}
+		}
+		return LiveEventsMsg{Events: events, feed: feed}
+	}
+}
+
+// applyEvents marks changed rows in place and inserts new keys that fall
+// within the loaded page. Deleted rows stay marked until the next fetch.
+func (m *DBMainModel) applyEvents(events []db.KeyEvent) {
+	match, err := db.ListKeysOptions{Prefix: m.searchIn.Value(), Mode: m.searchMode}.Matcher()
+	if err != nil {
+		return
+	}
+	if m.marks == nil {
+		m.marks = make(map[string]string)
+	}
+
+	// A page sorted by another column is searched linearly and re-sorted
+	sorted := m.pageSort.column != ""
+	for _, e := range events {
+		i := sort.Search(len(m.keys), func(i int) bool {
+			if m.sortDesc {
+				return m.keys[i].Key <= e.Key
+			}
+			return m.keys[i].Key >= e.Key
+		})
+		if sorted {
+			i = slices.IndexFunc(m.keys, func(k db.KeyItem) bool { return k.Key == e.Key })
+			if i < 0 {
+				i = len(m.keys)
+			}
+		}
+
+		if i < len(m.keys) && m.keys[i].Key == e.Key {
+			if e.Op == db.EventDeleted {
+				m.marks[e.Key] = db.EventDeleted
+				continue
+			}
+			m.keys[i].Size = e.Size
+			m.keys[i].ExpiresAt = e.ExpiresAt
+			m.keys[i].Version = e.Version
+			if m.marks[e.Key] != db.EventAdded {
+				m.marks[e.Key] = db.EventChanged
+			}
+			continue
+		}
+
+		if e.Op == db.EventDeleted || !match(e.Key) {
+			continue
+		}
+		// Keys sorting before the page or after a page that has more belong
+		// elsewhere; a re-sorted page only takes new keys when it holds them all
+		if sorted && (m.offset > 0 || m.hasMore) {
+			continue
+		}
+		if !sorted && ((i == 0 && m.offset > 0) || (i == len(m.keys) && m.hasMore)) {
+			continue
+		}
+		item := db.KeyItem{Key: e.Key, Size: e.Size, ExpiresAt: e.ExpiresAt, Version: e.Version}
+		m.keys = append(m.keys, db.KeyItem{})
+		copy(m.keys[i+1:], m.keys[i:])
+		m.keys[i] = item
+		m.marks[e.Key] = db.EventAdded
+	}
+	if sorted {
+		m.pageSort.apply(m.keys)
+	}
+	m.updateTable()
+}
@@ -0,0 +1,321 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"badger_explorer_core/config"
+	"badger_explorer_core/db"
+	"badger_explorer_core/pkg"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// DiffModel compares the open DB (A) with another DB (B) side by side
+// and syncs selected differences in either direction.
+type DiffModel struct {
+	dbClient *db.DBClient
+	cfg      *config.Config
+	styles   pkg.Styles
+
+	pathIn textinput.Model
+	table  table.Model
+
+	prefix string
+	mode   string
+
+	otherPath string
+	entries   []db.DiffEntry
+	selected  map[string]bool
+	isLoading bool
+
+	err error
+	msg string
+
+	width  int
+	height int
+}
+
+func NewDiffModel(client *db.DBClient, cfg *config.Config, prefix, mode string) DiffModel {
+	ti := textinput.New()
+	ti.Placeholder = "Path of the DB to compare with"
+	ti.Prompt = "B: "
+	ti.CharLimit = 512
+	ti.Width = 60
+	ti.Focus()
+
+	columns := []table.Column{
+		{Title: " ", Width: 3},
+		{Title: "Key", Width: 30},
+		{Title: "Op", Width: 8},
+		{Title: "A (size/expires)", Width: 22},
+		{Title: "B (size/expires)", Width: 22},
+	}
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithHeight(10),
+	)
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(pkg.ColorPurple)).
+		BorderBottom(true).
+		Bold(true).
+		Foreground(lipgloss.Color(pkg.ColorCyan))
+	s.Selected = s.Selected.
+		Foreground(lipgloss.Color(pkg.ColorBackground)).
+		Background(lipgloss.Color(pkg.ColorPink)).
+		Bold(true)
+	t.SetStyles(s)
+
+	return DiffModel{
+		dbClient: client,
+		cfg:      cfg,
+		styles:   pkg.DefaultStyles(),
+		pathIn:   ti,
+		table:    t,
+		prefix:   prefix,
+		mode:     mode,
+		selected: make(map[string]bool),
+	}
+}
+
+func (m DiffModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m DiffModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if m.pathIn.Focused() {
+			switch msg.String() {
+			case "esc":
+				return m, func() tea.Msg { return BackToMainMsg{} }
+			case "enter":
+				m.otherPath = strings.TrimSpace(m.pathIn.Value())
+				if m.otherPath == "" {
+					return m, nil
+				}
+				m.pathIn.Blur()
+				m.table.Focus()
+				m.isLoading = true
+				m.err = nil
+				m.msg = ""
+				return m, m.diffCmd()
+			}
+		} else {
+			switch msg.String() {
+			case "esc":
+				return m, func() tea.Msg { return BackToMainMsg{} }
+			case "/":
+				m.table.Blur()
+				m.pathIn.Focus()
+				return m, textinput.Blink
+			case " ":
+				if row := m.table.SelectedRow(); len(row) > 1 {
+					key := row[1]
+					m.selected[key] = !m.selected[key]
+					m.updateTable()
+				}
+				return m, nil
+			case "a":
+				// Toggle all
+				all := len(m.selectedKeys()) < len(m.entries)
+				for _, e := range m.entries {
+					m.selected[e.Key] = all
+				}
+				m.updateTable()
+				return m, nil
+			case "r":
+				m.isLoading = true
+				return m, m.diffCmd()
+			case ">":
+				return m, m.applyCmd(true)
+			case "<":
+				return m, m.applyCmd(false)
+			}
+		}
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+		// Header + Path input + Status + Footer + Container Padding
+		availableHeight := msg.Height - 12
+		if availableHeight < 1 {
+			availableHeight = 1
+		}
+		m.table.SetWidth(msg.Width - 4)
+		m.table.SetHeight(availableHeight)
+
+	case DiffFetchedMsg:
+		m.isLoading = false
+		if msg.Err != nil {
+			m.err = msg.Err
+		} else {
+			m.entries = msg.Entries
+			m.selected = make(map[string]bool)
+			m.updateTable()
+		}
+		return m, nil
+
+	case DiffAppliedMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+			return m, nil
+		}
+		m.msg = fmt.Sprintf("Synced %d keys", msg.Synced)
+		m.isLoading = true
+		return m, m.diffCmd()
+	}
+
+	if m.pathIn.Focused() {
+		m.pathIn, cmd = m.pathIn.Update(msg)
+	} else {
+		m.table, cmd = m.table.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m *DiffModel) updateTable() {
+	rows := make([]table.Row, len(m.entries))
+	for i, e := range m.entries {
+		mark := "[ ]"
+		if m.selected[e.Key] {
+			mark = "[x]"
+		}
+		a, b := "-", "-"
+		if e.Op != db.DiffAdded {
+			a = fmt.Sprintf("%d / %d", e.SizeA, e.ExpiresA)
+		}
+		if e.Op != db.DiffRemoved {
+			b = fmt.Sprintf("%d / %d", e.SizeB, e.ExpiresB)
+		}
+		rows[i] = table.Row{mark, e.Key, e.Op, a, b}
+	}
+	m.table.SetRows(rows)
+}
+
+func (m DiffModel) selectedKeys() []string {
+	var keys []string
+	for _, e := range m.entries {
+		if m.selected[e.Key] {
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
+
+func (m DiffModel) View() string {
+	header := m.styles.Title.Render(fmt.Sprintf("Diff: %s ↔ %s", m.dbClient.GetPath(), m.otherPath))
+
+	filter := m.styles.Dimmed.Render(fmt.Sprintf("[%s] %s", m.mode, m.prefix))
+	pathBar := lipgloss.JoinHorizontal(lipgloss.Left, m.pathIn.View(), " ", filter)
+	pathBar = m.styles.Container.Copy().Padding(0, 1).Render(pathBar)
+
+	status := ""
+	if m.err != nil {
+		status = m.styles.Error.Render(m.err.Error())
+	} else if m.msg != "" {
+		status = m.styles.Success.Render(m.msg)
+	} else if m.otherPath != "" && !m.isLoading {
+		summary := fmt.Sprintf("%d differences, %d selected", len(m.entries), len(m.selectedKeys()))
+		if len(m.entries) >= m.cfg.DB.OpenBatchSize {
+			summary += " (more may exist)"
+		}
+		status = m.styles.Dimmed.Render(summary)
+	}
+
+	tableView := m.styles.Border.Render(m.table.View())
+
+	helpText := "Enter: Compare | Space: Select | a: Select All | >: Apply A→B | <: Apply B→A | r: Refresh | /: Path | Esc: Back"
+	if m.isLoading {
+		helpText += " | Loading..."
+	}
+	footer := m.styles.Help.Render(helpText)
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		header,
+		pathBar,
+		status,
+		tableView,
+		footer,
+	)
+	return m.styles.Container.Render(content)
+}
+
+// Commands & Messages
+
+type DiffFetchedMsg struct {
+	Entries []db.DiffEntry
+	Err     error
+}
+
+type DiffAppliedMsg struct {
+	Synced int
+	Err    error
+}
+
+// openOther opens the DB being compared. It is opened per command and closed
+// afterwards so the TUI never keeps a second Badger lock around.
+func (m DiffModel) openOther() (*db.DBClient, error) {
+	other := db.NewDBClient()
+	if err := other.Open(m.otherPath); err != nil {
+		return nil, err
+	}
+	return other, nil
+}
+
+func (m DiffModel) diffCmd() tea.Cmd {
+	return func() tea.Msg {
+		other, err := m.openOther()
+		if err != nil {
+			return DiffFetchedMsg{Err: err}
+		}
+		defer other.Close()
+
+		opts := db.ListKeysOptions{
+			Prefix: m.prefix,
+			Mode:   m.mode,
+			Limit:  m.cfg.DB.OpenBatchSize,
+		}
+		var entries []db.DiffEntry
+		err = db.Diff(m.dbClient, other, opts, func(e db.DiffEntry) error {
+			entries = append(entries, e)
+			return nil
+		})
+		return DiffFetchedMsg{Entries: entries, Err: err}
+	}
+}
+
+// applyCmd syncs the selected keys; aToB copies from the open DB into the other one.
+func (m DiffModel) applyCmd(aToB bool) tea.Cmd {
+	keys := m.selectedKeys()
+	if len(keys) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		other, err := m.openOther()
+		if err != nil {
+			return DiffAppliedMsg{Err: err}
+		}
+		defer other.Close()
+
+		src, dst := m.dbClient, other
+		if !aToB {
+			src, dst = other, m.dbClient
+		}
+		n, err := db.SyncKeys(src, dst, keys)
+		return DiffAppliedMsg{Synced: n, Err: err}
+	}
+}
+
+type OpenDiffMsg struct {
+	Prefix string
+	Mode   string
+}
@@ -71,6 +71,15 @@ func (m InsertModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case "ctrl+s":
 			return m, m.saveCmd()
+		case "ctrl+v":
+			// Falls back to the text copied in the app without a system clipboard
+			paste, err := pasteMsg()
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+			m.err = nil
+			return m.Update(paste)
 		}
 
 	case OperationResultMsg:
@@ -115,7 +124,7 @@ func (m InsertModel) View() string {
 	s.WriteString("Value:\n")
 	s.WriteString(m.valueInput.View() + "\n\n")
 
-	s.WriteString(m.styles.Help.Render("Tab: Switch Focus | Ctrl+S: Save | Esc: Back"))
+	s.WriteString(m.styles.Help.Render("Tab: Switch Focus | Ctrl+V: Paste | Ctrl+S: Save | Esc: Back"))
 
 	return s.String()
 }
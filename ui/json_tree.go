@@ -0,0 +1,276 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"badger_explorer_core/codec"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// jsonNode is one value of a JSON document in the tree view.
+// Object key order is preserved as it appears in the value.
+type jsonNode struct {
+	label    string // Key or index; empty for the root
+	path     string // JSONPath of this node, e.g. $.users[0].name
+	depth    int
+	kind     json.Delim // '{' or '[' for containers, 0 for scalars
+	scalar   string     // JSON text of a scalar
+	children []*jsonNode
+	parent   *jsonNode
+	expanded bool
+}
+
+// jsonTree is a foldable view over a JSON document.
+type jsonTree struct {
+	root    *jsonNode
+	visible []*jsonNode
+	cursor  int
+	offset  int
+	height  int
+}
+
+// jsonTreeExpandDepth is how many levels are expanded when a document is opened.
+const jsonTreeExpandDepth = 2
+
+func newJSONTree(text string) (*jsonTree, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+
+	root, err := parseJSONNode(dec, nil, "", "$", 0)
+	if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+
+	t := &jsonTree{root: root, height: 10}
+	t.rebuild()
+	return t, nil
+}
+
+func parseJSONNode(dec *json.Decoder, parent *jsonNode, label, path string, depth int) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &jsonNode{label: label, path: path, depth: depth, parent: parent}
+	switch t := tok.(type) {
+	case json.Delim:
+		n.kind = t
+		n.expanded = depth < jsonTreeExpandDepth
+		for i := 0; dec.More(); i++ {
+			var childLabel, childPath string
+			if t == '{' {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				childLabel = keyTok.(string)
+				childPath = path + jsonPathKey(childLabel)
+			} else {
+				childLabel = strconv.Itoa(i)
+				childPath = fmt.Sprintf("%s[%d]", path, i)
+			}
+			child, err := parseJSONNode(dec, n, childLabel, childPath, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		// Closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		n.scalar = string(b)
+	}
+	return n, nil
+}
+
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func jsonPathKey(key string) string {
+	if identRe.MatchString(key) {
+		return "." + key
+	}
+	return codec.QuoteName(key)
+}
+
+// rebuild recomputes the visible lines after folding changes.
+func (t *jsonTree) rebuild() {
+	t.visible = t.visible[:0]
+	var walk func(n *jsonNode)
+	walk = func(n *jsonNode) {
+		t.visible = append(t.visible, n)
+		if n.expanded {
+			for _, c := range n.children {
+				walk(c)
+			}
+		}
+	}
+	walk(t.root)
+
+	if t.cursor >= len(t.visible) {
+		t.cursor = len(t.visible) - 1
+	}
+	t.scroll()
+}
+
+func (t *jsonTree) current() *jsonNode {
+	return t.visible[t.cursor]
+}
+
+func (t *jsonTree) move(delta int) {
+	t.cursor += delta
+	if t.cursor < 0 {
+		t.cursor = 0
+	}
+	if t.cursor >= len(t.visible) {
+		t.cursor = len(t.visible) - 1
+	}
+	t.scroll()
+}
+
+func (t *jsonTree) scroll() {
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+t.height {
+		t.offset = t.cursor - t.height + 1
+	}
+}
+
+func (t *jsonTree) toggle() {
+	n := t.current()
+	if n.kind != 0 {
+		n.expanded = !n.expanded
+		t.rebuild()
+	}
+}
+
+func (t *jsonTree) expand() {
+	n := t.current()
+	if n.kind != 0 && !n.expanded {
+		n.expanded = true
+		t.rebuild()
+	}
+}
+
+// collapse folds the current node, or jumps to its parent if it is already folded.
+func (t *jsonTree) collapse() {
+	n := t.current()
+	if n.kind != 0 && n.expanded {
+		n.expanded = false
+		t.rebuild()
+		return
+	}
+	if n.parent != nil {
+		for i, v := range t.visible {
+			if v == n.parent {
+				t.cursor = i
+				break
+			}
+		}
+		t.scroll()
+	}
+}
+
+func (t *jsonTree) setHeight(h int) {
+	if h < 1 {
+		h = 1
+	}
+	t.height = h
+	t.scroll()
+}
+
+// marshal renders a node back to compact JSON, keeping key order.
+func (n *jsonNode) marshal() string {
+	if n.kind == 0 {
+		return n.scalar
+	}
+	var sb strings.Builder
+	if n.kind == '{' {
+		sb.WriteByte('{')
+	} else {
+		sb.WriteByte('[')
+	}
+	for i, c := range n.children {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		if n.kind == '{' {
+			key, _ := json.Marshal(c.label)
+			sb.Write(key)
+			sb.WriteByte(':')
+		}
+		sb.WriteString(c.marshal())
+	}
+	if n.kind == '{' {
+		sb.WriteByte('}')
+	} else {
+		sb.WriteByte(']')
+	}
+	return sb.String()
+}
+
+func (t *jsonTree) View(width int, selected, dimmed lipgloss.Style) string {
+	var sb strings.Builder
+	end := t.offset + t.height
+	if end > len(t.visible) {
+		end = len(t.visible)
+	}
+
+	for i := t.offset; i < end; i++ {
+		n := t.visible[i]
+		line := strings.Repeat("  ", n.depth)
+
+		switch {
+		case n.kind == 0:
+			line += "  "
+		case n.expanded:
+			line += "▾ "
+		default:
+			line += "▸ "
+		}
+
+		if n.parent != nil {
+			if n.parent.kind == '{' {
+				line += n.label + ": "
+			} else {
+				line += "[" + n.label + "] "
+			}
+		}
+
+		switch {
+		case n.kind == 0:
+			line += n.scalar
+		case n.kind == '{':
+			line += dimmed.Render(fmt.Sprintf("{…} %d keys", len(n.children)))
+		default:
+			line += dimmed.Render(fmt.Sprintf("[…] %d items", len(n.children)))
+		}
+
+		if width > 0 {
+			line = lipgloss.NewStyle().MaxWidth(width).Render(line)
+		}
+		if i == t.cursor {
+			line = selected.Render(line)
+		}
+		sb.WriteString(line)
+		if i < end-1 {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
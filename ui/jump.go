@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"strings"
+
+	"badger_explorer_core/db"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// keySeparators end a key segment for completion, e.g. "user:" or "logs/".
+const keySeparators = ":/"
+
+// maxCompletions bounds the segment scan behind the jump prompt.
+const maxCompletions = 20
+
+// jumpPrompt asks for a key to go to and completes it one segment at a time.
+type jumpPrompt struct {
+	input       textinput.Model
+	completions []string
+	more        bool // More segments than maxCompletions
+	choice      int  // Completion picked with Tab, -1 for none
+	id          int  // Latest completion request
+}
+
+// keyAnchor positions the page at a key instead of an offset.
+type keyAnchor struct {
+	key    string
+	before int  // Keys shown ahead of key
+	after  bool // Page starts after key, to continue a window
+}
+
+// CompletionsMsg carries the completions of the jump prompt.
+type CompletionsMsg struct {
+	ID       int
+	Segments []string
+	More     bool
+	Err      error
+}
+
+// startJump opens the "go to key" prompt, starting from the search prefix.
+func (m *DBMainModel) startJump() tea.Cmd {
+	in := textinput.New()
+	in.Prompt = "Go to key: "
+	in.CharLimit = 1024
+	if m.searchMode == "prefix" {
+		in.SetValue(m.searchIn.Value())
+		in.CursorEnd()
+	}
+	m.jump = &jumpPrompt{input: in, choice: -1}
+	m.status = ""
+	m.err = nil
+	return tea.Batch(m.jump.input.Focus(), m.completeCmd())
+}
+
+// completeCmd looks up the next segments of the typed key.
+func (m *DBMainModel) completeCmd() tea.Cmd {
+	m.jump.id++
+	id, prefix, client := m.jump.id, m.jump.input.Value(), m.dbClient
+	return func() tea.Msg {
+		segments, more, err := client.KeySegments(prefix, keySeparators, maxCompletions)
+		return CompletionsMsg{ID: id, Segments: segments, More: more, Err: err}
+	}
+}
+
+func (m DBMainModel) updateJump(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.jump
+	switch msg.String() {
+	case "esc":
+		m.jump = nil
+		return m, nil
+	case "enter":
+		m.jump = nil
+		m.at = &keyAnchor{key: p.input.Value(), before: m.cfg.DB.OpenBatchSize / 2}
+		m.offset = 0
+		return m, m.fetchKeysCmd()
+	case "tab", "shift+tab":
+		if len(p.completions) == 0 {
+			return m, nil
+		}
+		// Extend to the shared part first, then cycle through the candidates
+		if shared := sharedPrefix(p.completions); p.choice < 0 && len(shared) > len(p.input.Value()) {
+			p.input.SetValue(shared)
+			p.input.CursorEnd()
+			return m, m.completeCmd()
+		}
+		n := len(p.completions)
+		if msg.String() == "tab" {
+			p.choice = (p.choice + 1) % n
+		} else {
+			p.choice = (p.choice - 1 + n) % n
+		}
+		p.input.SetValue(p.completions[p.choice])
+		p.input.CursorEnd()
+		return m, nil
+	}
+
+	old := p.input.Value()
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	if p.input.Value() != old {
+		p.choice = -1
+		return m, tea.Batch(cmd, m.completeCmd())
+	}
+	return m, cmd
+}
+
+// jumpView renders the prompt with its completions.
+func (m DBMainModel) jumpView() string {
+	p := m.jump
+	var parts []string
+	for i, c := range p.completions {
+		if i == p.choice {
+			parts = append(parts, m.styles.Highlight.Render(c))
+		} else {
+			parts = append(parts, m.styles.Dimmed.Render(c))
+		}
+	}
+	if p.more {
+		parts = append(parts, m.styles.Dimmed.Render("…"))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		p.input.View(),
+		strings.Join(parts, "  "),
+		m.styles.Help.Render("Enter: Go | Tab/Shift+Tab: Complete | Esc: Cancel"),
+	)
+}
+
+// fetchWindow loads the page around the anchor of the list.
+func (m DBMainModel) fetchWindow(at keyAnchor, opts db.ListKeysOptions) KeysFetchedMsg {
+	var w db.KeyWindow
+	var err error
+	if at.after {
+		w, err = m.dbClient.KeysAfter(opts, at.key)
+	} else {
+		w, err = m.dbClient.KeysAround(opts, at.key, at.before)
+	}
+	if err != nil {
+		return KeysFetchedMsg{Err: err}
+	}
+	msg := KeysFetchedMsg{Keys: w.Items, HasMore: w.HasMore, HasLess: w.HasLess}
+	if !at.after && w.Index < len(w.Items) {
+		msg.Focus = w.Items[w.Index].Key
+	}
+	return msg
+}
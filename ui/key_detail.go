@@ -4,12 +4,14 @@ import (
 	"encoding/hex"
 	"fmt"
 
+	"badger_explorer_core/codec"
 	"badger_explorer_core/config"
 	"badger_explorer_core/db"
 	"badger_explorer_core/locale"
 	"badger_explorer_core/pkg"
 
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -21,10 +23,31 @@ type DetailModel struct {
 	styles   pkg.Styles
 
 	key       string
-	value     []byte
-	isHex     bool
+	value     []byte // Whole value, or the current page when paged
 	isEditing bool
 
+	// Paging for values larger than UIConfig.ValuePageSize
+	paged      bool
+	pageOffset int64
+	total      int64
+	fullLoad   bool // Load the whole value regardless of the page size
+
+	codecName   string // Selected codec, "auto" detects per value
+	activeCodec string // Codec chain actually used for the current content
+	editCodec   string // Codec chain the textarea content is encoded with on save
+	decodeErr   error
+	decodedText string // Text of the last successful decode, source of the JSON tree
+
+	// JSON tree view, nil when showing the plain viewport
+	tree    *jsonTree
+	queryIn textinput.Model
+
+	// New key prompt of rename and copy
+	keyOp string // "rename" or "copy" while the prompt is open
+	keyIn textinput.Model
+
+	copying bool // Asking for the format to copy the value in
+
 	viewport viewport.Model
 	textarea textarea.Model
 
@@ -43,16 +66,39 @@ func NewDetailModel(client *db.DBClient, cfg *config.Config, key string) DetailM
 
 	vp := viewport.New(0, 0)
 
+	qi := textinput.New()
+	qi.Placeholder = "$.path or .path[0] (JSONPath)"
+	qi.Prompt = "Query: "
+	qi.CharLimit = 256
+
+	ki := textinput.New()
+	ki.CharLimit = 1024
+
 	return DetailModel{
-		dbClient: client,
-		cfg:      cfg,
-		styles:   pkg.DefaultStyles(),
-		key:      key,
-		textarea: ta,
-		viewport: vp,
+		dbClient:  client,
+		cfg:       cfg,
+		styles:    pkg.DefaultStyles(),
+		key:       key,
+		codecName: keyCodec(cfg, key),
+		textarea:  ta,
+		viewport:  vp,
+		queryIn:   qi,
+		keyIn:     ki,
 	}
 }
 
+// keyCodec returns the codec a key is shown with. Key-pattern rules take
+// precedence over the default codec.
+func keyCodec(cfg *config.Config, key string) string {
+	if name := cfg.CodecFor(key); name != "" {
+		return name
+	}
+	if cfg.Codec.DefaultCodec != "" {
+		return cfg.Codec.DefaultCodec
+	}
+	return codec.Auto
+}
+
 func (m DetailModel) Init() tea.Cmd {
 	return m.fetchValueCmd()
 }
@@ -63,6 +109,19 @@ func (m DetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.keyOp != "" {
+			return m.updateKeyPrompt(msg)
+		}
+		if m.copying {
+			m.copying = false
+			if format, ok := copyFormatKeys[msg.String()]; ok {
+				return m, copyValueCmd(m.dbClient, m.key, format, m.codecName)
+			}
+			return m, nil
+		}
+		if m.tree != nil && !m.isEditing {
+			return m.updateTree(msg)
+		}
 		if m.isEditing {
 			switch msg.String() {
 			case "esc":
@@ -70,16 +129,55 @@ func (m DetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.textarea.Blur()
 				return m, nil
 			case "ctrl+s":
-				// Save
-				return m, m.saveValueCmd()
+				// Re-encode to the original format; parse errors keep the editor open
+				data, err := codec.Encode(m.editCodec, m.textarea.Value(), m.value)
+				if err != nil {
+					m.err = fmt.Errorf("cannot save: %w", err)
+					return m, nil
+				}
+				m.err = nil
+				return m, m.saveValueCmd(data)
 			}
 		} else {
 			switch msg.String() {
 			case "esc":
 				return m, func() tea.Msg { return BackToMainMsg{} }
+			case "]", "n":
+				if m.paged && m.pageOffset+int64(len(m.value)) < m.total {
+					m.pageOffset += int64(len(m.value))
+					return m, m.fetchValueCmd()
+				}
+			case "[", "p":
+				if m.paged && m.pageOffset > 0 {
+					m.pageOffset -= int64(m.cfg.UI.ValuePageSize)
+					if m.pageOffset < 0 {
+						m.pageOffset = 0
+					}
+					return m, m.fetchValueCmd()
+				}
+			case "L":
+				if m.paged {
+					m.fullLoad = true
+					return m, m.fetchValueCmd()
+				}
 			case "e":
+				if m.paged {
+					m.err = fmt.Errorf("value is shown in pages; press L to load it fully before editing")
+					return m, nil
+				}
+				// Edit through the active codec; values it cannot round-trip are edited as hex
+				m.editCodec = m.activeCodec
+				if !codec.CanEdit(m.editCodec) {
+					m.editCodec = "hex"
+				}
+				text, err := codec.EditText(m.editCodec, m.value)
+				if err != nil {
+					m.editCodec = "hex"
+					text, _ = codec.EditText(m.editCodec, m.value)
+				}
 				m.isEditing = true
-				m.textarea.SetValue(string(m.value)) // Assuming UTF-8 for edit
+				m.err = nil
+				m.textarea.SetValue(text)
 				m.textarea.Focus()
 				return m, textarea.Blink
 			case "d":
@@ -87,8 +185,56 @@ func (m DetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// For now, just delete
 				return m, m.deleteKeyCmd()
 			case "h":
-				m.isHex = !m.isHex
+				if m.codecName == "hex" {
+					m.codecName = codec.Auto
+				} else {
+					m.codecName = "hex"
+				}
+				m.updateContent()
+			case "c":
+				m.codecName = codec.Next(m.codecName)
 				m.updateContent()
+			case "b":
+				if m.cfg.ToggleBookmark(m.dbClient.GetPath(), m.key) {
+					m.msg = "Bookmarked"
+				} else {
+					m.msg = "Bookmark removed"
+				}
+				m.err = m.cfg.Save()
+				return m, nil // Also pages up in the viewport
+			case "y":
+				return m, copyTextCmd(m.key, "key")
+			case "Y":
+				m.copying = true
+				m.err = nil
+				m.msg = ""
+			case "R", "C":
+				m.keyOp = "rename"
+				m.keyIn.Prompt = "Rename to: "
+				if msg.String() == "C" {
+					m.keyOp = "copy"
+					m.keyIn.Prompt = "Copy to: "
+				}
+				m.keyIn.SetValue(m.key)
+				m.keyIn.CursorEnd()
+				m.err = nil
+				m.msg = ""
+				return m, m.keyIn.Focus()
+			case "t":
+				if m.paged {
+					m.err = fmt.Errorf("value is shown in pages; press L to load it fully first")
+					return m, nil
+				}
+				tree, err := newJSONTree(m.decodedText)
+				if err != nil {
+					m.err = fmt.Errorf("not a JSON document: %w", err)
+					return m, nil
+				}
+				tree.setHeight(m.viewport.Height - 1) // Breadcrumb line
+				m.tree = tree
+				m.err = nil
+				m.queryIn.SetValue("")
+				return m, nil
 			}
 		}
 
@@ -103,15 +249,30 @@ func (m DetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewport.Height = msg.Height - verticalMarginHeight - 2 // Border
 		m.textarea.SetWidth(msg.Width - 4)
 		m.textarea.SetHeight(msg.Height - verticalMarginHeight - 2)
+		if m.tree != nil {
+			m.tree.setHeight(m.viewport.Height - 1)
+		}
 
 	case ValueFetchedMsg:
 		if msg.Err != nil {
 			m.err = msg.Err
 		} else {
 			m.value = msg.Value
+			m.pageOffset = msg.Offset
+			m.total = msg.Total
+			m.paged = int64(len(msg.Value)) < msg.Total
+			m.err = nil
 			m.updateContent()
 		}
 
+	case CopiedMsg:
+		if msg.Err != nil {
+			m.err = fmt.Errorf("copy failed: %w", msg.Err)
+		} else {
+			m.err = nil
+			m.msg = msg.status()
+		}
+
 	case OperationResultMsg:
 		if msg.Err != nil {
 			m.err = msg.Err
@@ -122,7 +283,8 @@ func (m DetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			if msg.Op == "save" {
 				m.isEditing = false
-				m.fetchValueCmd() // Reload
+				m.textarea.Blur()
+				return m, m.fetchValueCmd() // Reload
 			}
 		}
 	}
@@ -138,33 +300,149 @@ func (m DetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+func (m DetailModel) updateKeyPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.keyOp = ""
+		m.keyIn.Blur()
+		return m, nil
+	case "enter":
+		op, newKey := m.keyOp, m.keyIn.Value()
+		m.keyOp = ""
+		m.keyIn.Blur()
+		return m, m.renameKeyCmd(op, newKey)
+	}
+	var cmd tea.Cmd
+	m.keyIn, cmd = m.keyIn.Update(msg)
+	return m, cmd
+}
+
 func (m *DetailModel) updateContent() {
-	content := ""
-	if m.isHex {
-		content = hex.Dump(m.value)
-	} else {
-		content = string(m.value)
+	d, err := codec.Decode(m.codecName, m.value)
+	m.decodeErr = err
+	if err != nil {
+		// Fall back to a hex dump so the value is still visible
+		m.activeCodec = "hex"
+		m.decodedText = ""
+		m.viewport.SetContent(hex.Dump(m.value))
+		return
 	}
-	m.viewport.SetContent(content)
+	m.activeCodec = d.Codec
+	m.decodedText = d.Text
+	m.viewport.SetContent(d.Text)
+}
+
+// updateTree handles keys while the JSON tree view is open.
+func (m DetailModel) updateTree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.queryIn.Focused() {
+		switch msg.String() {
+		case "esc":
+			m.queryIn.Blur()
+			return m, nil
+		case "enter":
+			m.queryIn.Blur()
+			m.runQuery()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.queryIn, cmd = m.queryIn.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "esc", "t":
+		m.tree = nil
+		m.err = nil
+	case "up", "k":
+		m.tree.move(-1)
+	case "down", "j":
+		m.tree.move(1)
+	case "pgup":
+		m.tree.move(-m.tree.height)
+	case "pgdown":
+		m.tree.move(m.tree.height)
+	case "enter", " ":
+		m.tree.toggle()
+	case "right", "l":
+		m.tree.expand()
+	case "left":
+		m.tree.collapse()
+	case "/":
+		m.queryIn.Focus()
+		return m, textinput.Blink
+	case "y":
+		return m, copyTextCmd(m.tree.current().path, "path")
+	case "Y":
+		return m, copyTextCmd(m.tree.current().marshal(), "value")
+	}
+	return m, nil
+}
+
+// runQuery filters the tree to the result of the query box; an empty query restores the document.
+func (m *DetailModel) runQuery() {
+	text := m.decodedText
+	if q := m.queryIn.Value(); q != "" {
+		result, err := codec.QueryJSONPretty(m.decodedText, q)
+		if err != nil {
+			m.err = err
+			return
+		}
+		text = result
+	}
+
+	tree, err := newJSONTree(text)
+	if err != nil {
+		m.err = err
+		return
+	}
+	tree.setHeight(m.tree.height)
+	m.tree = tree
+	m.err = nil
 }
 
 func (m DetailModel) View() string {
 	// Title
 	title := m.styles.Title.Render(fmt.Sprintf("Key: %s", m.key))
+	if m.cfg.IsBookmarked(m.dbClient.GetPath(), m.key) {
+		title += " ★"
+	}
+	info := fmt.Sprintf("[%s → %s]", m.codecName, m.activeCodec)
+	if m.paged {
+		end := m.pageOffset + int64(len(m.value))
+		info += fmt.Sprintf(" bytes %d–%d of %d", m.pageOffset, end, m.total)
+	}
+	codecInfo := m.styles.Dimmed.Render(info)
+	title = lipgloss.JoinHorizontal(lipgloss.Top, title, " ", codecInfo)
 
 	// Status Message
 	status := ""
 	if m.err != nil {
 		status = m.styles.Error.Render(m.err.Error())
+	} else if m.decodeErr != nil {
+		status = m.styles.Error.Render(m.decodeErr.Error())
 	} else if m.msg != "" {
 		status = m.styles.Success.Render(m.msg)
 	}
+	if m.keyOp != "" {
+		status = m.keyIn.View()
+	}
+	if m.copying {
+		status = m.styles.Highlight.Render(copyFormatHelp)
+	}
 
 	// Content
 	var content string
 	if m.isEditing {
 		content = m.textarea.View()
 		content = m.styles.Focused.Render(content)
+	} else if m.tree != nil {
+		crumb := m.styles.Highlight.Render(m.tree.current().path)
+		if m.queryIn.Focused() || m.queryIn.Value() != "" {
+			crumb = m.queryIn.View() + "  " + crumb
+		}
+		selected := lipgloss.NewStyle().Foreground(lipgloss.Color(pkg.ColorBackground)).Background(lipgloss.Color(pkg.ColorPink))
+		content = crumb + "\n" + m.tree.View(m.viewport.Width, selected, m.styles.Dimmed)
+		content = m.styles.Border.Render(content)
 	} else {
 		content = m.viewport.View()
 		content = m.styles.Border.Render(content)
@@ -173,9 +451,13 @@ func (m DetailModel) View() string {
 	// Footer
 	var help string
 	if m.isEditing {
-		help = m.styles.Help.Render("Ctrl+S: Save | Esc: Cancel")
+		help = m.styles.Help.Render(fmt.Sprintf("Editing as %s | Ctrl+S: Save | Esc: Cancel", m.editCodec))
+	} else if m.tree != nil {
+		help = m.styles.Help.Render("↑/↓: Move | Enter: Fold | ←/→: Collapse/Expand | /: Query | y: Copy Path | Y: Copy Value | Esc: Close Tree")
+	} else if m.paged {
+		help = m.styles.Help.Render("[/]: Prev/Next Page | L: Load All | d: Delete | h: Toggle Hex | c: Cycle Codec | y/Y: Copy Key/Value | b: Pin | R/C: Rename/Copy | Esc: Back")
 	} else {
-		help = m.styles.Help.Render("e: Edit | d: Delete | h: Toggle Hex | Esc: Back")
+		help = m.styles.Help.Render("e: Edit | d: Delete | h: Toggle Hex | c: Cycle Codec | t: JSON Tree | y/Y: Copy Key/Value | b: Pin | R/C: Rename/Copy | Esc: Back")
 	}
 
 	view := lipgloss.JoinVertical(lipgloss.Left,
@@ -191,14 +473,25 @@ func (m DetailModel) View() string {
 // Commands
 
 type ValueFetchedMsg struct {
-	Value []byte
-	Err   error
+	Value  []byte
+	Offset int64 // Start of Value within the whole value
+	Total  int64 // Size of the whole value
+	Err    error
 }
 
+// fetchValueCmd loads the page at pageOffset, or the whole value when paging is off.
 func (m DetailModel) fetchValueCmd() tea.Cmd {
+	pageSize := int64(m.cfg.UI.ValuePageSize)
+	if m.fullLoad {
+		pageSize = 0
+	}
+	offset := m.pageOffset
+	if pageSize <= 0 {
+		offset = 0
+	}
 	return func() tea.Msg {
-		val, err := m.dbClient.GetValue(m.key)
-		return ValueFetchedMsg{Value: val, Err: err}
+		val, total, err := m.dbClient.GetValueRange(m.key, offset, pageSize)
+		return ValueFetchedMsg{Value: val, Offset: offset, Total: total, Err: err}
 	}
 }
 
@@ -208,7 +501,7 @@ type OperationResultMsg struct {
 	Err     error
 }
 
-func (m DetailModel) saveValueCmd() tea.Cmd {
+func (m DetailModel) saveValueCmd(data []byte) tea.Cmd {
 	return func() tea.Msg {
 		// Auto backup
 		if m.cfg.DB.AutoBackupOnWrite {
@@ -219,7 +512,7 @@ func (m DetailModel) saveValueCmd() tea.Cmd {
 		}
 
 		// Save
-		err := m.dbClient.SetValue(m.key, []byte(m.textarea.Value()), 0) // TTL 0 for now
+		err := m.dbClient.SetValue(m.key, data, 0) // TTL 0 for now
 		if err != nil {
 			return OperationResultMsg{Op: "save", Err: err}
 		}
@@ -237,4 +530,21 @@ func (m DetailModel) deleteKeyCmd() tea.Cmd {
 	}
 }
 
+// renameKeyCmd renames or copies the key; a renamed key is reopened under its new name.
+func (m DetailModel) renameKeyCmd(op, newKey string) tea.Cmd {
+	client, key := m.dbClient, m.key
+	return func() tea.Msg {
+		if op == "copy" {
+			if err := client.CopyKey(key, newKey, false); err != nil {
+				return OperationResultMsg{Op: op, Err: err}
+			}
+			return OperationResultMsg{Op: op, Message: fmt.Sprintf("Copied to %s", newKey)}
+		}
+		if err := client.RenameKey(key, newKey, false); err != nil {
+			return OperationResultMsg{Op: op, Err: err}
+		}
+		return OpenDetailMsg{Key: newKey}
+	}
+}
+
 type BackToMainMsg struct{}
@@ -0,0 +1,270 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"badger_explorer_core/codec"
+	"badger_explorer_core/config"
+	"badger_explorer_core/pkg"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SavedQueriesModel lists the saved queries and applies one to the key list.
+type SavedQueriesModel struct {
+	cfg    *config.Config
+	styles pkg.Styles
+
+	queries []config.SavedQuery
+	cursor  int
+	current config.SavedQuery // Search of the key list, used for new queries
+
+	// Query editor
+	isEditing bool
+	editIndex int // -1 for a new query
+	inputs    []textinput.Model
+	focus     int
+
+	err error
+}
+
+// Editor fields
+const (
+	queryName = iota
+	queryTerm
+	queryMode
+	querySort
+	queryCase
+	queryCodec
+)
+
+func NewSavedQueriesModel(cfg *config.Config, current config.SavedQuery) SavedQueriesModel {
+	inputs := make([]textinput.Model, 6)
+	prompts := []string{"Name: ", "Term: ", "Mode (prefix/substring/regex): ", "Sort (asc/desc): ", "Ignore case (true/false): ", "Codec filter: "}
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Prompt = prompts[i]
+	}
+	inputs[queryCodec].Placeholder = "any"
+
+	return SavedQueriesModel{
+		cfg:       cfg,
+		styles:    pkg.DefaultStyles(),
+		queries:   cfg.GetSavedQueries(),
+		current:   current,
+		inputs:    inputs,
+		editIndex: -1,
+	}
+}
+
+func (m SavedQueriesModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m SavedQueriesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.isEditing {
+		return m.updateEditor(key)
+	}
+
+	switch key.String() {
+	case "esc":
+		return m, func() tea.Msg { return BackToMainMsg{} }
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.queries)-1 {
+			m.cursor++
+		}
+	case "enter":
+		if len(m.queries) > 0 {
+			q := m.queries[m.cursor]
+			return m, func() tea.Msg { return ApplySavedQueryMsg{Query: q} }
+		}
+	case "a":
+		return m, m.openEditor(-1)
+	case "e":
+		if len(m.queries) > 0 {
+			return m, m.openEditor(m.cursor)
+		}
+	case "d":
+		if len(m.queries) > 0 {
+			m.cfg.DeleteSavedQuery(m.queries[m.cursor].Name)
+			m.err = m.cfg.Save()
+			m.queries = m.cfg.GetSavedQueries()
+			if m.cursor >= len(m.queries) && m.cursor > 0 {
+				m.cursor--
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *SavedQueriesModel) openEditor(index int) tea.Cmd {
+	m.isEditing = true
+	m.editIndex = index
+	m.err = nil
+
+	q := m.current
+	q.Name = ""
+	if index >= 0 {
+		q = m.queries[index]
+	}
+	sort := "asc"
+	if q.SortDesc {
+		sort = "desc"
+	}
+	m.inputs[queryName].SetValue(q.Name)
+	m.inputs[queryTerm].SetValue(q.Term)
+	m.inputs[queryMode].SetValue(q.Mode)
+	m.inputs[querySort].SetValue(sort)
+	m.inputs[queryCase].SetValue(strconv.FormatBool(q.IgnoreCase))
+	m.inputs[queryCodec].SetValue(q.Codec)
+
+	m.focus = queryName
+	for i := range m.inputs {
+		m.inputs[i].Blur()
+	}
+	return m.inputs[queryName].Focus()
+}
+
+func (m SavedQueriesModel) updateEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.isEditing = false
+		return m, nil
+	case "tab", "down":
+		m.inputs[m.focus].Blur()
+		m.focus = (m.focus + 1) % len(m.inputs)
+		return m, m.inputs[m.focus].Focus()
+	case "shift+tab", "up":
+		m.inputs[m.focus].Blur()
+		m.focus = (m.focus - 1 + len(m.inputs)) % len(m.inputs)
+		return m, m.inputs[m.focus].Focus()
+	case "enter":
+		q, err := m.queryFromInputs()
+		if err != nil {
+			m.err = err
+			return m, nil
+		}
+		// Renaming replaces the old entry
+		if m.editIndex >= 0 && m.queries[m.editIndex].Name != q.Name {
+			m.cfg.DeleteSavedQuery(m.queries[m.editIndex].Name)
+		}
+		m.cfg.SaveQuery(q)
+		m.err = m.cfg.Save()
+		m.queries = m.cfg.GetSavedQueries()
+		for i := range m.queries {
+			if m.queries[i].Name == q.Name {
+				m.cursor = i
+			}
+		}
+		m.isEditing = false
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+// queryFromInputs validates the editor fields.
+func (m SavedQueriesModel) queryFromInputs() (config.SavedQuery, error) {
+	q := config.SavedQuery{
+		Name:  strings.TrimSpace(m.inputs[queryName].Value()),
+		Term:  m.inputs[queryTerm].Value(),
+		Mode:  strings.TrimSpace(m.inputs[queryMode].Value()),
+		Codec: strings.TrimSpace(m.inputs[queryCodec].Value()),
+	}
+	if q.Name == "" {
+		return q, fmt.Errorf("name cannot be empty")
+	}
+	switch q.Mode {
+	case "":
+		q.Mode = "prefix"
+	case "prefix", "substring", "regex":
+	default:
+		return q, fmt.Errorf("unknown mode: %s", q.Mode)
+	}
+	switch strings.TrimSpace(m.inputs[querySort].Value()) {
+	case "", "asc":
+	case "desc":
+		q.SortDesc = true
+	default:
+		return q, fmt.Errorf("sort must be asc or desc")
+	}
+	if v := strings.TrimSpace(m.inputs[queryCase].Value()); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, fmt.Errorf("ignore case must be true or false")
+		}
+		q.IgnoreCase = b
+	}
+	if q.Codec != "" && !strings.HasPrefix(q.Codec, "protobuf:") {
+		if _, ok := codec.Get(q.Codec); !ok {
+			return q, fmt.Errorf("unknown codec: %s", q.Codec)
+		}
+	}
+	return q, nil
+}
+
+func (m SavedQueriesModel) View() string {
+	s := strings.Builder{}
+
+	s.WriteString(m.styles.Title.Render("Saved Queries") + "\n\n")
+
+	if m.err != nil {
+		s.WriteString(m.styles.Error.Render(m.err.Error()) + "\n")
+	}
+
+	if m.isEditing {
+		for i := range m.inputs {
+			s.WriteString(m.inputs[i].View() + "\n")
+		}
+		s.WriteString("\n" + m.styles.Help.Render("Enter: Save | Tab/Arrows: Navigate | Esc: Cancel"))
+		return s.String()
+	}
+
+	if len(m.queries) == 0 {
+		s.WriteString(m.styles.Dimmed.Render("No saved queries. Press a to save the current search.") + "\n")
+	}
+	for i, q := range m.queries {
+		line := fmt.Sprintf("%s: %q [%s]", q.Name, q.Term, q.Mode)
+		if q.SortDesc {
+			line += " desc"
+		}
+		if q.IgnoreCase {
+			line += " ignore-case"
+		}
+		if q.Codec != "" {
+			line += " codec:" + q.Codec
+		}
+		if i == m.cursor {
+			s.WriteString(m.styles.Highlight.Render("> "+line) + "\n")
+		} else {
+			s.WriteString("  " + line + "\n")
+		}
+	}
+
+	s.WriteString("\n" + m.styles.Help.Render("Enter: Apply | a: Save Current Search | e: Edit | d: Delete | Esc: Back"))
+
+	return s.String()
+}
+
+// OpenSavedQueriesMsg opens the picker; Current is the search of the key list.
+type OpenSavedQueriesMsg struct {
+	Current config.SavedQuery
+}
+
+// ApplySavedQueryMsg runs a saved query in the key list.
+type ApplySavedQueryMsg struct {
+	Query config.SavedQuery
+}